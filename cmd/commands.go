@@ -0,0 +1,411 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"giiny/internal/bot"
+	"giiny/internal/config"
+	"giiny/internal/gemini"
+	"giiny/internal/imvu"
+	"giiny/internal/secrets"
+	"giiny/internal/store"
+	"giiny/internal/telemetry"
+
+	"github.com/gorilla/websocket"
+)
+
+// newFlagSet creates the flag set of a subcommand with the common -config flag
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+
+	defaultPath := os.Getenv("CONFIG_PATH")
+	if defaultPath == "" {
+		defaultPath = "../config.json"
+	}
+	configPath := fs.String("config", defaultPath, "path to the configuration file")
+
+	return fs, configPath
+}
+
+// clientOptions translates the configuration into options for the IMVU client
+func clientOptions(cfg *config.Config) ([]imvu.ClientOption, error) {
+	var tlsConfig *tls.Config
+	if t := cfg.ClientTLS; t.CertFile != "" || t.CAFile != "" {
+		var err error
+		tlsConfig, err = imvu.ClientTLSConfig(t.CertFile, t.KeyFile, t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dialer := &websocket.Dialer{
+		HandshakeTimeout:  time.Duration(cfg.IMQ.HandshakeTimeoutSeconds) * time.Second,
+		EnableCompression: cfg.IMQ.EnableCompression,
+		TLSClientConfig:   tlsConfig,
+	}
+	if len(cfg.IMQ.PinnedSHA256) > 0 {
+		pinned := imvu.PinnedTLSConfig(cfg.IMQ.PinnedSHA256)
+		if tlsConfig != nil {
+			pinned.Certificates = tlsConfig.Certificates
+			pinned.RootCAs = tlsConfig.RootCAs
+		}
+		dialer.TLSClientConfig = pinned
+	}
+	if cfg.IMQ.UseProxy {
+		dialer.Proxy = http.ProxyFromEnvironment
+	}
+
+	options := []imvu.ClientOption{
+		imvu.WithEndpoints(cfg.Endpoints),
+		imvu.WithDialer(dialer),
+		imvu.WithRateLimits(cfg.RateLimits),
+		imvu.WithIMQMaxMessageSize(int64(cfg.IMQ.MaxMessageKB) << 10),
+	}
+	if tlsConfig != nil {
+		options = append(options, imvu.WithTLSConfig(tlsConfig))
+	}
+	if cfg.HTTPCache.Enabled {
+		options = append(options, imvu.WithCache(cfg.HTTPCache.MaxEntries, cfg.HTTPCache.Dir))
+	}
+
+	switch cfg.Captcha.Solver {
+	case "prompt":
+		options = append(options, imvu.WithCaptchaSolver(imvu.PromptSolver{In: os.Stdin, Out: os.Stderr}))
+	case "service":
+		options = append(options, imvu.WithCaptchaSolver(imvu.ServiceSolver{
+			URL:    cfg.Captcha.ServiceURL,
+			APIKey: cfg.Captcha.APIKey,
+		}))
+	}
+
+	return options, nil
+}
+
+// configureClient applies the settings of a created IMVU client
+func configureClient(client *imvu.IMVU, cfg *config.Config) {
+	client.SetMaxMessageBytes(cfg.MaxMessageBytes)
+	client.SetUserCacheTTL(time.Duration(cfg.UserCacheSeconds) * time.Second)
+	client.SetBots(cfg.Bots)
+	client.SetEchoTimeout(time.Duration(cfg.IMQ.EchoTimeoutSeconds) * time.Second)
+	client.SetStaleChatTimeout(time.Duration(cfg.IMQ.StaleChatMinutes) * time.Minute)
+}
+
+// login loads the configuration and returns a logged in IMVU client. Callers
+// must Close the client.
+func login(configPath string) (*config.Config, *imvu.IMVU, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	options, err := clientOptions(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to configure IMVU client: %w", err)
+	}
+	client, err := imvu.New(options...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create IMVU instance: %w", err)
+	}
+	configureClient(client, cfg)
+
+	if err := client.Login(cfg.Username, cfg.Password); err != nil {
+		return nil, nil, loginError(err)
+	}
+
+	return cfg, client, nil
+}
+
+// loginError adds a hint to the login errors the user has to act on
+func loginError(err error) error {
+	switch {
+	case errors.Is(err, imvu.ErrInvalidCredentials):
+		return fmt.Errorf("%w (check the username and password)", err)
+	case errors.Is(err, imvu.ErrCaptchaRequired):
+		return fmt.Errorf("%w (set captcha.solver in the config or log in from a browser once)", err)
+	}
+	return err
+}
+
+func cmdRun(args []string) error {
+	fs, configPath := newFlagSet("run")
+	dryRun := fs.Bool("dry-run", false, "log replies and actions instead of sending them to the room")
+	fs.Parse(args)
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if *dryRun {
+		cfg.DryRun = true
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	shutdownTracing, err := telemetry.Start(context.Background(), cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to start tracing: %w", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	st, err := store.Open(cfg.DatabasePath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer st.Close()
+
+	gemini.Start(cfg.Gemini)
+
+	options, err := clientOptions(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to configure IMVU client: %w", err)
+	}
+	client, err := imvu.New(options...)
+	if err != nil {
+		return fmt.Errorf("failed to create IMVU instance: %w", err)
+	}
+	client.SetDryRun(cfg.DryRun)
+	configureClient(client, cfg)
+
+	ownerID, chatroomID := getRoomIDsFromURL(cfg.RoomURL)
+
+	return loginError(bot.Start(cfg, ownerID, chatroomID, client, st))
+}
+
+func cmdLoginTest(args []string) error {
+	fs, configPath := newFlagSet("login-test")
+	fs.Parse(args)
+
+	cfg, client, err := login(*configPath)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	fmt.Printf("Login successful as %s (user %s)\n", cfg.Username, client.UserID())
+	return nil
+}
+
+func cmdSend(args []string) error {
+	fs, configPath := newFlagSet("send")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: giiny send [flags] <room> <message>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return errors.New("missing room or message")
+	}
+
+	ownerID, chatroomID := getRoomIDs(fs.Arg(0))
+	if ownerID == "" {
+		return fmt.Errorf("invalid room: %s", fs.Arg(0))
+	}
+	message := strings.Join(fs.Args()[1:], " ")
+
+	_, client, err := login(*configPath)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if _, err := client.JoinRoom(ownerID, chatroomID); err != nil {
+		return err
+	}
+
+	if err := client.SendChatMessage(message); err != nil {
+		return err
+	}
+	log.Printf("Message sent to room %s-%s", ownerID, chatroomID)
+
+	return client.LeaveRoom(ownerID, chatroomID)
+}
+
+func cmdObserve(args []string) error {
+	fs, configPath := newFlagSet("observe")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: giiny observe [flags] [room]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	room := cfg.RoomURL
+	if fs.NArg() > 0 {
+		room = fs.Arg(0)
+	}
+	ownerID, chatroomID := getRoomIDs(room)
+	if ownerID == "" {
+		return fmt.Errorf("invalid room: %s", room)
+	}
+
+	st, err := store.Open(cfg.DatabasePath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer st.Close()
+
+	options, err := clientOptions(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to configure IMVU client: %w", err)
+	}
+	client, err := imvu.New(options...)
+	if err != nil {
+		return fmt.Errorf("failed to create IMVU instance: %w", err)
+	}
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return loginError(bot.Observe(ctx, cfg, ownerID, chatroomID, client, st))
+}
+
+func cmdRooms(args []string) error {
+	if len(args) == 0 || args[0] != "list" {
+		return errors.New("usage: giiny rooms list [-user <id>]")
+	}
+
+	fs, configPath := newFlagSet("rooms list")
+	userID := fs.String("user", "", "owner of the rooms (defaults to the logged in user)")
+	fs.Parse(args[1:])
+
+	_, client, err := login(*configPath)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if *userID == "" {
+		*userID = client.UserID()
+	}
+
+	rooms, err := client.Rooms(*userID)
+	if err != nil {
+		return err
+	}
+
+	for _, room := range rooms {
+		fmt.Printf("%s-%s\t%d/%d\t%s\n", room.OwnerID, room.ChatroomID, room.Occupancy, room.Capacity, room.Name)
+	}
+	return nil
+}
+
+func cmdWhoami(args []string) error {
+	fs, configPath := newFlagSet("whoami")
+	fs.Parse(args)
+
+	_, client, err := login(*configPath)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	user := client.User()
+	fmt.Printf("User ID:      %s\n", client.UserID())
+	fmt.Printf("Username:     %s\n", user.Username)
+	fmt.Printf("Display name: %s\n", user.DisplayName)
+	fmt.Printf("VIP:          %t\n", user.IsVIP)
+	fmt.Printf("AP:           %t\n", user.IsAP)
+	fmt.Printf("Created:      %s\n", user.Created)
+	return nil
+}
+
+func cmdConfig(args []string) error {
+	if len(args) == 0 || args[0] != "validate" {
+		return errors.New("usage: giiny config validate [-config <path>]")
+	}
+
+	fs, configPath := newFlagSet("config validate")
+	fs.Parse(args[1:])
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	if ownerID, _ := getRoomIDsFromURL(cfg.RoomURL); ownerID == "" {
+		return fmt.Errorf("invalid configuration: cannot parse room_url %q", cfg.RoomURL)
+	}
+
+	fmt.Println("Configuration is valid")
+	return nil
+}
+
+func cmdSecrets(args []string) error {
+	const usage = "usage: giiny secrets set [-file <path>] <name> (names: %s; the value is read from stdin)"
+	if len(args) == 0 || args[0] != "set" {
+		return fmt.Errorf(usage, strings.Join(secrets.Names, ", "))
+	}
+
+	fs := flag.NewFlagSet("secrets set", flag.ExitOnError)
+	path := fs.String("file", "../secrets.json", "path to the encrypted secrets file")
+	fs.Parse(args[1:])
+	if fs.NArg() != 1 {
+		return fmt.Errorf(usage, strings.Join(secrets.Names, ", "))
+	}
+	name := fs.Arg(0)
+
+	passphrase := os.Getenv(secrets.PassphraseEnv)
+	if passphrase == "" {
+		return fmt.Errorf("%s is not set", secrets.PassphraseEnv)
+	}
+
+	// The value is read from stdin to keep it out of the shell history
+	fmt.Fprintf(os.Stderr, "Value for %s (empty to remove it): ", name)
+	value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	value = strings.TrimSpace(value)
+
+	if err := secrets.Set(*path, passphrase, name, value); err != nil {
+		return err
+	}
+	fmt.Printf("Saved %s to %s\n", name, *path)
+	return nil
+}
+
+func cmdREPL(args []string) error {
+	fs, configPath := newFlagSet("repl")
+	userID := fs.String("user", "", "user ID whose memories are used (defaults to senpai)")
+	fs.Parse(args)
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	st, err := store.Open(cfg.DatabasePath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer st.Close()
+
+	gemini.Start(cfg.Gemini)
+
+	return bot.RunREPL(cfg, st, *userID, os.Stdin, os.Stdout)
+}
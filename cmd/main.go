@@ -1,36 +1,69 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"strings"
-
-	"giiny/internal/bot"
-	"giiny/internal/gemini"
-	"giiny/internal/imvu"
+	_ "time/tzdata"
 
 	"github.com/joho/godotenv"
 )
 
+const usage = `Usage: giiny <command> [flags] [args]
+
+Commands:
+  run                   connect to the configured room and start the bot (default)
+  login-test            log in and exit, to check the credentials
+  send <room> <msg>     join a room, send a single message and leave
+  observe [room]        log a room's chat and activity without joining it
+  rooms list            list the rooms owned by a user
+  whoami                show the account the credentials belong to
+  config validate       check the configuration file and environment
+  repl                  chat with the persona from the terminal, without IMVU
+  secrets set <name>    store the password or Gemini API key in the encrypted
+                        secrets file (passphrase in $GIINY_SECRETS_PASSPHRASE)
+
+Every command accepts -config <path> (defaults to $CONFIG_PATH or ../config.json).
+`
+
 func main() {
 	_ = godotenv.Load("../.env")
 
-	gemini.Start()
-
-	client, err := imvu.New()
-	if err != nil {
-		log.Fatalf("Failed to create IMVU instance: %v", err)
+	args := os.Args[1:]
+	if len(args) == 0 {
+		args = []string{"run"}
 	}
 
-	username := os.Getenv("USERNAME")
-	password := os.Getenv("PASSWORD")
-
-	roomURL := os.Getenv("ROOM_URL")
-	ownerID, chatroomID := getRoomIDsFromURL(roomURL)
+	var err error
+	switch args[0] {
+	case "run":
+		err = cmdRun(args[1:])
+	case "login-test":
+		err = cmdLoginTest(args[1:])
+	case "send":
+		err = cmdSend(args[1:])
+	case "observe":
+		err = cmdObserve(args[1:])
+	case "rooms":
+		err = cmdRooms(args[1:])
+	case "whoami":
+		err = cmdWhoami(args[1:])
+	case "config":
+		err = cmdConfig(args[1:])
+	case "repl":
+		err = cmdREPL(args[1:])
+	case "secrets":
+		err = cmdSecrets(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n%s", args[0], usage)
+		os.Exit(2)
+	}
 
-	err = bot.Start(username, password, ownerID, chatroomID, client)
 	if err != nil {
-		log.Fatalf("Something went wrong")
+		log.Fatalf("%s: %v", args[0], err)
 	}
 }
 
@@ -43,3 +76,12 @@ func getRoomIDsFromURL(roomURL string) (string, string) {
 
 	return roomURLSplit[1], roomURLSplit[2]
 }
+
+// getRoomIDs accepts either a room URL or a bare "<owner>-<chatroom>" pair
+func getRoomIDs(room string) (string, string) {
+	fields := strings.Split(room, "-")
+	if len(fields) == 2 && !strings.Contains(room, "/") {
+		return fields[0], fields[1]
+	}
+	return getRoomIDsFromURL(room)
+}
@@ -0,0 +1,155 @@
+// Package autoreply answers chat messages from declarative pattern rules
+// before they reach the LLM, for FAQ answers and inside jokes.
+package autoreply
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Match kinds of a Rule
+const (
+	MatchExact     = "exact"
+	MatchSubstring = "substring"
+	MatchRegex     = "regex"
+)
+
+// Rule maps a pattern to a response. Exact and substring patterns are case
+// insensitive. The response is a text/template executed with Data; the
+// "gemini" function sends its arguments, joined, as a prompt to the LLM.
+type Rule struct {
+	Match           string `json:"match"`
+	Pattern         string `json:"pattern"`
+	Response        string `json:"response"`
+	CooldownSeconds int    `json:"cooldown_seconds,omitempty"`
+}
+
+// Data is passed to response templates
+type Data struct {
+	UserID  string
+	Message string
+	// Groups holds the submatches of regex rules, Groups[0] being the whole
+	// match
+	Groups []string
+}
+
+// Generator produces an LLM answer to the prompt on behalf of a user
+type Generator func(userID, prompt string) (string, error)
+
+type rule struct {
+	Rule
+	re       *regexp.Regexp
+	tmpl     *template.Template
+	cooldown time.Duration
+}
+
+// Engine evaluates the rules in order; the first matching rule that is not
+// cooling down answers
+type Engine struct {
+	rules    []rule
+	generate Generator
+
+	mu        sync.Mutex
+	lastFired map[int]time.Time
+}
+
+// New compiles the rules. generate may be nil when the responses don't use the
+// gemini function.
+func New(rules []Rule, generate Generator) (*Engine, error) {
+	e := &Engine{
+		generate:  generate,
+		lastFired: map[int]time.Time{},
+	}
+
+	for i, r := range rules {
+		compiled := rule{Rule: r, cooldown: time.Duration(r.CooldownSeconds) * time.Second}
+
+		switch r.Match {
+		case MatchExact, MatchSubstring:
+		case MatchRegex:
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: invalid regex: %w", i, err)
+			}
+			compiled.re = re
+		default:
+			return nil, fmt.Errorf("rule %d: unknown match %q", i, r.Match)
+		}
+
+		if r.Pattern == "" {
+			return nil, fmt.Errorf("rule %d: pattern is not set", i)
+		}
+
+		tmpl, err := template.New(fmt.Sprintf("rule %d", i)).
+			Funcs(template.FuncMap{"gemini": func(string, ...string) (string, error) { return "", nil }}).
+			Parse(r.Response)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: invalid response template: %w", i, err)
+		}
+		compiled.tmpl = tmpl
+
+		e.rules = append(e.rules, compiled)
+	}
+
+	return e, nil
+}
+
+// Reply returns the response of the first matching rule, or false when no
+// rule matches
+func (e *Engine) Reply(userID, text string, now time.Time) (string, bool, error) {
+	for i, r := range e.rules {
+		groups, ok := r.match(text)
+		if !ok || !e.fire(i, r.cooldown, now) {
+			continue
+		}
+
+		tmpl, err := r.tmpl.Clone()
+		if err != nil {
+			return "", true, fmt.Errorf("failed to render response of rule %d: %w", i, err)
+		}
+		tmpl.Funcs(template.FuncMap{"gemini": func(prompt string, more ...string) (string, error) {
+			if e.generate == nil {
+				return "", fmt.Errorf("gemini is not available")
+			}
+			return e.generate(userID, strings.Join(append([]string{prompt}, more...), ""))
+		}})
+
+		data := Data{UserID: userID, Message: text, Groups: groups}
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, data); err != nil {
+			return "", true, fmt.Errorf("failed to render response of rule %d: %w", i, err)
+		}
+
+		return strings.TrimSpace(sb.String()), true, nil
+	}
+
+	return "", false, nil
+}
+
+func (r *rule) match(text string) ([]string, bool) {
+	switch r.Match {
+	case MatchExact:
+		return []string{text}, strings.EqualFold(strings.TrimSpace(text), r.Pattern)
+	case MatchSubstring:
+		return []string{text}, strings.Contains(strings.ToLower(text), strings.ToLower(r.Pattern))
+	default:
+		groups := r.re.FindStringSubmatch(text)
+		return groups, groups != nil
+	}
+}
+
+// fire records that the rule answers now unless it is still cooling down
+func (e *Engine) fire(i int, cooldown time.Duration, now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if last, ok := e.lastFired[i]; ok && now.Sub(last) < cooldown {
+		return false
+	}
+	e.lastFired[i] = now
+	return true
+}
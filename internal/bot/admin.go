@@ -0,0 +1,167 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"html/template"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"time"
+
+	"giiny/internal/imvu"
+)
+
+var statusPage = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="30">
+<title>Giiny status</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+th { text-align: left; padding-right: 2em; }
+</style>
+</head>
+<body>
+<h1>Giiny status</h1>
+<table>
+<tr><th>Version</th><td>{{.Version}}</td></tr>
+<tr><th>Uptime</th><td>{{.Uptime}}</td></tr>
+<tr><th>API</th><td>{{.API}}</td></tr>
+<tr><th>IMQ</th><td>{{.State}} for {{.StateFor}}</td></tr>
+<tr><th>Last IMQ message</th><td>{{.LastMessage}} ago</td></tr>
+<tr><th>Room</th><td>{{.Room}}</td></tr>
+<tr><th>Participants</th><td>{{.Participants}}</td></tr>
+<tr><th>Gemini latency</th><td>p50 {{.GeminiP50}}, p95 {{.GeminiP95}} ({{.GeminiCalls}} calls)</td></tr>
+<tr><th>Memory</th><td>{{.HeapMB}} MB heap, {{.SysMB}} MB from the OS</td></tr>
+<tr><th>Goroutines</th><td>{{.Goroutines}}</td></tr>
+<tr><th>Mood</th><td>{{.Mood}}</td></tr>
+<tr><th>Paused</th><td>{{.Paused}}</td></tr>
+<tr><th>Sleeping</th><td>{{.Sleeping}}</td></tr>
+</table>
+{{if .LookImage}}<h2>Look</h2>
+<p><a href="{{.LookImage}}"><img src="{{.LookImage}}" alt="Current look" height="300"></a></p>
+{{end}}<p><a href="/debug/vars">Metrics</a> · <a href="/stats?period=week">Room activity</a></p>
+</body>
+</html>
+`))
+
+// statusPageData is the status with a picture of the bot's current look,
+// which takes an API call and so is only shown on the status page
+type statusPageData struct {
+	status
+	LookImage string
+}
+
+func collectStatusPage(client *imvu.IMVU) statusPageData {
+	data := statusPageData{status: collectStatus(client)}
+	if client.ChatQueue() == "" {
+		return data
+	}
+	image, err := client.LookImage(client.UserID())
+	if err != nil {
+		log.Printf("Failed to get the look image of the bot: %v", err)
+		return data
+	}
+	data.LookImage = image
+	return data
+}
+
+// startAdmin serves the status page, the room activity as JSON, the expvar
+// metrics and, when enabled, the profiler on the configured address until the
+// context is cancelled. Without a token the server has no authentication, so
+// it should listen on a private address.
+func startAdmin(ctx context.Context, client *imvu.IMVU) {
+	if cfg.Load().Admin.Listen == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/status", http.StatusFound)
+	})
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := statusPage.Execute(w, collectStatusPage(client)); err != nil {
+			log.Printf("Failed to render the status page: %v", err)
+		}
+	})
+	mux.HandleFunc("GET /stats", func(w http.ResponseWriter, r *http.Request) {
+		since, ok := statsSince(r.URL.Query().Get("period"))
+		if !ok {
+			http.Error(w, "period must be today or week", http.StatusBadRequest)
+			return
+		}
+		room := r.URL.Query().Get("room")
+		if room == "" {
+			room = currentRoomKey()
+		}
+		activity, err := db.RoomActivitySince(room, since)
+		if err != nil {
+			log.Printf("Failed to read room activity: %v", err)
+			http.Error(w, "failed to read room activity", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(exportRoomStats(activity))
+	})
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	// The profiler exposes the command line and memory of the process, so it
+	// is never served without authentication
+	if cfg.Load().Admin.Pprof && cfg.Load().Admin.Token == "" {
+		log.Printf("Admin pprof is enabled without a token, not serving it")
+	} else if cfg.Load().Admin.Pprof {
+		mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+		mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+	}
+
+	var handler http.Handler = mux
+	if cfg.Load().Admin.Token != "" {
+		handler = requireToken(cfg.Load().Admin.Token, mux)
+	}
+
+	server := &http.Server{
+		Addr:              cfg.Load().Admin.Listen,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	go func() {
+		log.Printf("Admin server listening on %s", cfg.Load().Admin.Listen)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Admin server stopped: %v", err)
+		}
+	}()
+}
+
+// requireToken rejects the requests that don't carry the token as a bearer
+// token or as the password of basic auth, so a browser can log in too
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			_, given, _ = r.BasicAuth()
+		}
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="giiny"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
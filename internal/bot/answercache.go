@@ -0,0 +1,96 @@
+package bot
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"giiny/internal/metrics"
+)
+
+// answerCacheSize bounds how many answers are cached
+const answerCacheSize = 500
+
+// answers caches Gemini's answers to short questions, so the common ones in a
+// busy room ("what's your name?") are not paid for again and again
+var answers = struct {
+	sync.Mutex
+	byKey map[string]cachedAnswer
+}{byKey: map[string]cachedAnswer{}}
+
+type cachedAnswer struct {
+	response string
+	expires  time.Time
+}
+
+// answerKey returns the cache key of a message answered in language, or an
+// empty string when the cache is disabled or the message is too long to be a
+// common question. Messages that differ only in case, punctuation and spacing
+// share a key.
+func answerKey(text, language string) string {
+	c := cfg.Load().AnswerCache
+	if c.Minutes <= 0 || utf8.RuneCountInString(text) > c.MaxLength {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(word)
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	// The persona and language of the room shape the answer
+	return currentRoomKey() + "|" + language + "|" + sb.String()
+}
+
+// cachedResponse returns the answer cached under the key, if it is fresh
+func cachedResponse(key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	answers.Lock()
+	defer answers.Unlock()
+
+	a, ok := answers.byKey[key]
+	if !ok || time.Now().After(a.expires) {
+		return "", false
+	}
+	metrics.GeminiCacheHits.Add(1)
+	return a.response, true
+}
+
+// cacheResponse keeps the answer under the key for the configured minutes
+func cacheResponse(key, response string) {
+	if key == "" {
+		return
+	}
+	answers.Lock()
+	defer answers.Unlock()
+
+	now := time.Now()
+	if len(answers.byKey) >= answerCacheSize {
+		var oldest string
+		for k, a := range answers.byKey {
+			if now.After(a.expires) {
+				delete(answers.byKey, k)
+			} else if oldest == "" || a.expires.Before(answers.byKey[oldest].expires) {
+				oldest = k
+			}
+		}
+		if len(answers.byKey) >= answerCacheSize {
+			delete(answers.byKey, oldest)
+		}
+	}
+	answers.byKey[key] = cachedAnswer{
+		response: response,
+		expires:  now.Add(time.Duration(cfg.Load().AnswerCache.Minutes) * time.Minute),
+	}
+}
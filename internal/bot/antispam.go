@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"giiny/internal/events"
+	"giiny/internal/imvu"
+	"giiny/internal/plugin"
+)
+
+// floodGuard counts recent messages per user to catch floods
+type floodGuard struct {
+	mu     sync.Mutex
+	recent map[string][]time.Time
+}
+
+var flood = &floodGuard{recent: map[string][]time.Time{}}
+
+// record adds a message of the user and reports whether the user sent more
+// than max messages within the window
+func (f *floodGuard) record(userID string, at time.Time, max int, window time.Duration) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	times := f.recent[userID][:0]
+	for _, t := range f.recent[userID] {
+		if at.Sub(t) < window {
+			times = append(times, t)
+		}
+	}
+	times = append(times, at)
+	f.recent[userID] = times
+
+	if len(times) > max {
+		delete(f.recent, userID)
+		return true
+	}
+	return false
+}
+
+func init() {
+	plugin.Register("moderation", func() plugin.Plugin { return moderationPlugin{} })
+}
+
+// moderationPlugin ignores users for a while when they flood the room
+type moderationPlugin struct {
+	plugin.Base
+}
+
+func (moderationPlugin) OnMessage(_ *imvu.IMVU, msg events.ChatMessage) {
+	if msg.UserID == senpaiID {
+		return
+	}
+
+	spam := cfg.Load().AntiSpam
+	if spam.MaxMessages <= 0 {
+		return
+	}
+	now := time.Now()
+	if !flood.record(msg.UserID, now, spam.MaxMessages, time.Duration(spam.WindowSeconds)*time.Second) {
+		return
+	}
+
+	log.Printf("User %s sent more than %d messages in %ds, ignoring for %d minutes",
+		msg.UserID, spam.MaxMessages, spam.WindowSeconds, spam.IgnoreMinutes)
+	expiresAt := now.Add(time.Duration(spam.IgnoreMinutes) * time.Minute)
+	if err := db.Ignore(msg.UserID, "flood", expiresAt); err != nil {
+		log.Printf("Failed to ignore user %s: %v", msg.UserID, err)
+	}
+}
+
+// ignored reports whether messages of the user should be dropped because the
+// user is on the ignore list, by command or for flooding the room
+func ignored(userID string) bool {
+	if userID == senpaiID {
+		return false
+	}
+
+	isIgnored, err := db.IsIgnored(userID, time.Now())
+	if err != nil {
+		log.Printf("Failed to check ignore list: %v", err)
+	}
+	return isIgnored
+}
+
+func ignoreUser(client *imvu.IMVU, args []string) {
+	if len(args) == 0 {
+		say(client, "usage_ignore")
+		return
+	}
+	if args[0] == senpaiID {
+		say(client, "never_ignore")
+		return
+	}
+
+	if err := db.Ignore(args[0], "command", time.Time{}); err != nil {
+		log.Printf("Failed to ignore user %s: %v", args[0], err)
+		return
+	}
+	say(client, "ignoring", args[0])
+}
+
+func unignoreUser(client *imvu.IMVU, args []string) {
+	if len(args) == 0 {
+		say(client, "usage_unignore")
+		return
+	}
+
+	removed, err := db.Unignore(args[0])
+	if err != nil {
+		log.Printf("Failed to unignore user %s: %v", args[0], err)
+		return
+	}
+	if !removed {
+		say(client, "not_ignored", args[0])
+		return
+	}
+	say(client, "unignored", args[0])
+}
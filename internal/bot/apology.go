@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"giiny/internal/imvu"
+	"giiny/internal/metrics"
+)
+
+const (
+	// apologies is how many apology_N strings there are
+	apologies = 5
+	// apologyLimit is how many failures in a row are apologized for; later
+	// ones are only logged until apologyCooldown passes without failures or
+	// an answer gets through
+	apologyLimit    = 3
+	apologyCooldown = 10 * time.Minute
+)
+
+// failures counts the failures in a row to answer users, so that an outage
+// doesn't turn into a stream of apologies
+var failures = struct {
+	sync.Mutex
+	count int
+	last  time.Time
+}{}
+
+// apologize tells the room, in character, that the bot couldn't answer
+func apologize(client *imvu.IMVU) {
+	if apologyAllowed() {
+		say(client, apologyKey())
+	}
+}
+
+// apologizeTo whispers the apology to the user whose command failed
+func apologizeTo(client *imvu.IMVU, userID string) {
+	if apologyAllowed() {
+		whisper(client, userID, apologyKey())
+	}
+}
+
+func apologyKey() string {
+	return fmt.Sprintf("apology_%d", rand.IntN(apologies)+1)
+}
+
+// apologyAllowed records a failure and reports whether it may be apologized
+// for
+func apologyAllowed() bool {
+	failures.Lock()
+	defer failures.Unlock()
+
+	metrics.AnswerFailures.Add(1)
+	now := time.Now()
+	if now.Sub(failures.last) > apologyCooldown {
+		failures.count = 0
+	}
+	failures.count++
+	failures.last = now
+	if failures.count == apologyLimit+1 {
+		log.Printf("%d failures in a row, not apologizing until they stop for %s", apologyLimit, apologyCooldown)
+	}
+	return failures.count <= apologyLimit
+}
+
+// answered closes the apology circuit after a successful answer
+func answered() {
+	failures.Lock()
+	defer failures.Unlock()
+	failures.count = 0
+}
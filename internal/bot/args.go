@@ -0,0 +1,228 @@
+package bot
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"giiny/internal/i18n"
+	"giiny/internal/imvu"
+)
+
+// argKind is what a positional argument of a command holds
+type argKind int
+
+const (
+	// argWord is any single argument
+	argWord argKind = iota
+	// argUser is a user ID, "user-<id>", or the name or "@name" of a
+	// participant of the room, resolved to the ID
+	argUser
+	// argNumber is a non-negative integer; "#" and "product-" prefixes are
+	// dropped
+	argNumber
+	// argRest is the remaining arguments joined by spaces
+	argRest
+)
+
+// param is a positional argument of a command
+type param struct {
+	name     string
+	kind     argKind
+	optional bool
+}
+
+// argSpec describes the arguments of a command. Flags are given anywhere as
+// "--name" or "--name=value".
+type argSpec struct {
+	// usage is the i18n key of the usage sent back on errors
+	usage  string
+	params []param
+	flags  []string
+}
+
+// parsedArgs are the arguments of a command, by name
+type parsedArgs struct {
+	values map[string]string
+	flags  map[string]string
+}
+
+// get returns the argument, or an empty string if it was not given
+func (a parsedArgs) get(name string) string {
+	return a.values[name]
+}
+
+// number returns a numeric argument, or 0 if it was not given
+func (a parsedArgs) number(name string) int64 {
+	n, _ := strconv.ParseInt(a.values[name], 10, 64)
+	return n
+}
+
+// flag reports whether the flag was given, and its value
+func (a parsedArgs) flag(name string) (string, bool) {
+	value, ok := a.flags[name]
+	return value, ok
+}
+
+// argError is a usage error, described by an i18n key
+type argError struct {
+	key  string
+	args []any
+}
+
+func (e *argError) Error() string {
+	return i18n.T(i18n.English, e.key, e.args...)
+}
+
+// registerParsed registers a command whose arguments are parsed with spec.
+// Usage errors are sent back to the chat and don't reach run.
+func registerParsed(access access, spec argSpec, run func(client *imvu.IMVU, userID string, args parsedArgs), names ...string) {
+	registerHandler(access, func(client *imvu.IMVU, userID string, fields []string) {
+		args, err := parseArgs(client, spec, fields)
+		if err != nil {
+			sendArgError(client, err, spec.usage)
+			return
+		}
+		run(client, userID, args)
+	}, names...)
+}
+
+// sendArgError tells the room what was wrong with the arguments, followed by
+// the usage of the command
+func sendArgError(client *imvu.IMVU, err error, usage string) {
+	message := err.Error()
+	if e, ok := err.(*argError); ok {
+		message = i18n.T(lang(), e.key, e.args...)
+	}
+	if usage != "" {
+		message += " " + i18n.T(lang(), usage)
+	}
+	client.SendChatMessage(message)
+}
+
+// parseArgs matches the fields of a command line against spec
+func parseArgs(client *imvu.IMVU, spec argSpec, fields []string) (parsedArgs, error) {
+	args := parsedArgs{values: map[string]string{}, flags: map[string]string{}}
+
+	var positional []string
+	for _, field := range fields {
+		name, ok := strings.CutPrefix(field, "--")
+		if !ok || name == "" {
+			positional = append(positional, field)
+			continue
+		}
+		name, value, _ := strings.Cut(name, "=")
+		if !slices.Contains(spec.flags, name) {
+			return args, &argError{key: "arg_unknown_flag", args: []any{name}}
+		}
+		args.flags[name] = value
+	}
+
+	for n, p := range spec.params {
+		if n >= len(positional) {
+			if !p.optional {
+				return args, &argError{key: "arg_missing", args: []any{p.name}}
+			}
+			continue
+		}
+
+		value := positional[n]
+		switch p.kind {
+		case argRest:
+			value = strings.Join(positional[n:], " ")
+			positional = positional[:n+1]
+		case argNumber:
+			value = strings.TrimPrefix(strings.TrimPrefix(value, "#"), "product-")
+			if _, err := strconv.ParseUint(value, 10, 63); err != nil {
+				return args, &argError{key: "arg_not_number", args: []any{p.name, positional[n]}}
+			}
+		case argUser:
+			id, err := resolveUser(client, value)
+			if err != nil {
+				return args, err
+			}
+			value = id
+		}
+		args.values[p.name] = value
+	}
+
+	if len(positional) > len(spec.params) {
+		return args, &argError{key: "arg_too_many"}
+	}
+	return args, nil
+}
+
+// resolveUser turns a user ID, "user-<id>", or the display name or username
+// of a participant of the room, with or without "@", into a user ID
+func resolveUser(client *imvu.IMVU, arg string) (string, error) {
+	arg = strings.TrimPrefix(arg, "@")
+	if id := strings.TrimPrefix(arg, "user-"); id != "" && isDigits(id) {
+		return id, nil
+	}
+
+	var matches []string
+	for _, id := range client.Participants() {
+		user, err := client.Profile(id)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(user.DisplayName, arg) || strings.EqualFold(user.Username, arg) {
+			matches = append(matches, id)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", &argError{key: "arg_unknown_user", args: []any{arg}}
+	case 1:
+		return matches[0], nil
+	default:
+		return "", &argError{key: "arg_ambiguous_user", args: []any{arg}}
+	}
+}
+
+// splitArgs splits a command line into fields at spaces, keeping the text
+// between double or single quotes that open a field together. Without a
+// closing quote the line is split at spaces only.
+func splitArgs(line string) []string {
+	var fields []string
+	var field strings.Builder
+	var quote rune
+	inField := false
+
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			field.WriteRune(r)
+		case !inField && (r == '"' || r == '\''):
+			quote, inField = r, true
+		case unicode.IsSpace(r):
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return strings.Fields(line)
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
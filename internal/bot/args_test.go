@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"a b c", []string{"a", "b", "c"}},
+		{"  a   b  ", []string{"a", "b"}},
+		{`say "hello there" now`, []string{"say", "hello there", "now"}},
+		{`say 'it is "fine"'`, []string{"say", `it is "fine"`}},
+		{`don't stop`, []string{"don't", "stop"}},
+		{`say "unclosed quote`, []string{"say", `"unclosed`, "quote"}},
+		{`""`, []string{""}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := splitArgs(tt.line); !slices.Equal(got, tt.want) {
+			t.Errorf("splitArgs(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	spec := argSpec{
+		params: []param{
+			{name: "user", kind: argUser},
+			{name: "product", kind: argNumber},
+			{name: "note", kind: argRest, optional: true},
+		},
+		flags: []string{"quiet", "count"},
+	}
+
+	args, err := parseArgs(nil, spec, []string{"user-123", "--quiet", "#456", "nice", "shoes", "--count=2"})
+	if err != nil {
+		t.Fatalf("parseArgs: %v", err)
+	}
+	if got := args.get("user"); got != "123" {
+		t.Errorf("user = %q, want 123", got)
+	}
+	if got := args.number("product"); got != 456 {
+		t.Errorf("product = %d, want 456", got)
+	}
+	if got := args.get("note"); got != "nice shoes" {
+		t.Errorf("note = %q, want %q", got, "nice shoes")
+	}
+	if _, ok := args.flag("quiet"); !ok {
+		t.Error("quiet flag not set")
+	}
+	if value, _ := args.flag("count"); value != "2" {
+		t.Errorf("count flag = %q, want 2", value)
+	}
+
+	args, err = parseArgs(nil, spec, []string{"@42", "product-7"})
+	if err != nil {
+		t.Fatalf("parseArgs: %v", err)
+	}
+	if args.get("user") != "42" || args.number("product") != 7 || args.get("note") != "" {
+		t.Errorf("got %v, want user 42, product 7 and no note", args.values)
+	}
+
+	errs := []struct {
+		name   string
+		spec   argSpec
+		fields []string
+		key    string
+	}{
+		{"missing", spec, []string{"123"}, "arg_missing"},
+		{"unknown flag", spec, []string{"123", "4", "--loud"}, "arg_unknown_flag"},
+		{"not a number", spec, []string{"123", "four"}, "arg_not_number"},
+		{"negative", spec, []string{"123", "-4"}, "arg_not_number"},
+		{"too many", argSpec{params: []param{{name: "id", kind: argNumber}}}, []string{"1", "2"}, "arg_too_many"},
+	}
+	for _, tt := range errs {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseArgs(nil, tt.spec, tt.fields)
+			var argErr *argError
+			if !errors.As(err, &argErr) || argErr.key != tt.key {
+				t.Errorf("parseArgs(%q) error = %v, want %s", tt.fields, err, tt.key)
+			}
+		})
+	}
+}
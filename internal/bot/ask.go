@@ -0,0 +1,175 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"giiny/internal/events"
+	"giiny/internal/gemini"
+	"giiny/internal/i18n"
+	"giiny/internal/imvu"
+	"giiny/internal/store"
+)
+
+const (
+	// askScanLines is how far back in the transcript !ask looks
+	askScanLines = 500
+	// askContextLines is how many transcript lines are given to Gemini
+	askContextLines = 20
+)
+
+// recordTranscript stores the text of every public message of the room,
+// including the bot's own, so that !ask can answer questions about it.
+// Avatar commands, system notices and bare emotes are left out.
+func recordTranscript(client *imvu.IMVU) {
+	sub := events.Subscribe[events.ChatMessage](client.Events, 64)
+	defer sub.Close()
+
+	for msg := range sub.C {
+		if msg.Whispered() {
+			continue
+		}
+		text := plainText(client, msg)
+		if text == "" {
+			continue
+		}
+		if err := db.AddChatLine(msg.Queue, msg.UserID, msg.Sender, text, msg.ReceivedAt); err != nil {
+			log.Printf("Failed to record chat line: %v", err)
+		}
+	}
+}
+
+// ask answers a question about the room with the most relevant lines of the
+// transcript as context, citing them by number
+func ask(client *imvu.IMVU, args []string) {
+	if len(args) == 0 {
+		say(client, "usage_ask")
+		return
+	}
+	question := strings.Join(args, " ")
+
+	lines, err := db.RecentChatLines(client.ChatQueue(), askScanLines)
+	if err != nil {
+		log.Printf("Failed to read chat log: %v", err)
+		return
+	}
+	lines = withoutNoAI(lines)
+	if len(lines) == 0 {
+		say(client, "ask_empty")
+		return
+	}
+	lines = relevantLines(question, lines, askContextLines)
+
+	var prompt strings.Builder
+	prompt.WriteString("Trechos do chat da sala:\n")
+	for n, line := range lines {
+		fmt.Fprintf(&prompt, "[%d] %s %s %s: %s\n", n+1, line.CreatedAt.Local().Format("15:04"), speaker(line.Sender), line.UserID, line.Message)
+	}
+	prompt.WriteString("\nResponda à pergunta usando apenas os trechos acima e cite os trechos usados como [n]. ")
+	prompt.WriteString("Se a resposta não estiver nos trechos, diga que não sabe.\n")
+	prompt.WriteString("Pergunta: " + question)
+
+	if !withinBudget() {
+		return
+	}
+
+	var usage gemini.Usage
+	response, err := gemini.Process(context.Background(), prompt.String(), gemini.WithUsage(&usage), gemini.WithLanguage(i18n.Name(lang())))
+	if err != nil {
+		log.Printf("Error answering question with Gemini: %v", err)
+		apologize(client)
+		return
+	}
+	recordUsage(senpaiID, usage)
+
+	for _, sentence := range strings.Split(response, ";") {
+		if sentence = strings.TrimSpace(sentence); sentence != "" {
+			client.SendChatMessage(sentence)
+		}
+	}
+}
+
+// withoutNoAI drops the lines of the users who keep their messages away from
+// Gemini. Their lines stay in the transcript, so turning noai off later
+// brings them back.
+func withoutNoAI(lines []store.ChatLine) []store.ChatLine {
+	return slices.DeleteFunc(lines, func(line store.ChatLine) bool {
+		return line.Sender != events.SenderBot && profileOf(line.UserID).NoAI
+	})
+}
+
+// speaker is how the author of a transcript line is introduced to Gemini
+func speaker(sender string) string {
+	if sender == events.SenderBot {
+		return "bot"
+	}
+	return "usuário"
+}
+
+// relevantLines picks up to limit lines sharing the most words with the
+// question, preferring recent lines on ties, and returns them in chronological
+// order. Without any overlap the latest lines are used.
+func relevantLines(question string, lines []store.ChatLine, limit int) []store.ChatLine {
+	words := map[string]bool{}
+	for _, w := range tokenize(question) {
+		words[w] = true
+	}
+
+	type scored struct {
+		index int
+		score int
+	}
+	var candidates []scored
+	for i, line := range lines {
+		score := 0
+		for _, w := range tokenize(line.Message) {
+			if words[w] {
+				score++
+			}
+		}
+		if score > 0 {
+			candidates = append(candidates, scored{index: i, score: score})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return lines[max(0, len(lines)-limit):]
+	}
+
+	sort.Slice(candidates, func(a, b int) bool {
+		if candidates[a].score != candidates[b].score {
+			return candidates[a].score > candidates[b].score
+		}
+		return candidates[a].index > candidates[b].index
+	})
+	candidates = candidates[:min(limit, len(candidates))]
+	sort.Slice(candidates, func(a, b int) bool {
+		return candidates[a].index < candidates[b].index
+	})
+
+	result := make([]store.ChatLine, len(candidates))
+	for i, c := range candidates {
+		result[i] = lines[c.index]
+	}
+	return result
+}
+
+// tokenize splits text into lowercase words of at least three letters
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	words := fields[:0]
+	for _, f := range fields {
+		if len([]rune(f)) >= 3 {
+			words = append(words, f)
+		}
+	}
+	return words
+}
@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"giiny/internal/events"
+	"giiny/internal/imvu"
+)
+
+const (
+	auditDefaultEntries = 5
+	auditMaxEntries     = 20
+	// telegramActor is the actor of the commands sent from the Telegram
+	// admin channel
+	telegramActor = "telegram"
+)
+
+// recordAudit stores every IMVU command executed by the bot
+func recordAudit(client *imvu.IMVU) {
+	sub := events.Subscribe[events.CommandExecuted](client.Events, 64)
+	defer sub.Close()
+
+	for e := range sub.C {
+		result := "ok"
+		if e.Err != nil {
+			result = e.Err.Error()
+		}
+		if err := db.AddAudit(e.Actor, e.Command, strings.Join(e.Args, " "), result, e.At); err != nil {
+			log.Printf("Failed to record audit entry: %v", err)
+		}
+	}
+}
+
+// showAudit whispers the latest executed commands, "!audit [count]"
+func showAudit(client *imvu.IMVU, userID string, args []string) {
+	limit := auditDefaultEntries
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			whisper(client, userID, "usage_audit")
+			return
+		}
+		limit = min(n, auditMaxEntries)
+	}
+
+	entries, err := db.RecentAudit(limit)
+	if err != nil {
+		log.Printf("Failed to read audit log: %v", err)
+		return
+	}
+	if len(entries) == 0 {
+		whisper(client, userID, "audit_empty")
+		return
+	}
+
+	for _, e := range entries {
+		actor := e.Actor
+		switch actor {
+		case "":
+			actor = "giiny"
+		case telegramActor:
+		default:
+			actor = client.UserName(actor)
+		}
+		whisper(client, userID, "audit_entry",
+			e.CreatedAt.Local().Format("01-02 15:04"), actor, strings.TrimSpace(e.Command+" "+e.Args), e.Result)
+	}
+}
+
+func init() {
+	registerHandler(senpaiOnly, showAudit, "audit")
+}
@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"giiny/internal/events"
+	"giiny/internal/gemini"
+	"giiny/internal/i18n"
+	"giiny/internal/imvu"
+)
+
+// badgesShown is how many badge names !badges lists
+const badgesShown = 5
+
+func init() {
+	registerHandler(everyone, showBadges, "badges")
+}
+
+// showBadges whispers the badges of the user given as argument, or the
+// bot's own badge progress without one
+func showBadges(client *imvu.IMVU, userID string, args []string) {
+	if len(args) == 0 {
+		progress, err := client.BadgeProgress()
+		if err != nil {
+			log.Printf("Failed to get badge progress: %v", err)
+			apologizeTo(client, userID)
+			return
+		}
+		whisper(client, userID, "badges_progress", progress.Count, progress.Level)
+		return
+	}
+
+	target := args[0]
+	if !isDigits(target) {
+		whisper(client, userID, "usage_badges")
+		return
+	}
+	badges, total, err := client.Badges(target, badgesShown)
+	if err != nil {
+		log.Printf("Failed to get badges of user %s: %v", target, err)
+		apologizeTo(client, userID)
+		return
+	}
+
+	name := client.UserName(target)
+	if len(badges) == 0 {
+		whisper(client, userID, "badges_none", name)
+		return
+	}
+	names := make([]string, len(badges))
+	for n, b := range badges {
+		names[n] = b.Name
+	}
+	whisper(client, userID, "badges", name, total, strings.Join(names, ", "))
+}
+
+// bragAboutBadges tells the room, in character, about every badge the bot
+// earns
+func bragAboutBadges(ctx context.Context, client *imvu.IMVU) {
+	earned := events.Subscribe[events.BadgeEarned](client.Events, 8)
+	defer earned.Close()
+
+	for {
+		select {
+		case e := <-earned.C:
+			log.Printf("Earned badge %s: %s", e.ID, e.Name)
+			if pause.Load() || lurking.Load() || sleeping(e.At) {
+				continue
+			}
+			client.SendChatMessage(badgeBrag(e))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// badgeBrag returns the message announcing a badge, written by Gemini when
+// the budget allows
+func badgeBrag(e events.BadgeEarned) string {
+	fallback := i18n.T(lang(), "badge_earned", e.Name)
+	if !withinBudget() {
+		return fallback
+	}
+
+	prompt := fmt.Sprintf("Você acabou de ganhar a insígnia \"%s\" no IMVU (%s). Conte para a sala em uma frase curta, se gabando do seu jeito.", e.Name, e.Description)
+	var usage gemini.Usage
+	response, err := gemini.Process(context.Background(), prompt, gemini.WithUsage(&usage), gemini.WithLanguage(i18n.Name(lang())))
+	if err != nil {
+		log.Printf("Error writing badge message with Gemini: %v", err)
+		return fallback
+	}
+	recordUsage(senpaiID, usage)
+	if response = strings.TrimSpace(response); response == "" {
+		return fallback
+	}
+	return response
+}
@@ -1,26 +1,114 @@
 package bot
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"giiny/internal/autoreply"
+	"giiny/internal/config"
+	"giiny/internal/conversation"
+	"giiny/internal/events"
 	"giiny/internal/gemini"
+	"giiny/internal/i18n"
 	"giiny/internal/imvu"
+	"giiny/internal/intent"
+	"giiny/internal/memory"
+	"giiny/internal/metrics"
+	"giiny/internal/store"
 	"log"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 )
 
 var startTime time.Time
-var pause bool = false
+var pause atomic.Bool
 
 const senpaiID = "361230062"
 
 var doneCh chan bool
 
-func Start(username, password, roomOwner, chatID string, client *imvu.IMVU) error {
+// cfg is the configuration in use. Reloads and !config replace it as a
+// whole, so each use sees either the old or the new one.
+var cfg atomic.Pointer[config.Config]
+
+var mem *memory.Memory
+
+var db *store.Store
+
+var autoReplies atomic.Pointer[autoreply.Engine]
+
+var history *conversation.History
+
+// memoryRecallLimit is how many remembered facts are added to each prompt
+const memoryRecallLimit = 5
+
+// setup initializes the state shared by the IMVU bot and the REPL
+func setup(c *config.Config, st *store.Store) error {
+	cfg.Store(c)
+	db = st
+	if err := applyOverrides(cfg.Load()); err != nil {
+		log.Printf("Config overrides not applied: %v", err)
+	}
+	mem = memory.New(st, gemini.Embed, gemini.ExtractFact)
+	intents = intent.NewRouter(gemini.Embed)
+	history = conversation.New(cfg.Load().Conversation.MaxTokens, cfg.Load().Conversation.KeepTurns, gemini.Summarize)
+
+	engine, err := autoreply.New(cfg.Load().AutoReplies, generateAutoReply)
+	if err != nil {
+		return fmt.Errorf("invalid auto replies: %w", err)
+	}
+	autoReplies.Store(engine)
+	lurking.Store(cfg.Load().Response.Lurk)
+	if err := loadRoomPersonas(cfg.Load()); err != nil {
+		return err
+	}
+	return loadPersona(cfg.Load().PersonaFile)
+}
+
+// generateAutoReply answers the prompt of an auto reply template with Gemini,
+// within the daily token budget
+func generateAutoReply(userID, prompt string) (string, error) {
+	if !withinBudget() {
+		return "", nil
+	}
+
+	var usage gemini.Usage
+	response, err := gemini.Process(context.Background(), prompt, gemini.WithUsage(&usage), gemini.WithLanguage(i18n.Name(lang())))
+	if err != nil {
+		return "", err
+	}
+	recordUsage(userID, usage)
+	return response, nil
+}
+
+func Start(c *config.Config, roomOwner, chatID string, client *imvu.IMVU, st *store.Store) error {
 	doneCh = make(chan bool)
+	if err := setup(c, st); err != nil {
+		return err
+	}
 
-	log.Printf("Trying to login as %s", username)
-	err := client.Login(username, password)
+	if err := loadPlugins(cfg.Load().Plugins, cfg.Load().ExternalPlugins); err != nil {
+		return err
+	}
+	defer stopPlugins()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	startTelegram(ctx, client)
+	startAdmin(ctx, client)
+	go recordAudit(client)
+	go watchConfig(ctx)
+
+	room.owner, room.chat = roomOwner, chatID
+	lurking.Store(responseSettings().Lurk)
+
+	log.Printf("Trying to login as %s", cfg.Load().Username)
+	err := client.Login(cfg.Load().Username, cfg.Load().Password)
 	if err != nil {
 		return err
 	}
@@ -28,83 +116,472 @@ func Start(username, password, roomOwner, chatID string, client *imvu.IMVU) erro
 	startTime = time.Now()
 
 	log.Printf("Login successful!")
+	go client.Supervise(ctx)
+	go client.WatchNotifications(ctx)
+	go client.WatchBadges(ctx)
+	go bragAboutBadges(ctx, client)
+	go followFeeds(ctx, client)
+	go trackGreeterScore(ctx, client)
+	go trackRoomStats(ctx, client)
+	go watchOccupancy(ctx, client)
+	go watchBuddies(ctx, client)
+	go announceCatalog(ctx, client)
+	go answerFriendRequests(ctx, client)
+	go runPlugins(ctx, client)
+
+	if client.DryRun() {
+		log.Printf("Dry run: replies and actions are logged, not sent to the room")
+	}
+
+	var prize *imvu.RoulettePrize
+	if cfg.Load().AutoSpinRoulette && client.DryRun() {
+		log.Printf("[dry-run] Would claim the daily roulette spin")
+	} else if cfg.Load().AutoSpinRoulette {
+		prize, err = client.ClaimDailySpin()
+		if err != nil {
+			log.Printf("Failed to claim the daily roulette spin: %v", err)
+		}
+	}
+
 	log.Printf("Trying to join a room.")
 
-	err = client.JoinRoom(roomOwner, chatID)
+	client.SetJoinActions(joinActions())
+	joined, err := client.JoinRoom(roomOwner, chatID)
 	if err != nil {
 		return err
 	}
+	if joined.Seated() {
+		log.Printf("Landed on seat %d of furni %d", joined.SeatNumber, joined.SeatFurniID)
+	} else {
+		log.Printf("Joined without a seat, the room may be full")
+	}
+
+	if prize != nil {
+		client.SendWhisper(senpaiID, i18n.T(lang(), "daily_roulette", prize.Description, prize.Amount, prize.PrizeType))
+	}
 
 	log.Printf("Joined successfully, starting to consume messages")
+	go recordTranscript(client)
+	go sendHeartbeats(ctx, client)
+	go leaveWhenIdle(ctx, client)
+	go trackActivity(client)
 	go handleIncomingChatMessages(client)
 
 	<-doneCh
 
-	client.LeaveRoom(roomOwner, chatID)
+	room.Lock()
+	defer room.Unlock()
+	client.LeaveRoom(room.owner, room.chat)
 	return nil
 }
 
 func handleIncomingChatMessages(client *imvu.IMVU) {
-	for {
-		msg := <-client.ChatMessageChannel
+	sub := events.Subscribe[events.ChatMessage](client.Events, 16)
+	defer sub.Close()
+
+	answers := newAnswerPool(client, cfg.Load().ChatWorkers)
+	defer answers.close()
+	commandQueue := newCommandPool(commandWorkers)
+	defer commandQueue.close()
 
-		if len(msg.Message) == 0 || msg.UserID.String() == client.UserID || msg.UserID.String() != senpaiID {
+	for msg := range sub.C {
+		if len(msg.Message) == 0 || msg.Sender != events.SenderHuman {
 			continue
 		}
+		metrics.ChatMessagesHandled.Add(1)
+		fromSenpai := msg.UserID == senpaiID
+		pluginsOnMessage(client, msg)
 
+		// Commands skip the answer queue so a slow answer does not hold them
 		firstCh := msg.Message[0]
-		switch firstCh {
-		case '!':
-			runCommand(client, msg.Message[1:])
-		case '*':
-			log.Printf("[%s] Incoming IMVU command: %s", msg.UserID, msg.Message[1:])
+		switch {
+		case firstCh == '!' && canRunCommand(msg.UserID, msg.Message[1:]):
+			commandQueue.enqueue(msg.UserID, func() { runCommand(client, msg.UserID, msg.Message[1:]) })
+		case firstCh == '!':
+			// Commands unknown to the bot are offered to the plugins
+			commandQueue.enqueue(msg.UserID, func() { runPluginCommand(client, msg.UserID, msg.Message[1:]) })
+		case msg.Kind == events.MessageCommand && fromSenpai:
+			log.Printf("[%s] Incoming IMVU command: %s", msg.UserID, msg.Text)
+		case msg.Kind == events.MessageCommand || msg.Kind == events.MessageSystem:
+			continue
 		default:
-			log.Printf("Message: %s", msg.Message)
+			if ignored(msg.UserID) {
+				continue
+			}
 
-			if pause {
+			if pause.Load() {
 				fmt.Println("Bot is paused, ignoring message.")
 				continue
 			}
+			if sleeping(msg.ReceivedAt) {
+				continue
+			}
 
-			response, err := gemini.Process(msg.Message)
-			if err != nil {
-				log.Printf("Error processing message with Gemini: %v", err)
+			// Gemini gets the words without the emotes and other markup
+			plain := plainText(client, msg)
+			if plain == "" {
 				continue
 			}
-			sentences := strings.Split(response, ";")
-			for _, sentence := range sentences {
-				sentence = strings.TrimSpace(sentence)
-				if len(sentence) > 0 {
-					log.Printf("Sending response: %s", sentence)
-					client.SendChatMessage(sentence)
+
+			observeMood(client, plain)
+			noAI := profileOf(msg.UserID).NoAI
+			if cfg.Load().Translation.Auto && !noAI {
+				go translateMessage(client, msg.UserID, plain)
+			}
+			quiet := roomWasQuiet(msg.ReceivedAt)
+
+			// Auto replies are not addressed to the bot, so lurking skips them
+			if !lurking.Load() {
+				if response, ok, err := autoReplies.Load().Reply(msg.UserID, plain, time.Now()); ok {
+					if err != nil {
+						log.Printf("Auto reply failed: %v", err)
+					} else if response != "" {
+						log.Printf("Sending auto reply: %s", response)
+						send(func() error { return client.SendChatMessage(response) })
+					}
+					continue
 				}
 			}
+
+			text, addressed := stripMention(client, plain)
+			if !wantsReply(msg.UserID, addressed, quiet) {
+				continue
+			}
+			if text == "" || noAI {
+				continue
+			}
+			msg.Message = text
+
+			log.Printf("Message: %s", msg.Message)
+
+			if !withinBudget() {
+				noticeBudgetSpent(client)
+				continue
+			}
+
+			answers.enqueue(msg.UserID, msg.Message)
 		}
 	}
 }
 
-func runCommand(client *imvu.IMVU, cmd string) {
-	cmd = strings.ToLower(cmd)
+// answer generates the answer to a user's message and sends it, unless the
+// user sends a newer message first
+func answer(client *imvu.IMVU, job answerJob) {
+	ctx, cancel, ok := startGeneration(job.userID, job.gen)
+	if !ok {
+		log.Printf("Answer to user %s superseded by a newer message", job.userID)
+		return
+	}
+	defer cancel()
+	defer finishGeneration(job.userID, job.gen)
+
+	if routeIntent(client, job.userID, job.text) {
+		return
+	}
+
+	started := startTyping(client)
+	var sentences []string
+	err := runStage(ctx, stageGemini, func(ctx context.Context) error {
+		var err error
+		sentences, err = reply(ctx, job.userID, job.text)
+		return err
+	})
+	if ctx.Err() != nil {
+		log.Printf("Answer to user %s cancelled by a newer message", job.userID)
+		return
+	}
+	if err != nil {
+		log.Printf("Error processing message with Gemini: %v", err)
+		alert("Gemini error: %v", err)
+		apologize(client)
+		return
+	}
+	answered()
+	sendTyped(client, sentences, started)
+}
+
+// plainText returns the text of a chat message without markup. Actions are
+// told in the third person, as in "Ana waves".
+func plainText(client *imvu.IMVU, msg events.ChatMessage) string {
+	switch msg.Kind {
+	case events.MessageText:
+		return msg.Text
+	case events.MessageAction:
+		if msg.Text == "" {
+			return ""
+		}
+		// The display name in the message is whatever the sender's client
+		// put there, so the name is looked up instead
+		return client.UserName(msg.UserID) + " " + msg.Text
+	}
+	return ""
+}
 
-	log.Printf("Trying to run command: %s", cmd)
+// reply runs a chat message through the memory and Gemini pipeline and returns
+// the sentences to send back. Nothing is returned when the daily token budget
+// is spent.
+func reply(ctx context.Context, userID, text string) ([]string, error) {
+	if !withinBudget() {
+		return nil, nil
+	}
 
-	switch cmd {
-	case "quit":
-		doneCh <- true
-	case "uptime":
-		msg := fmt.Sprintf("Uptime: %s", time.Since(startTime))
-		client.SendChatMessage(msg)
-	case "dress":
+	memories, err := mem.Recall(userID, text, memoryRecallLimit)
+	if err != nil {
+		log.Printf("Failed to recall memories for user %s: %v", userID, err)
+	}
+
+	go func() {
+		if err := mem.Learn(userID, text); err != nil {
+			log.Printf("Failed to learn from message of user %s: %v", userID, err)
+		}
+	}()
+
+	profile := profileOf(userID)
+	language := userLang(userID)
+	thread := threadKey(userID)
+	summary, turns := history.Context(thread)
+	// Answers that draw on what is known about the user, or call them by name
+	// or pronouns, are not shared with others
+	key := ""
+	if len(memories) == 0 && summary == "" && len(turns) == 0 && profile.Nickname == "" && profile.Pronouns == "" {
+		key = answerKey(text, language)
+	}
+	if response, ok := cachedResponse(key); ok {
+		go history.Add(thread, text, response)
+		return splitSentences(response), nil
+	}
+
+	var usage gemini.Usage
+	response, err := gemini.Process(ctx, text,
+		gemini.WithMemories(memories),
+		gemini.WithSpeaker(profile.Nickname, profile.Pronouns),
+		gemini.WithHistory(summary, turns),
+		gemini.WithUsage(&usage),
+		gemini.WithLanguage(i18n.Name(language)),
+		moodOption(),
+		personaOption(),
+	)
+	if errors.Is(err, gemini.ErrRefused) {
+		// Answer in character instead of going silent
+		log.Printf("Gemini refused to answer user %s: %v", userID, err)
+		recordUsage(userID, usage)
+		return []string{i18n.T(language, "refusal")}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	recordUsage(userID, usage)
+	cacheResponse(key, response)
+	go history.Add(thread, text, response)
+	return splitSentences(response), nil
+}
+
+// splitSentences splits an answer at the semicolons the persona separates its
+// messages with
+func splitSentences(response string) []string {
+	var sentences []string
+	for _, sentence := range strings.Split(response, ";") {
+		sentence = strings.TrimSpace(sentence)
+		if len(sentence) > 0 {
+			sentences = append(sentences, sentence)
+		}
+	}
+	return sentences
+}
+
+// stripMention looks for the configured prefixes and, when enabled, the bot's
+// name anywhere in the message. It returns the message without the mention and
+// whether one was found.
+func stripMention(client *imvu.IMVU, text string) (string, bool) {
+	response := responseSettings()
+	mentions := slices.Clone(response.Prefixes)
+	if user := client.User(); response.MentionName && user != nil {
+		mentions = append(mentions, user.DisplayName, user.Username)
+	}
+
+	return removeMention(text, mentions)
+}
+
+// removeMention removes the first of the mentions found in text, ignoring
+// case, and reports whether one was
+func removeMention(text string, mentions []string) (string, bool) {
+	for _, mention := range mentions {
+		if mention == "" {
+			continue
+		}
+		start, end, ok := indexFold(text, mention)
+		if !ok {
+			continue
+		}
+
+		before := strings.TrimRight(text[:start], " ,:")
+		after := strings.TrimLeft(text[end:], " ,:!?")
+		return strings.TrimSpace(before + " " + after), true
+	}
+
+	return text, false
+}
+
+// indexFold returns the bounds of the first substring of s equal to substr
+// under Unicode case folding. Folding maps runes to runes but may change
+// their encoded length, so the bounds are found on the runes of s itself.
+func indexFold(s, substr string) (start, end int, ok bool) {
+	runes := utf8.RuneCountInString(substr)
+	for start = range s {
+		end = start
+		for n := 0; n < runes && end < len(s); n++ {
+			_, size := utf8.DecodeRuneInString(s[end:])
+			end += size
+		}
+		if strings.EqualFold(s[start:end], substr) {
+			return start, end, true
+		}
+	}
+	return 0, 0, false
+}
+
+func init() {
+	register(senpaiOnly, func(*imvu.IMVU, []string) { doneCh <- true }, CmdQuit)
+	register(senpaiOnly, func(client *imvu.IMVU, _ []string) {
+		say(client, "uptime", time.Since(startTime))
+	}, CmdUptime)
+	register(senpaiOnly, func(client *imvu.IMVU, _ []string) {
 		outfitItemIDS := []string{
 			"69320200", "70312022", "12444122", "13831030", "16070306", "19442649", "23974249", "55139083", "55595518", "63520397", "63520471", "70082645", "70082730", "55595754", "61753525", "62845575", "59508957", "63520653", "63520746",
 		}
 
-		client.Exec(imvu.CmdPutOnOutfit, outfitItemIDS...)
-		client.Exec(imvu.CmdUse, outfitItemIDS...)
-	case "lap":
-		client.SendChatMessage("Colinhooo!! uwu *tomato*")
-		client.Exec(imvu.CmdMsg, "SeatAssignment 2 361230062 101 99982")
-	case "pause":
-		pause = !pause
+		client.ChangeOutfit(senpaiID, outfitItemIDS...)
+	}, "dress")
+	register(senpaiOnly, func(client *imvu.IMVU, _ []string) { sitOnPreset(client, senpaiID, "lap") }, "lap")
+	register(senpaiOnly, func(client *imvu.IMVU, args []string) {
+		if len(args) == 0 {
+			say(client, "usage_sit")
+			return
+		}
+		sitOnPreset(client, senpaiID, strings.ToLower(args[0]))
+	}, "sit")
+	register(senpaiOnly, func(client *imvu.IMVU, _ []string) { listSeats(client) }, "seats")
+	register(senpaiOnly, func(*imvu.IMVU, []string) { pause.Store(!pause.Load()) }, "pause")
+	register(senpaiOnly, setTemperature, "temp")
+	register(senpaiOnly, func(client *imvu.IMVU, _ []string) { triggerAction(client, senpaiID, "dance") }, "dance")
+	register(senpaiOnly, func(client *imvu.IMVU, _ []string) { triggerAction(client, senpaiID, "wave") }, "wave")
+	register(senpaiOnly, func(client *imvu.IMVU, args []string) {
+		if len(args) == 0 {
+			say(client, "usage_hug")
+			return
+		}
+		if triggerAction(client, senpaiID, "hug") {
+			say(client, "hugs", strings.Join(args, " "))
+		}
+	}, "hug")
+	register(senpaiOnly, ignoreUser, "ignore")
+	register(senpaiOnly, unignoreUser, "unignore")
+	registerHandler(moderatorsOnly, music, "music")
+	register(senpaiOnly, ask, "ask")
+	register(senpaiOnly, showUsage, "usage")
+	register(moderatorsOnly, setLanguage, "lang")
+	register(senpaiOnly, func(client *imvu.IMVU, _ []string) { say(client, "help") }, "help")
+	register(senpaiOnly, func(client *imvu.IMVU, _ []string) {
+		if err := client.LoadTriggers(); err != nil {
+			log.Printf("Failed to load triggers: %v", err)
+			return
+		}
+		say(client, "triggers", strings.Join(client.AvailableTriggers(), ", "))
+	}, "triggers")
+}
+
+func sitOnPreset(client *imvu.IMVU, actor, name string) {
+	seat, ok := cfg.Load().Seats[name]
+	if !ok {
+		say(client, "unknown_seat", name)
+		return
+	}
+
+	if seat.Message != "" {
+		client.SendChatMessage(seat.Message)
+	}
+
+	if err := client.SitOn(actor, seat.UserID, seat.FurniID, seat.SeatNumber); err != nil {
+		log.Printf("Failed to sit on %s: %v", name, err)
+	}
+}
+
+func listSeats(client *imvu.IMVU) {
+	seats, err := client.RoomSeats()
+	if err != nil {
+		log.Printf("Failed to list seats: %v", err)
+		return
+	}
+
+	names := make([]string, 0, len(cfg.Load().Seats))
+	for name := range cfg.Load().Seats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	say(client, "seats", len(seats), strings.Join(names, ", "))
+	for _, seat := range seats {
+		log.Printf("Seat %d on furni %d: user %s", seat.SeatNumber, seat.FurniID, seat.UserID)
+	}
+}
+
+func setTemperature(client *imvu.IMVU, args []string) {
+	if len(args) == 0 {
+		current := i18n.T(lang(), "temperature_default")
+		if t := gemini.CurrentConfig().Temperature; t != nil {
+			current = strconv.FormatFloat(float64(*t), 'f', -1, 32)
+		}
+		say(client, "temperature", current)
+		return
+	}
+
+	temperature, err := strconv.ParseFloat(args[0], 32)
+	if err != nil {
+		say(client, "usage_temp")
+		return
+	}
+
+	if err := gemini.SetTemperature(float32(temperature)); err != nil {
+		client.SendChatMessage(err.Error())
+		return
+	}
+
+	log.Printf("Gemini temperature set to %v", temperature)
+	say(client, "temperature_set", temperature)
+}
+
+// joinActions translates the on_join config into the actions of the client
+func joinActions() imvu.JoinActions {
+	onJoin := cfg.Load().OnJoin
+	actions := imvu.JoinActions{
+		PureUser: onJoin.PureUser,
+		Outfit:   cfg.Load().Outfits[onJoin.Outfit],
+		Greeting: onJoin.Greeting,
+	}
+	if seat, ok := cfg.Load().Seats[onJoin.Seat]; ok {
+		actions.Seat = &imvu.Seat{UserID: seat.UserID, FurniID: seat.FurniID, SeatNumber: seat.SeatNumber}
+	}
+	for _, emote := range onJoin.Emotes {
+		if trigger, ok := cfg.Load().Actions[emote]; ok {
+			emote = trigger
+		}
+		actions.Emotes = append(actions.Emotes, emote)
+	}
+	return actions
+}
+
+// triggerAction plays the avatar trigger configured for the action name and
+// reports whether it was sent
+func triggerAction(client *imvu.IMVU, actor, name string) bool {
+	trigger, ok := cfg.Load().Actions[name]
+	if !ok {
+		trigger = name
+	}
+
+	if err := client.TriggerAction(actor, trigger); err != nil {
+		log.Printf("Failed to trigger %s: %v", name, err)
+		say(client, "cant_action", name)
+		return false
 	}
+	return true
 }
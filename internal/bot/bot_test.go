@@ -0,0 +1,30 @@
+package bot
+
+import "testing"
+
+func TestRemoveMention(t *testing.T) {
+	mentions := []string{"giiny,", "Giiny", "ȺNA"}
+	tests := []struct {
+		name      string
+		text      string
+		want      string
+		mentioned bool
+	}{
+		{"prefix", "giiny, how are you?", "how are you?", true},
+		{"other case", "GIINY, hi", "hi", true},
+		{"middle", "hey giiny how are you", "hey how are you", true},
+		{"end", "how are you giiny?", "how are you", true},
+		{"none", "how are you?", "how are you?", false},
+		{"longer lowercase", "ȺȺȺȺȺȺȺ giiny,", "ȺȺȺȺȺȺȺ", true},
+		{"folded mention", "oi ⱥna, tudo bem?", "oi tudo bem?", true},
+		{"multibyte before", "çãõ giiny, olá", "çãõ olá", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, mentioned := removeMention(tt.text, mentions)
+			if got != tt.want || mentioned != tt.mentioned {
+				t.Errorf("removeMention(%q) = %q, %v, want %q, %v", tt.text, got, mentioned, tt.want, tt.mentioned)
+			}
+		})
+	}
+}
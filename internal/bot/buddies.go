@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"giiny/internal/events"
+	"giiny/internal/imvu"
+)
+
+// buddyGreetingCooldown is how long a buddy isn't greeted again after a
+// greeting
+const buddyGreetingCooldown = 12 * time.Hour
+
+func init() {
+	userArg := argSpec{usage: "usage_follow", params: []param{{name: "user", kind: argUser}}}
+	registerParsed(senpaiOnly, userArg, func(client *imvu.IMVU, userID string, args parsedArgs) {
+		follow(client, userID, args.get("user"), true)
+	}, "follow")
+	registerParsed(senpaiOnly, userArg, func(client *imvu.IMVU, userID string, args parsedArgs) {
+		follow(client, userID, args.get("user"), false)
+	}, "unfollow")
+}
+
+// follow follows or unfollows the target on IMVU, which adds them to or
+// removes them from the watched buddies
+func follow(client *imvu.IMVU, userID, target string, followed bool) {
+	name := client.UserName(target)
+	if followed {
+		if err := client.Follow(target); err != nil {
+			log.Printf("Failed to follow user %s: %v", target, err)
+			apologizeTo(client, userID)
+			return
+		}
+		whisper(client, userID, "followed", name)
+		return
+	}
+	if err := client.Unfollow(target); err != nil {
+		log.Printf("Failed to unfollow user %s: %v", target, err)
+		apologizeTo(client, userID)
+		return
+	}
+	whisper(client, userID, "unfollowed", name)
+}
+
+// greeted is when each buddy was last greeted
+var greeted = struct {
+	sync.Mutex
+	at map[string]time.Time
+}{at: map[string]time.Time{}}
+
+// greetBuddy reports whether the buddy is due a greeting, and records it
+func greetBuddy(userID string, now time.Time) bool {
+	if cfg.Load().Buddies.Greeting == "" {
+		return false
+	}
+	greeted.Lock()
+	defer greeted.Unlock()
+	if now.Sub(greeted.at[userID]) < buddyGreetingCooldown {
+		return false
+	}
+	greeted.at[userID] = now
+	return true
+}
+
+// watchBuddies tells senpai when a user the bot follows comes online or
+// enters the room, and greets them if configured
+func watchBuddies(ctx context.Context, client *imvu.IMVU) {
+	if cfg.Load().Buddies.PollMinutes <= 0 {
+		return
+	}
+
+	online := events.Subscribe[events.BuddyOnline](client.Events, 16)
+	defer online.Close()
+	joined := events.Subscribe[events.UserJoined](client.Events, 16)
+	defer joined.Close()
+	go client.WatchFollowing(ctx, time.Duration(cfg.Load().Buddies.PollMinutes)*time.Minute)
+
+	for {
+		select {
+		case e := <-online.C:
+			name := client.UserName(e.UserID)
+			log.Printf("Buddy %s (%s) came online", name, e.UserID)
+			whisper(client, senpaiID, "buddy_online", name)
+			alert("%s (%s) came online", name, e.UserID)
+			if greetBuddy(e.UserID, e.At) {
+				if err := client.SendDirectMessage(e.UserID, cfg.Load().Buddies.Greeting); err != nil {
+					log.Printf("Failed to greet buddy %s: %v", e.UserID, err)
+				}
+			}
+		case e := <-joined.C:
+			if !client.Follows(e.UserID) {
+				continue
+			}
+			name := client.UserName(e.UserID)
+			log.Printf("Buddy %s (%s) entered the room", name, e.UserID)
+			whisper(client, senpaiID, "buddy_arrived", name)
+			alert("%s (%s) entered %s", name, e.UserID, roomName(client))
+			if greetBuddy(e.UserID, time.Now()) {
+				client.SendWhisper(e.UserID, cfg.Load().Buddies.Greeting)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
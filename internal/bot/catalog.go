@@ -0,0 +1,70 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"giiny/internal/config"
+	"giiny/internal/events"
+	"giiny/internal/imvu"
+)
+
+// announceCatalog posts the interesting new and discounted products of the
+// store catalog in the room, as configured. It always runs so that enabling
+// the announcer only takes a config reload.
+func announceCatalog(ctx context.Context, client *imvu.IMVU) {
+	items := events.Subscribe[events.CatalogItem](client.Events, 32)
+	defer items.Close()
+	client.SetCatalogWanted(func() bool { return cfg.Load().Catalog.Announce })
+
+	// announced are the times of the announcements of the last hour
+	var announced []time.Time
+	for {
+		select {
+		case item := <-items.C:
+			c := cfg.Load().Catalog
+			if !c.Announce || !interestingProduct(c, item) || pause.Load() || sleeping(item.At) || lurking.Load() {
+				continue
+			}
+
+			announced = slices.DeleteFunc(announced, func(t time.Time) bool {
+				return item.At.Sub(t) >= time.Hour
+			})
+			if c.MaxPerHour > 0 && len(announced) >= c.MaxPerHour {
+				log.Printf("Not announcing catalog product %s, %d announced in the last hour", item.ProductID, len(announced))
+				continue
+			}
+			announced = append(announced, item.At)
+
+			if item.Kind == events.CatalogSale && item.Discount > 0 {
+				say(client, "catalog_sale", item.Name, item.CreatorName, item.Discount, item.URL)
+			} else {
+				say(client, "catalog_new", item.Name, item.CreatorName, item.Price, item.URL)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// interestingProduct reports whether the product passes the category and
+// price filters
+func interestingProduct(c config.Catalog, item events.CatalogItem) bool {
+	if c.MaxPrice > 0 && item.Price > c.MaxPrice {
+		return false
+	}
+	if len(c.Categories) == 0 {
+		return true
+	}
+	for _, category := range item.Categories {
+		if slices.ContainsFunc(c.Categories, func(want string) bool {
+			return strings.EqualFold(want, category)
+		}) {
+			return true
+		}
+	}
+	return false
+}
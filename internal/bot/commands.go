@@ -1,7 +1,107 @@
 package bot
 
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+
+	"giiny/internal/imvu"
+)
+
 const (
 	CmdQuit   = "quit"
 	CmdStop   = "stop"
 	CmdUptime = "uptime"
 )
+
+// access is who may run a chat command
+type access int
+
+const (
+	// senpaiOnly commands can only be run by senpai, who can run anything
+	senpaiOnly access = iota
+	// moderatorsOnly commands can also be run by the configured moderators
+	moderatorsOnly
+	// everyone can run the command, unless ignored
+	everyone
+)
+
+// handler runs a chat command for the user who sent it
+type handler func(client *imvu.IMVU, userID string, args []string)
+
+// command is an entry of the chat command registry
+type command struct {
+	run    handler
+	access access
+}
+
+// commands is the chat command registry, by lowercase name
+var commands = map[string]command{}
+
+// register adds a chat command to the registry under each of the names.
+// Registering a name twice is a programming error and panics.
+func register(access access, run func(client *imvu.IMVU, args []string), names ...string) {
+	registerHandler(access, func(client *imvu.IMVU, _ string, args []string) {
+		run(client, args)
+	}, names...)
+}
+
+// registerHandler is like register for commands that need to know who sent
+// them
+func registerHandler(access access, run handler, names ...string) {
+	for _, name := range names {
+		if _, ok := commands[name]; ok {
+			panic("bot: command registered twice: " + name)
+		}
+		commands[name] = command{run: run, access: access}
+	}
+}
+
+// canRunCommand reports whether the user may run the chat command
+func canRunCommand(userID, cmd string) bool {
+	fields := strings.Fields(cmd)
+	if len(fields) == 0 {
+		return false
+	}
+	c, ok := commands[strings.ToLower(fields[0])]
+	if !ok {
+		return false
+	}
+
+	if userID == senpaiID {
+		return true
+	}
+	if ignored(userID) || !commandAllowed(strings.ToLower(fields[0])) {
+		return false
+	}
+
+	switch c.access {
+	case everyone:
+		return true
+	case moderatorsOnly:
+		return slices.Contains(cfg.Load().Moderators, userID)
+	default:
+		return false
+	}
+}
+
+func runCommand(client *imvu.IMVU, userID, cmd string) {
+	log.Printf("[%s] Trying to run command: %s", userID, cmd)
+
+	fields := splitArgs(cmd)
+	if len(fields) == 0 {
+		return
+	}
+
+	c, ok := commands[strings.ToLower(fields[0])]
+	if !ok {
+		return
+	}
+	// Handlers don't take a context, so one past the deadline is only
+	// reported
+	runStage(context.Background(), stageCommand, func(context.Context) error {
+		c.run(client, userID, fields[1:])
+		return nil
+	})
+}
@@ -0,0 +1,278 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"giiny/internal/config"
+	"giiny/internal/i18n"
+	"giiny/internal/imvu"
+)
+
+func init() {
+	registerHandler(senpaiOnly, configCommand, "config")
+}
+
+// setting is an option that can be changed from the chat with "!config set".
+// Values are kept in the store and applied over the config file, at startup
+// and on every reload.
+type setting struct {
+	// values describes the accepted values in the usage message
+	values string
+	get    func(c *config.Config) string
+	set    func(c *config.Config, value string) error
+}
+
+var settings = map[string]setting{
+	"reply_mode": {
+		values: "open|addressed",
+		get: func(c *config.Config) string {
+			if c.Response.Lurk {
+				return "addressed"
+			}
+			return "open"
+		},
+		set: func(c *config.Config, value string) error {
+			switch value {
+			case "open", "addressed":
+				c.Response.Lurk = value == "addressed"
+				return nil
+			}
+			return fmt.Errorf("unknown reply mode %q", value)
+		},
+	},
+	"reply_chance": {
+		values: "0-1",
+		get: func(c *config.Config) string {
+			return strconv.FormatFloat(c.Response.ReplyChance, 'f', -1, 64)
+		},
+		set: func(c *config.Config, value string) error {
+			chance, err := strconv.ParseFloat(value, 64)
+			if err != nil || chance < 0 || chance > 1 {
+				return fmt.Errorf("invalid reply chance %q", value)
+			}
+			c.Response.ReplyChance = chance
+			return nil
+		},
+	},
+	"silence_minutes": {
+		values: "0, 1, 2...",
+		get: func(c *config.Config) string {
+			return strconv.Itoa(c.Response.SilenceMinutes)
+		},
+		set: func(c *config.Config, value string) error {
+			minutes, err := strconv.Atoi(value)
+			if err != nil || minutes < 0 {
+				return fmt.Errorf("invalid silence minutes %q", value)
+			}
+			c.Response.SilenceMinutes = minutes
+			return nil
+		},
+	},
+	"quiet_hours": {
+		values: "HH:MM-HH:MM|off",
+		get: func(c *config.Config) string {
+			if !c.QuietHours.Enabled {
+				return "off"
+			}
+			return c.QuietHours.Start + "-" + c.QuietHours.End
+		},
+		set: func(c *config.Config, value string) error {
+			if value == "off" {
+				c.QuietHours.Enabled = false
+				return nil
+			}
+			start, end, ok := strings.Cut(value, "-")
+			if !ok {
+				return fmt.Errorf("invalid quiet hours %q", value)
+			}
+			for _, clock := range []string{start, end} {
+				if _, err := config.ParseClock(clock); err != nil {
+					return err
+				}
+			}
+			c.QuietHours.Enabled = true
+			c.QuietHours.Start, c.QuietHours.End = start, end
+			return nil
+		},
+	},
+	"language": {
+		values: "pt|en|es",
+		get: func(c *config.Config) string {
+			return c.Language
+		},
+		set: func(c *config.Config, value string) error {
+			if !i18n.Supported(value) {
+				return fmt.Errorf("unsupported language %q", value)
+			}
+			c.Language = value
+			return nil
+		},
+	},
+}
+
+// configMu serializes the changes to the config, so a reload and a "!config"
+// change can't both start from the same config and drop each other's change
+var configMu sync.Mutex
+
+// applyOverrides applies the settings stored with "!config set" to c. Invalid
+// ones are skipped and reported together.
+func applyOverrides(c *config.Config) error {
+	overrides, err := db.ConfigOverrides()
+	if err != nil {
+		return err
+	}
+
+	var invalid []string
+	for key, value := range overrides {
+		s, ok := settings[key]
+		if !ok {
+			invalid = append(invalid, key)
+			continue
+		}
+		if err := s.set(c, value); err != nil {
+			invalid = append(invalid, key)
+		}
+	}
+	if len(invalid) > 0 {
+		slices.Sort(invalid)
+		return fmt.Errorf("invalid config overrides: %s", strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// configCommand handles "!config [get [key]]", "!config set <key> <value>"
+// and "!config reset <key>"
+func configCommand(client *imvu.IMVU, userID string, args []string) {
+	if len(args) == 0 {
+		args = []string{"get"}
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "get":
+		keys := args[1:]
+		if len(keys) == 0 {
+			for key := range settings {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+		}
+		var values []string
+		for _, key := range keys {
+			s, ok := settings[strings.ToLower(key)]
+			if !ok {
+				whisper(client, userID, "config_unknown", key)
+				return
+			}
+			values = append(values, strings.ToLower(key)+"="+s.get(cfg.Load()))
+		}
+		whisper(client, userID, "config_values", strings.Join(values, ", "))
+	case "set":
+		if len(args) < 3 {
+			whisper(client, userID, "usage_config")
+			return
+		}
+		setConfig(client, userID, strings.ToLower(args[1]), strings.ToLower(strings.Join(args[2:], " ")))
+	case "reset":
+		if len(args) != 2 {
+			whisper(client, userID, "usage_config")
+			return
+		}
+		resetConfig(client, userID, strings.ToLower(args[1]))
+	default:
+		whisper(client, userID, "usage_config")
+	}
+}
+
+// setConfig changes a setting for good and applies it right away
+func setConfig(client *imvu.IMVU, userID, key, value string) {
+	s, ok := settings[key]
+	if !ok {
+		whisper(client, userID, "config_unknown", key)
+		return
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	updated := *cfg.Load()
+	if err := s.set(&updated, value); err != nil {
+		whisper(client, userID, "config_invalid", key, s.values)
+		return
+	}
+	if err := updated.Validate(); err != nil {
+		log.Printf("Config change %s=%s rejected: %v", key, value, err)
+		whisper(client, userID, "config_invalid", key, s.values)
+		return
+	}
+	if err := db.SetConfigOverride(key, value, userID); err != nil {
+		log.Printf("Failed to save config change %s=%s: %v", key, value, err)
+		apologizeTo(client, userID)
+		return
+	}
+
+	wasLurk := responseSettings().Lurk
+	cfg.Store(&updated)
+	if lurk := responseSettings().Lurk; lurk != wasLurk {
+		lurking.Store(lurk)
+	}
+
+	log.Printf("Config changed by %s: %s=%s", userID, key, value)
+	if err := db.AddAudit(userID, "config set", key+" "+value, "ok", time.Now()); err != nil {
+		log.Printf("Failed to record audit entry: %v", err)
+	}
+	whisper(client, userID, "config_set", key, s.get(cfg.Load()))
+}
+
+// resetConfig drops the override of a setting and brings back the config
+// file's value of that setting only
+func resetConfig(client *imvu.IMVU, userID, key string) {
+	s, ok := settings[key]
+	if !ok {
+		whisper(client, userID, "config_unknown", key)
+		return
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	file, err := config.Load(cfg.Load().Path)
+	if err != nil {
+		log.Printf("Failed to reset config %s: %v", key, err)
+		apologizeTo(client, userID)
+		return
+	}
+	updated := *cfg.Load()
+	if err := s.set(&updated, s.get(file)); err != nil {
+		log.Printf("Failed to reset config %s: %v", key, err)
+		apologizeTo(client, userID)
+		return
+	}
+	if err := updated.Validate(); err != nil {
+		log.Printf("Config reset of %s rejected: %v", key, err)
+		apologizeTo(client, userID)
+		return
+	}
+	if _, err := db.DeleteConfigOverride(key); err != nil {
+		log.Printf("Failed to reset config %s: %v", key, err)
+		apologizeTo(client, userID)
+		return
+	}
+
+	wasLurk := responseSettings().Lurk
+	cfg.Store(&updated)
+	if lurk := responseSettings().Lurk; lurk != wasLurk {
+		lurking.Store(lurk)
+	}
+
+	log.Printf("Config reset by %s: %s", userID, key)
+	if err := db.AddAudit(userID, "config reset", key, "ok", time.Now()); err != nil {
+		log.Printf("Failed to record audit entry: %v", err)
+	}
+	whisper(client, userID, "config_set", key, s.get(cfg.Load()))
+}
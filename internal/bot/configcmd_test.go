@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"testing"
+)
+
+func TestSetConfig(t *testing.T) {
+	useTestDB(t)
+	useConfigFile(t, map[string]any{"response": map[string]any{"reply_chance": 0.5}})
+	client := testClient(t)
+
+	setConfig(client, "owner", "reply_chance", "0.3")
+	if got := cfg.Load().Response.ReplyChance; got != 0.3 {
+		t.Errorf("reply_chance = %v, want 0.3", got)
+	}
+	overrides, err := db.ConfigOverrides()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overrides["reply_chance"] != "0.3" {
+		t.Errorf("overrides = %v, want reply_chance=0.3 saved", overrides)
+	}
+
+	before := cfg.Load()
+	for _, change := range [][2]string{{"reply_chance", "1.5"}, {"reply_chance", "often"}, {"no_such_setting", "1"}} {
+		setConfig(client, "owner", change[0], change[1])
+	}
+	if cfg.Load() != before {
+		t.Error("an invalid change was applied")
+	}
+	if overrides, _ := db.ConfigOverrides(); len(overrides) != 1 || overrides["reply_chance"] != "0.3" {
+		t.Errorf("overrides = %v, want only reply_chance=0.3", overrides)
+	}
+}
+
+func TestSetConfigLurk(t *testing.T) {
+	useTestDB(t)
+	useConfigFile(t, nil)
+	client := testClient(t)
+	was := lurking.Load()
+	t.Cleanup(func() { lurking.Store(was) })
+
+	lurking.Store(false)
+	setConfig(client, "owner", "reply_mode", "addressed")
+	if !cfg.Load().Response.Lurk || !lurking.Load() {
+		t.Error("reply_mode=addressed did not start lurking")
+	}
+	setConfig(client, "owner", "reply_mode", "open")
+	if cfg.Load().Response.Lurk || lurking.Load() {
+		t.Error("reply_mode=open did not stop lurking")
+	}
+}
+
+func TestResetConfig(t *testing.T) {
+	useTestDB(t)
+	useConfigFile(t, map[string]any{"response": map[string]any{"reply_chance": 0.5, "silence_minutes": 4}})
+	client := testClient(t)
+
+	setConfig(client, "owner", "reply_chance", "0.3")
+	setConfig(client, "owner", "silence_minutes", "9")
+	resetConfig(client, "owner", "reply_chance")
+
+	if got := cfg.Load().Response.ReplyChance; got != 0.5 {
+		t.Errorf("reply_chance = %v, want the file's 0.5", got)
+	}
+	// Only the reset setting goes back to the file
+	if got := cfg.Load().Response.SilenceMinutes; got != 9 {
+		t.Errorf("silence_minutes = %v, want 9", got)
+	}
+	overrides, err := db.ConfigOverrides()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := overrides["reply_chance"]; ok || overrides["silence_minutes"] != "9" {
+		t.Errorf("overrides = %v, want only silence_minutes=9", overrides)
+	}
+}
@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"giiny/internal/events"
+	"giiny/internal/gemini"
+	"giiny/internal/i18n"
+	"giiny/internal/imvu"
+)
+
+func init() {
+	register(senpaiOnly, postStatus, "post")
+	register(senpaiOnly, postSnapshot, "snapshot")
+}
+
+// postStatus posts the arguments as a status on the bot's feed
+func postStatus(client *imvu.IMVU, args []string) {
+	if len(args) == 0 {
+		say(client, "usage_post")
+		return
+	}
+	if err := client.PostStatus(strings.Join(args, " ")); err != nil {
+		log.Printf("Failed to post status: %v", err)
+		return
+	}
+	say(client, "feed_done")
+}
+
+// postSnapshot posts the bot's current look on its feed, with the arguments
+// as caption
+func postSnapshot(client *imvu.IMVU, args []string) {
+	if err := client.PostSnapshot(strings.Join(args, " ")); err != nil {
+		log.Printf("Failed to post snapshot: %v", err)
+		return
+	}
+	say(client, "feed_done")
+}
+
+// followFeeds watches the feeds of the configured users and of senpai, when
+// the bot likes or comments on their posts, and reacts to new posts
+func followFeeds(ctx context.Context, client *imvu.IMVU) {
+	feed := cfg.Load().Feed
+	users := slices.Clone(feed.Watch)
+	if (feed.LikeSenpai || feed.CommentSenpai) && !slices.Contains(users, senpaiID) {
+		users = append(users, senpaiID)
+	}
+	if len(users) == 0 {
+		return
+	}
+
+	posted := events.Subscribe[events.FeedPosted](client.Events, 8)
+	defer posted.Close()
+	go client.WatchFeeds(ctx, users, time.Duration(feed.PollMinutes)*time.Minute)
+
+	for {
+		select {
+		case e := <-posted.C:
+			log.Printf("User %s posted %s on the feed: %s", e.UserID, e.PostID, e.Message)
+			if e.UserID == senpaiID {
+				reactToSenpaiPost(client, e)
+			}
+			if slices.Contains(cfg.Load().Feed.Watch, e.UserID) && !pause.Load() && !sleeping(e.At) {
+				say(client, "feed_posted", client.UserName(e.UserID), e.Message)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reactToSenpaiPost likes and comments on a new post of senpai, as configured
+func reactToSenpaiPost(client *imvu.IMVU, e events.FeedPosted) {
+	if cfg.Load().Feed.LikeSenpai {
+		if err := client.LikePost(e.PostID); err != nil {
+			log.Printf("Failed to like post %s: %v", e.PostID, err)
+		}
+	}
+	if !cfg.Load().Feed.CommentSenpai || !withinBudget() {
+		return
+	}
+
+	prompt := fmt.Sprintf("Seu senpai acabou de postar no feed do IMVU: \"%s\". Escreva um comentário curto para o post, do seu jeito.", e.Message)
+	var usage gemini.Usage
+	comment, err := gemini.Process(context.Background(), prompt, gemini.WithUsage(&usage), gemini.WithLanguage(i18n.Name(lang())))
+	if err != nil {
+		log.Printf("Error writing comment with Gemini: %v", err)
+		return
+	}
+	recordUsage(senpaiID, usage)
+	if comment = strings.TrimSpace(comment); comment == "" {
+		return
+	}
+	if err := client.CommentPost(e.PostID, comment); err != nil {
+		log.Printf("Failed to comment on post %s: %v", e.PostID, err)
+	}
+}
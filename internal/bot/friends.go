@@ -0,0 +1,124 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"giiny/internal/config"
+	"giiny/internal/events"
+	"giiny/internal/imvu"
+)
+
+// maxFriendsChecked bounds the friend lists fetched to count mutual friends
+const maxFriendsChecked = 1000
+
+// answerFriendRequests accepts or declines the friend requests announced in
+// the notification center, following the configured policy, until ctx is done
+func answerFriendRequests(ctx context.Context, client *imvu.IMVU) {
+	notifications := events.Subscribe[events.NotificationReceived](client.Events, 16)
+	defer notifications.Close()
+
+	for {
+		select {
+		case n := <-notifications.C:
+			if n.Kind != imvu.NotificationFriendRequest || n.SenderID == "" || !cfg.Load().FriendRequests.Enabled {
+				continue
+			}
+			answerFriendRequest(client, n.SenderID)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func answerFriendRequest(client *imvu.IMVU, userID string) {
+	name := client.UserName(userID)
+
+	accept, reason, err := judgeFriendRequest(client, cfg.Load().FriendRequests, userID)
+	if err != nil {
+		log.Printf("Failed to check the friend request of %s (%s): %v", name, userID, err)
+		alert("Friend request from %s (%s) left for you: %v", name, userID, err)
+		return
+	}
+
+	decision := "declined"
+	if accept {
+		decision = "accepted"
+		err = client.AcceptFriendRequest(userID)
+	} else {
+		err = client.DeclineFriendRequest(userID)
+	}
+	if err != nil {
+		log.Printf("Failed to answer the friend request of %s (%s): %v", name, userID, err)
+		alert("Friend request from %s (%s) should be %s (%s), but it failed: %v", name, userID, decision, reason, err)
+		return
+	}
+
+	log.Printf("Friend request from %s (%s) %s: %s", name, userID, decision, reason)
+	alert("Friend request from %s (%s) %s: %s", name, userID, decision, reason)
+}
+
+// judgeFriendRequest applies the policy to the sender of a friend request and
+// returns the decision with the reason for it
+func judgeFriendRequest(client *imvu.IMVU, policy config.FriendRequests, userID string) (bool, string, error) {
+	if slices.Contains(policy.Blocked, userID) || ignored(userID) {
+		return false, "blocked", nil
+	}
+
+	user, err := client.Profile(userID)
+	if err != nil {
+		return false, "", err
+	}
+
+	if policy.MinAccountDays > 0 {
+		created, ok := user.CreatedAt()
+		if !ok {
+			return false, "account age unknown", nil
+		}
+		if days := int(time.Since(created).Hours() / 24); days < policy.MinAccountDays {
+			return false, fmt.Sprintf("account is %d days old", days), nil
+		}
+	}
+	if user.BadgeLevel < policy.MinBadgeLevel {
+		return false, fmt.Sprintf("badge level %d", user.BadgeLevel), nil
+	}
+
+	if policy.MinMutualFriends > 0 {
+		mutual, err := mutualFriends(client, userID)
+		if err != nil {
+			return false, "", err
+		}
+		if mutual < policy.MinMutualFriends {
+			return false, fmt.Sprintf("%d mutual friends", mutual), nil
+		}
+	}
+
+	return true, "passed every check", nil
+}
+
+// mutualFriends counts the friends the user and the bot have in common
+func mutualFriends(client *imvu.IMVU, userID string) (int, error) {
+	ours, err := client.Friends(client.UserID(), maxFriendsChecked)
+	if err != nil {
+		return 0, err
+	}
+	theirs, err := client.Friends(userID, maxFriendsChecked)
+	if err != nil {
+		return 0, err
+	}
+
+	friends := make(map[string]bool, len(ours))
+	for _, f := range ours {
+		friends[f.UserID] = true
+	}
+	mutual := 0
+	for _, f := range theirs {
+		if friends[f.UserID] {
+			mutual++
+		}
+	}
+	return mutual, nil
+}
@@ -0,0 +1,149 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"giiny/internal/games"
+	"giiny/internal/gemini"
+	"giiny/internal/i18n"
+	"giiny/internal/imvu"
+	"giiny/internal/plugin"
+)
+
+const (
+	// eightBallAnswers is the number of eightball_N strings in the catalog
+	eightBallAnswers = 8
+	// triviaTimeout is how long a trivia question stays open
+	triviaTimeout = 60 * time.Second
+	// triviaTopScores is how many players !trivia scores lists
+	triviaTopScores = 5
+)
+
+var trivia games.Trivia
+
+func init() {
+	plugin.Register("games", func() plugin.Plugin { return gamesPlugin{} })
+}
+
+// gamesPlugin runs the chat games, which everyone can play
+type gamesPlugin struct {
+	plugin.Base
+}
+
+func (gamesPlugin) OnCommand(client *imvu.IMVU, userID, name string, args []string) bool {
+	switch name {
+	case "roll":
+		roll(client, userID, args)
+	case "8ball":
+		eightBall(client, args)
+	case "trivia":
+		playTrivia(client, userID, args)
+	default:
+		return false
+	}
+	return true
+}
+
+// roll rolls dice in dice notation, one six-sided die by default
+func roll(client *imvu.IMVU, userID string, args []string) {
+	spec := ""
+	if len(args) > 0 {
+		spec = args[0]
+	}
+
+	dice, err := games.ParseDice(spec)
+	if err != nil {
+		say(client, "usage_roll")
+		return
+	}
+
+	rolls, total := dice.Roll()
+	faces := make([]string, len(rolls))
+	for i, r := range rolls {
+		faces[i] = strconv.Itoa(r)
+	}
+	say(client, "roll", client.UserName(userID), dice, strings.Join(faces, " + "), total)
+}
+
+func eightBall(client *imvu.IMVU, args []string) {
+	if len(args) == 0 {
+		say(client, "usage_8ball")
+		return
+	}
+	say(client, fmt.Sprintf("eightball_%d", rand.IntN(eightBallAnswers)+1))
+}
+
+// playTrivia asks a question with no arguments, lists the best players with
+// "scores" and otherwise takes the arguments as a guess
+func playTrivia(client *imvu.IMVU, userID string, args []string) {
+	if len(args) == 1 && strings.EqualFold(args[0], "scores") {
+		showTriviaScores(client)
+		return
+	}
+
+	if len(args) > 0 {
+		q, ok := trivia.Guess(strings.Join(args, " "))
+		if !ok {
+			if _, open := trivia.Current(); !open {
+				say(client, "trivia_none")
+			}
+			return
+		}
+		if err := db.AddScore("trivia", userID, 1); err != nil {
+			log.Printf("Failed to add trivia score of user %s: %v", userID, err)
+		}
+		say(client, "trivia_correct", client.UserName(userID), q.Answer)
+		return
+	}
+
+	if q, ok := trivia.Current(); ok {
+		say(client, "trivia_question", q.Text)
+		return
+	}
+
+	if !withinBudget() {
+		noticeBudgetSpent(client)
+		return
+	}
+
+	var usage gemini.Usage
+	text, answer, err := gemini.Trivia(i18n.Name(lang()), &usage)
+	if err != nil {
+		log.Printf("Failed to generate trivia question: %v", err)
+		return
+	}
+	recordUsage(userID, usage)
+
+	round := trivia.Ask(games.Question{Text: text, Answer: answer})
+	log.Printf("Trivia question: %s (answer: %s)", text, answer)
+	say(client, "trivia_question", text)
+
+	time.AfterFunc(triviaTimeout, func() {
+		if q, ok := trivia.Expire(round); ok {
+			say(client, "trivia_timeout", q.Answer)
+		}
+	})
+}
+
+func showTriviaScores(client *imvu.IMVU) {
+	scores, err := db.TopScores("trivia", triviaTopScores)
+	if err != nil {
+		log.Printf("Failed to get trivia scores: %v", err)
+		return
+	}
+	if len(scores) == 0 {
+		say(client, "trivia_no_scores")
+		return
+	}
+
+	entries := make([]string, len(scores))
+	for i, score := range scores {
+		entries[i] = fmt.Sprintf("%d. %s (%d)", i+1, client.UserName(score.UserID), score.Points)
+	}
+	say(client, "trivia_scores", strings.Join(entries, ", "))
+}
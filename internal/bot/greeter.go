@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"giiny/internal/imvu"
+	"giiny/internal/plugin"
+)
+
+// greetCooldown is how long a user isn't greeted again after a greeting, so
+// reconnecting users aren't welcomed over and over
+const greetCooldown = 6 * time.Hour
+
+func init() {
+	plugin.Register("greeter", func() plugin.Plugin {
+		return &greeterPlugin{greeted: map[string]time.Time{}}
+	})
+}
+
+// greeterPlugin welcomes the users who enter the room
+type greeterPlugin struct {
+	plugin.Base
+
+	mu      sync.Mutex
+	greeted map[string]time.Time
+}
+
+func (g *greeterPlugin) OnUserJoined(client *imvu.IMVU, userID string) {
+	if ignored(userID) || sleeping(time.Now()) || profileOf(userID).NoPing {
+		return
+	}
+
+	g.mu.Lock()
+	last, ok := g.greeted[userID]
+	now := time.Now()
+	if ok && now.Sub(last) < greetCooldown {
+		g.mu.Unlock()
+		return
+	}
+	g.greeted[userID] = now
+	g.mu.Unlock()
+
+	go func() {
+		say(client, "greeting", callName(client, userID))
+		if cfg.Load().Greeter.Official {
+			if err := client.Greet(userID); err != nil {
+				log.Printf("Failed to greet user %s as a greeter: %v", userID, err)
+			}
+		}
+	}()
+}
+
+// OnTick forgets the greetings that are past the cooldown
+func (g *greeterPlugin) OnTick(_ *imvu.IMVU, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for userID, at := range g.greeted {
+		if now.Sub(at) >= greetCooldown {
+			delete(g.greeted, userID)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"giiny/internal/imvu"
+)
+
+const (
+	// greeterScoreInterval is how often the greeter score is recorded
+	greeterScoreInterval = time.Hour
+	// greeterScoreDays is the period of the score change shown by !greeter
+	greeterScoreDays = 7
+	// greeterRoomsShown is how many rooms !greeter rooms lists
+	greeterRoomsShown = 3
+)
+
+func init() {
+	registerHandler(everyone, showGreeter, "greeter")
+}
+
+// trackGreeterScore records the bot's greeter score every day, while it is
+// an official greeter
+func trackGreeterScore(ctx context.Context, client *imvu.IMVU) {
+	ticker := time.NewTicker(greeterScoreInterval)
+	defer ticker.Stop()
+
+	for {
+		isGreeter, score, err := client.GreeterStatus()
+		if err != nil {
+			log.Printf("Failed to check greeter score: %v", err)
+		} else if isGreeter {
+			if err := db.SetGreeterScore(time.Now(), score); err != nil {
+				log.Printf("Failed to record greeter score: %v", err)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// showGreeter whispers the bot's greeter score and how it changed over the
+// last week, or with "rooms" the rooms asking for greeters
+func showGreeter(client *imvu.IMVU, userID string, args []string) {
+	if len(args) > 0 && strings.ToLower(args[0]) == "rooms" {
+		showGreeterRooms(client, userID)
+		return
+	}
+
+	isGreeter, score, err := client.GreeterStatus()
+	if err != nil {
+		log.Printf("Failed to check greeter score: %v", err)
+		return
+	}
+	if !isGreeter {
+		whisper(client, userID, "greeter_not")
+		return
+	}
+
+	scores, err := db.GreeterScoresSince(time.Now().AddDate(0, 0, -greeterScoreDays))
+	if err != nil {
+		log.Printf("Failed to read greeter scores: %v", err)
+	}
+	if len(scores) == 0 {
+		whisper(client, userID, "greeter_score", score)
+		return
+	}
+	whisper(client, userID, "greeter_score_change", score, score-scores[0].Score, scores[0].Day)
+}
+
+// showGreeterRooms whispers a few of the rooms asking for greeters
+func showGreeterRooms(client *imvu.IMVU, userID string) {
+	rooms, err := client.GreeterRooms(greeterRoomsShown)
+	if err != nil {
+		log.Printf("Failed to get greeter rooms: %v", err)
+		return
+	}
+	if len(rooms) == 0 {
+		whisper(client, userID, "greeter_rooms_none")
+		return
+	}
+	for n, r := range rooms {
+		whisper(client, userID, "greeter_room", n+1, r.Name, r.Occupancy, r.Capacity)
+	}
+}
@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"giiny/internal/i18n"
+	"giiny/internal/imvu"
+	"giiny/internal/metrics"
+)
+
+// heartbeatReport is the health summary posted to the heartbeat webhook.
+// Counts are since the previous heartbeat.
+type heartbeatReport struct {
+	Version         string    `json:"version"`
+	At              time.Time `json:"at"`
+	UptimeSeconds   int64     `json:"uptime_seconds"`
+	Room            string    `json:"room"`
+	IMQ             string    `json:"imq"`
+	RoomsJoined     int64     `json:"rooms_joined"`
+	MessagesHandled int64     `json:"messages_handled"`
+	Failures        int64     `json:"failures"`
+}
+
+// sendHeartbeats sends senpai a health summary every configured number of
+// hours, by direct message or to the webhook, so that a bot that died quietly
+// is noticed by the missing heartbeat
+func sendHeartbeats(ctx context.Context, client *imvu.IMVU) {
+	if cfg.Load().Heartbeat.Hours <= 0 {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(cfg.Load().Heartbeat.Hours) * time.Hour)
+	defer ticker.Stop()
+
+	var last heartbeatReport
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		state, _, _ := client.ConnectionState()
+		now := time.Now()
+		total := heartbeatReport{
+			Version:         version(),
+			At:              now,
+			UptimeSeconds:   int64(now.Sub(startTime).Seconds()),
+			Room:            roomName(client),
+			IMQ:             state.String(),
+			RoomsJoined:     metrics.RoomsJoined.Value(),
+			MessagesHandled: metrics.ChatMessagesHandled.Value(),
+			Failures:        metrics.AnswerFailures.Value(),
+		}
+		report := total
+		report.RoomsJoined -= last.RoomsJoined
+		report.MessagesHandled -= last.MessagesHandled
+		report.Failures -= last.Failures
+		last = total
+
+		if err := sendHeartbeat(client, report); err != nil {
+			log.Printf("Failed to send the heartbeat: %v", err)
+		}
+	}
+}
+
+func sendHeartbeat(client *imvu.IMVU, report heartbeatReport) error {
+	if url := cfg.Load().Heartbeat.WebhookURL; url != "" {
+		data, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		return postJSON(url, data)
+	}
+
+	uptime := (time.Duration(report.UptimeSeconds) * time.Second).String()
+	return client.SendDirectMessage(senpaiID, i18n.T(lang(), "heartbeat",
+		uptime, report.Room, report.IMQ, report.RoomsJoined, report.MessagesHandled, report.Failures))
+}
@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"giiny/internal/events"
+	"giiny/internal/imvu"
+)
+
+// idleCheckInterval is how often the time since the last message is checked
+const idleCheckInterval = time.Minute
+
+// parked is set while the bot is out of its room because nobody talked
+var parked atomic.Bool
+
+// leaveWhenIdle leaves the room, and logs out if configured, once nobody but
+// bots said anything for the configured hours. Senpai brings the bot back
+// with /rejoin on Telegram, or a direct message saying "rejoin" while it is
+// still logged in.
+func leaveWhenIdle(ctx context.Context, client *imvu.IMVU) {
+	if cfg.Load().Idle.LeaveHours <= 0 {
+		return
+	}
+	idleFor := time.Duration(cfg.Load().Idle.LeaveHours) * time.Hour
+
+	messages := events.Subscribe[events.ChatMessage](client.Events, 64)
+	defer messages.Close()
+	notifications := events.Subscribe[events.NotificationReceived](client.Events, 16)
+	defer notifications.Close()
+
+	ticker := time.NewTicker(idleCheckInterval)
+	defer ticker.Stop()
+
+	lastMessage := time.Now()
+	for {
+		select {
+		case msg := <-messages.C:
+			if msg.Sender == events.SenderHuman {
+				lastMessage = msg.ReceivedAt
+			}
+		case e := <-notifications.C:
+			if parked.Load() && e.Kind == imvu.NotificationMessage && e.SenderID == senpaiID &&
+				strings.Contains(strings.ToLower(e.Message), "rejoin") {
+				unpark(client)
+			}
+		case now := <-ticker.C:
+			if parked.Load() {
+				// The idle time starts over once back in the room
+				lastMessage = now
+				continue
+			}
+			if now.Sub(lastMessage) >= idleFor {
+				park(client, idleFor)
+				// If leaving failed, wait as long again before the next try
+				lastMessage = now
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// park leaves the room after idleFor without messages
+func park(client *imvu.IMVU, idleFor time.Duration) {
+	room.Lock()
+	defer room.Unlock()
+
+	log.Printf("Nobody talked for %s, leaving room %s-%s", idleFor, room.owner, room.chat)
+	if err := client.LeaveRoom(room.owner, room.chat); err != nil {
+		log.Printf("Failed to leave idle room %s-%s: %v", room.owner, room.chat, err)
+		return
+	}
+	parked.Store(true)
+
+	if cfg.Load().Idle.Logout {
+		if err := client.Logout(); err != nil {
+			log.Printf("Failed to log out: %v", err)
+		}
+		alert("Nobody talked for %s, left room %s-%s and logged out. Send /rejoin to come back.", idleFor, room.owner, room.chat)
+		return
+	}
+	alert("Nobody talked for %s, left room %s-%s. Send /rejoin or DM me \"rejoin\" to come back.", idleFor, room.owner, room.chat)
+}
+
+// unpark logs in again if needed and rejoins the room left by park
+func unpark(client *imvu.IMVU) {
+	room.Lock()
+	defer room.Unlock()
+
+	if !parked.Load() {
+		alert("Not parked, still in room %s-%s", room.owner, room.chat)
+		return
+	}
+	if !client.Authenticated() {
+		if err := client.Login(cfg.Load().Username, cfg.Load().Password); err != nil {
+			alert("Failed to log in again: %v", err)
+			return
+		}
+	}
+	if _, err := client.JoinRoom(room.owner, room.chat); err != nil {
+		alert("Failed to rejoin room %s-%s: %v", room.owner, room.chat, err)
+		return
+	}
+	parked.Store(false)
+	alert("Back in room %s-%s", room.owner, room.chat)
+}
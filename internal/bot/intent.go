@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"giiny/internal/imvu"
+	"giiny/internal/intent"
+	"giiny/internal/metrics"
+)
+
+// cannedReplies is how many intent_<intent>_N strings there are for each
+// intent answered without Gemini
+var cannedReplies = map[intent.Intent]int{
+	intent.Greeting:  4,
+	intent.Smalltalk: 4,
+	intent.Abuse:     3,
+}
+
+var intents *intent.Router
+
+// routeIntent answers a short message without Gemini when it is a greeting,
+// small talk, abuse or asks for an avatar action the bot can play. It
+// reports whether the message was handled.
+func routeIntent(client *imvu.IMVU, userID, text string) bool {
+	c := cfg.Load().Intents
+	if !c.Enabled || utf8.RuneCountInString(text) > c.MaxLength {
+		return false
+	}
+
+	kind, score, err := intents.Classify(text, c.Threshold)
+	if err != nil {
+		log.Printf("Failed to classify message intent: %v", err)
+		return false
+	}
+
+	switch kind {
+	case intent.CommandLike:
+		action, ok := requestedAction(client, text)
+		if !ok {
+			return false
+		}
+		triggerAction(client, userID, action)
+	case intent.Greeting, intent.Smalltalk, intent.Abuse:
+		say(client, fmt.Sprintf("intent_%s_%d", kind, rand.IntN(cannedReplies[kind])+1))
+	default:
+		return false
+	}
+
+	log.Printf("Answered %q as %s (%.2f) without Gemini", text, kind, score)
+	metrics.IntentsRouted.Add(string(kind), 1)
+	return true
+}
+
+// requestedAction returns the first word of the message that names a
+// configured action or a trigger of the worn products
+func requestedAction(client *imvu.IMVU, text string) (string, bool) {
+	triggers := client.AvailableTriggers()
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if _, ok := cfg.Load().Actions[word]; ok || slices.Contains(triggers, word) {
+			return word, true
+		}
+	}
+	return "", false
+}
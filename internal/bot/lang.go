@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"log"
+	"strings"
+	"sync"
+
+	"giiny/internal/i18n"
+	"giiny/internal/imvu"
+)
+
+// languages holds the languages chosen with !lang, by room, on top of the
+// configured ones
+var languages struct {
+	sync.Mutex
+	byRoom map[string]string
+}
+
+// roomKey identifies a room in the language configuration
+func roomKey(owner, chat string) string {
+	return owner + "-" + chat
+}
+
+// lang returns the language of the current room
+func lang() string {
+	room.Lock()
+	key := roomKey(room.owner, room.chat)
+	room.Unlock()
+
+	languages.Lock()
+	chosen, ok := languages.byRoom[key]
+	languages.Unlock()
+	if ok {
+		return chosen
+	}
+
+	if configured := cfg.Load().Rooms[key].Language; configured != "" {
+		return configured
+	}
+	if configured, ok := cfg.Load().RoomLanguages[key]; ok {
+		return configured
+	}
+	return cfg.Load().Language
+}
+
+// say sends the canned string of key in the language of the current room
+func say(client *imvu.IMVU, key string, args ...any) error {
+	message := i18n.T(lang(), key, args...)
+	return send(func() error { return client.SendChatMessage(message) })
+}
+
+// whisper sends the canned string of key to the user only, in the language
+// they chose with "!me set language" or else the language of the current room
+func whisper(client *imvu.IMVU, userID, key string, args ...any) error {
+	message := i18n.T(userLang(userID), key, args...)
+	return send(func() error { return client.SendWhisper(userID, message) })
+}
+
+// setLanguage changes the language of the current room until the bot restarts
+func setLanguage(client *imvu.IMVU, args []string) {
+	if len(args) == 0 || !i18n.Supported(strings.ToLower(args[0])) {
+		say(client, "usage_lang")
+		return
+	}
+	language := strings.ToLower(args[0])
+
+	room.Lock()
+	key := roomKey(room.owner, room.chat)
+	room.Unlock()
+
+	languages.Lock()
+	if languages.byRoom == nil {
+		languages.byRoom = map[string]string{}
+	}
+	languages.byRoom[key] = language
+	languages.Unlock()
+
+	log.Printf("Language of room %s set to %s", key, language)
+	say(client, "lang_set")
+}
@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"giiny/internal/imvu"
+)
+
+// lookSize is how many items !whatiswearing shows
+const lookSize = 8
+
+func init() {
+	registerHandler(everyone, whatIsWearing, "whatiswearing")
+}
+
+// whatIsWearing whispers the products a participant is wearing with their
+// catalog links, and a picture of the whole look
+func whatIsWearing(client *imvu.IMVU, userID string, args []string) {
+	if len(args) == 0 {
+		whisper(client, userID, "usage_whatiswearing")
+		return
+	}
+
+	target, ok := findParticipant(client, strings.Join(args, " "))
+	if !ok {
+		whisper(client, userID, "not_in_room", strings.Join(args, " "))
+		return
+	}
+	name := client.UserName(target)
+
+	items, total, err := client.Look(target, lookSize)
+	if errors.Is(err, imvu.ErrNotParticipant) {
+		whisper(client, userID, "not_in_room", name)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to get the look of user %s: %v", target, err)
+		apologizeTo(client, userID)
+		return
+	}
+	if len(items) == 0 {
+		whisper(client, userID, "look_empty", name)
+		return
+	}
+
+	whisper(client, userID, "look", name, total)
+	if image, err := client.LookImage(target); err != nil {
+		log.Printf("Failed to get the look image of user %s: %v", target, err)
+	} else if image != "" {
+		whisper(client, userID, "look_image", image)
+	}
+	for _, item := range items {
+		title := item.ProductName
+		if title == "" {
+			title = item.ProductID
+		}
+		whisper(client, userID, "look_item", title, item.URL())
+	}
+}
+
+// findParticipant resolves a user ID, or the display name or username of a
+// participant of the current room, to a user ID
+func findParticipant(client *imvu.IMVU, user string) (string, bool) {
+	if _, err := strconv.ParseUint(user, 10, 64); err == nil {
+		return user, true
+	}
+
+	for _, participant := range client.Participants() {
+		if strings.EqualFold(client.UserName(participant), user) {
+			return participant, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"log"
+	"time"
+
+	"giiny/internal/gemini"
+	"giiny/internal/imvu"
+	"giiny/internal/mood"
+)
+
+var moods = mood.New(time.Now())
+
+// observeMood lets a chat message sway the mood and plays the emote configured
+// for the new mood when it changes
+func observeMood(client *imvu.IMVU, text string) {
+	if !cfg.Load().Mood.Enabled {
+		return
+	}
+
+	m, changed := moods.Observe(text, time.Now())
+	if !changed {
+		return
+	}
+	log.Printf("Mood changed to %s", m)
+
+	trigger, ok := cfg.Load().Mood.Emotes[string(m)]
+	if !ok {
+		return
+	}
+	if err := client.TriggerAction("", trigger); err != nil {
+		log.Printf("Failed to play the %s emote: %v", m, err)
+	}
+}
+
+// moodOption sets the current mood in the prompt
+func moodOption() gemini.ProcessOption {
+	if !cfg.Load().Mood.Enabled {
+		return gemini.WithMood("")
+	}
+
+	m, _ := moods.Current(time.Now())
+	return gemini.WithMood(mood.Prompt(m))
+}
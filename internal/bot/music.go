@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"giiny/internal/imvu"
+)
+
+func music(client *imvu.IMVU, userID string, args []string) {
+	if len(args) == 0 {
+		say(client, "usage_music")
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "on":
+		station := ""
+		if len(args) > 1 {
+			name := strings.ToLower(args[1])
+			url, ok := cfg.Load().MusicStations[name]
+			if !ok {
+				say(client, "unknown_station", name)
+				return
+			}
+			station = url
+		}
+		if err := client.ActivateMusic(userID, station); err != nil {
+			log.Printf("Failed to activate music: %v", err)
+			return
+		}
+		say(client, "music_on")
+	case "off":
+		if err := client.DeactivateMusic(userID); err != nil {
+			log.Printf("Failed to deactivate music: %v", err)
+			return
+		}
+		say(client, "music_off")
+	case "stations":
+		if len(cfg.Load().MusicStations) == 0 {
+			say(client, "no_stations")
+			return
+		}
+		names := make([]string, 0, len(cfg.Load().MusicStations))
+		for name := range cfg.Load().MusicStations {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		say(client, "stations", strings.Join(names, ", "))
+	default:
+		say(client, "usage_music")
+	}
+}
@@ -0,0 +1,43 @@
+package bot
+
+import (
+	"log"
+
+	"giiny/internal/imvu"
+)
+
+// notificationsShown is how many unread notifications !notifications lists
+const notificationsShown = 3
+
+// showNotifications whispers a summary of the unread notifications
+func showNotifications(client *imvu.IMVU, userID string, _ []string) {
+	notifications, err := client.Notifications()
+	if err != nil {
+		log.Printf("Failed to get notifications: %v", err)
+		return
+	}
+
+	var unread []imvu.Notification
+	counts := map[string]int{}
+	for _, n := range notifications {
+		if n.IsRead {
+			continue
+		}
+		unread = append(unread, n)
+		counts[n.Kind]++
+	}
+	if len(unread) == 0 {
+		whisper(client, userID, "notifications_none")
+		return
+	}
+
+	whisper(client, userID, "notifications_summary", len(unread),
+		counts[imvu.NotificationFriendRequest], counts[imvu.NotificationGift], counts[imvu.NotificationMessage])
+	for _, n := range unread[:min(len(unread), notificationsShown)] {
+		whisper(client, userID, "notification_entry", n.Message)
+	}
+}
+
+func init() {
+	registerHandler(senpaiOnly, showNotifications, "notifications")
+}
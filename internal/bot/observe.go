@@ -0,0 +1,36 @@
+package bot
+
+import (
+	"context"
+	"log"
+
+	"giiny/internal/config"
+	"giiny/internal/imvu"
+	"giiny/internal/store"
+)
+
+// Observe logs the transcript and activity of a room without joining it,
+// until ctx is done. The bot doesn't appear in the room and never answers.
+func Observe(ctx context.Context, c *config.Config, roomOwner, chatID string, client *imvu.IMVU, st *store.Store) error {
+	if err := setup(c, st); err != nil {
+		return err
+	}
+
+	log.Printf("Trying to login as %s", cfg.Load().Username)
+	if err := client.Login(cfg.Load().Username, cfg.Load().Password); err != nil {
+		return err
+	}
+	go client.Supervise(ctx)
+
+	if err := client.Observe(roomOwner, chatID); err != nil {
+		return err
+	}
+	room.owner, room.chat = roomOwner, chatID
+
+	log.Printf("Observing room %s-%s", roomOwner, chatID)
+	go recordTranscript(client)
+	go trackActivity(client)
+
+	<-ctx.Done()
+	return client.LeaveRoom(roomOwner, chatID)
+}
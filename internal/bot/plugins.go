@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"giiny/internal/config"
+	"giiny/internal/events"
+	"giiny/internal/imvu"
+	"giiny/internal/plugin"
+)
+
+// pluginTick is how often the plugins' OnTick is called
+const pluginTick = time.Minute
+
+// plugins are the plugins enabled in the config, in order
+var plugins []plugin.Plugin
+
+// loadPlugins creates the compiled in plugins with the names and starts the
+// external ones
+func loadPlugins(names []string, external []config.ExternalPlugin) error {
+	plugins = nil
+	for _, name := range names {
+		p, err := plugin.New(name)
+		if err != nil {
+			return fmt.Errorf("%w (available: %s)", err, strings.Join(plugin.Names(), ", "))
+		}
+		plugins = append(plugins, p)
+	}
+	for _, e := range external {
+		p, err := plugin.StartExternal(e.Name, e.Command)
+		if err != nil {
+			stopPlugins()
+			return err
+		}
+		plugins = append(plugins, p)
+		names = append(names, e.Name)
+	}
+	log.Printf("Plugins enabled: %s", strings.Join(names, ", "))
+	return nil
+}
+
+// stopPlugins stops the plugins that hold resources, like external processes
+func stopPlugins() {
+	for _, p := range plugins {
+		if closer, ok := p.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				log.Printf("Failed to stop plugin: %v", err)
+			}
+		}
+	}
+}
+
+// runPlugins feeds the room's arrivals, departures and the ticks to the
+// plugins until ctx is done. Chat messages and commands are fed by the chat
+// loop, so that plugins see them before the bot handles them.
+func runPlugins(ctx context.Context, client *imvu.IMVU) {
+	joined := events.Subscribe[events.UserJoined](client.Events, 16)
+	defer joined.Close()
+	left := events.Subscribe[events.UserLeft](client.Events, 16)
+	defer left.Close()
+
+	ticker := time.NewTicker(pluginTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e := <-joined.C:
+			if client.Sender(e.UserID) != events.SenderHuman {
+				continue
+			}
+			for _, p := range plugins {
+				p.OnUserJoined(client, e.UserID)
+			}
+		case e := <-left.C:
+			if client.Sender(e.UserID) != events.SenderHuman {
+				continue
+			}
+			for _, p := range plugins {
+				p.OnUserLeft(client, e.UserID)
+			}
+		case now := <-ticker.C:
+			for _, p := range plugins {
+				p.OnTick(client, now)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pluginsOnMessage shows a chat message to the plugins
+func pluginsOnMessage(client *imvu.IMVU, msg events.ChatMessage) {
+	for _, p := range plugins {
+		p.OnMessage(client, msg)
+	}
+}
+
+// runPluginCommand offers a command unknown to the bot to the plugins, until
+// one of them handles it
+func runPluginCommand(client *imvu.IMVU, userID, cmd string) {
+	fields := splitArgs(cmd)
+	if len(fields) == 0 || ignored(userID) {
+		return
+	}
+	name := strings.ToLower(fields[0])
+	if userID != senpaiID && !commandAllowed(name) {
+		return
+	}
+
+	for _, p := range plugins {
+		if p.OnCommand(client, userID, name, fields[1:]) {
+			return
+		}
+	}
+}
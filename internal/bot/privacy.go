@@ -0,0 +1,188 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"giiny/internal/imvu"
+)
+
+// webhookTimeout bounds the upload of JSON to a webhook
+const webhookTimeout = 30 * time.Second
+
+func init() {
+	registerHandler(senpaiOnly, historyCommand, "history")
+	registerHandler(everyone, forgetCommand, "forget")
+}
+
+// userExport is everything stored about a user, as exported by
+// "!history export"
+type userExport struct {
+	UserID        string           `json:"user_id"`
+	ExportedAt    time.Time        `json:"exported_at"`
+	Profile       *exportedProfile `json:"profile,omitempty"`
+	Conversations []exportedThread `json:"conversations"`
+	Memories      []exportedFact   `json:"memories"`
+	ChatLines     []exportedLine   `json:"chat_lines"`
+}
+
+type exportedThread struct {
+	Room    string         `json:"room"`
+	Summary string         `json:"summary,omitempty"`
+	Turns   []exportedTurn `json:"turns"`
+}
+
+type exportedTurn struct {
+	Role string `json:"role"`
+	Text string `json:"text"`
+}
+
+type exportedFact struct {
+	Fact      string    `json:"fact"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportedProfile struct {
+	Nickname  string    `json:"nickname,omitempty"`
+	Pronouns  string    `json:"pronouns,omitempty"`
+	Language  string    `json:"language,omitempty"`
+	NoPing    bool      `json:"no_ping"`
+	NoAI      bool      `json:"no_ai"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type exportedLine struct {
+	Queue   string    `json:"queue"`
+	Message string    `json:"message"`
+	At      time.Time `json:"at"`
+}
+
+// historyCommand handles "!history export <user>"
+func historyCommand(client *imvu.IMVU, userID string, args []string) {
+	if len(args) != 2 || strings.ToLower(args[0]) != "export" {
+		whisper(client, userID, "usage_history")
+		return
+	}
+
+	where, err := exportUser(args[1])
+	if err != nil {
+		log.Printf("Failed to export the data of user %s: %v", args[1], err)
+		whisper(client, userID, "export_failed")
+		return
+	}
+	whisper(client, userID, "export_done", where)
+}
+
+// forgetCommand handles "!forget me", deleting what the bot stored about the
+// user who asked
+func forgetCommand(client *imvu.IMVU, userID string, args []string) {
+	if len(args) != 1 || strings.ToLower(args[0]) != "me" {
+		whisper(client, userID, "usage_forget")
+		return
+	}
+
+	if err := db.ForgetUser(userID); err != nil {
+		log.Printf("Failed to forget user %s: %v", userID, err)
+		whisper(client, userID, "forget_failed")
+		return
+	}
+	for _, key := range userThreads(userID) {
+		history.Reset(key)
+	}
+	forgetProfile(userID)
+	log.Printf("Forgot the data of user %s at their request", userID)
+	whisper(client, userID, "forgotten")
+}
+
+// userThreads returns the conversation keys of the user in every room
+func userThreads(userID string) []string {
+	var keys []string
+	for _, key := range history.Keys() {
+		if strings.HasSuffix(key, "/"+userID) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// exportUser bundles the profile, conversations, memories and chat lines of
+// the user and saves them as configured. It returns the file or webhook written to.
+func exportUser(userID string) (string, error) {
+	export := userExport{UserID: userID, ExportedAt: time.Now().UTC()}
+
+	for _, key := range userThreads(userID) {
+		summary, turns := history.Context(key)
+		thread := exportedThread{Room: strings.TrimSuffix(key, "/"+userID), Summary: summary}
+		for _, turn := range turns {
+			thread.Turns = append(thread.Turns, exportedTurn{Role: turn.Role, Text: turn.Text})
+		}
+		export.Conversations = append(export.Conversations, thread)
+	}
+
+	profile, err := db.Profile(userID)
+	if err != nil {
+		return "", err
+	}
+	if !profile.UpdatedAt.IsZero() {
+		export.Profile = &exportedProfile{
+			Nickname:  profile.Nickname,
+			Pronouns:  profile.Pronouns,
+			Language:  profile.Language,
+			NoPing:    profile.NoPing,
+			NoAI:      profile.NoAI,
+			UpdatedAt: profile.UpdatedAt,
+		}
+	}
+
+	memories, err := db.Memories(userID)
+	if err != nil {
+		return "", err
+	}
+	for _, m := range memories {
+		export.Memories = append(export.Memories, exportedFact{Fact: m.Fact, CreatedAt: m.CreatedAt})
+	}
+
+	lines, err := db.UserChatLines(userID)
+	if err != nil {
+		return "", err
+	}
+	for _, l := range lines {
+		export.ChatLines = append(export.ChatLines, exportedLine{Queue: l.Queue, Message: l.Message, At: l.CreatedAt})
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	if url := cfg.Load().Export.WebhookURL; url != "" {
+		return "the webhook", postJSON(url, data)
+	}
+
+	if err := os.MkdirAll(cfg.Load().Export.Dir, 0o700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(cfg.Load().Export.Dir, fmt.Sprintf("%s-%s.json", userID, export.ExportedAt.Format("20060102-150405")))
+	return path, os.WriteFile(path, data, 0o600)
+}
+
+// postJSON uploads JSON, such as an export, to a webhook
+func postJSON(url string, data []byte) error {
+	client := http.Client{Timeout: webhookTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package bot
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"giiny/internal/i18n"
+	"giiny/internal/imvu"
+	"giiny/internal/store"
+)
+
+const (
+	// maxProfileText is the longest nickname or pronouns a user can set
+	maxProfileText = 32
+	// maxCachedProfiles is how many profiles are cached at most
+	maxCachedProfiles = 1000
+)
+
+func init() {
+	registerHandler(everyone, meCommand, "me")
+}
+
+// profiles caches the users' profiles, which are read for every message.
+// forgotten counts the profiles dropped, so a profile read from the store
+// while it changed isn't cached.
+var profiles = struct {
+	sync.Mutex
+	byUser    map[string]store.Profile
+	forgotten uint64
+}{byUser: map[string]store.Profile{}}
+
+// profileOf returns the profile of the user, empty if they never set one or
+// it can't be read
+func profileOf(userID string) store.Profile {
+	profiles.Lock()
+	p, ok := profiles.byUser[userID]
+	forgotten := profiles.forgotten
+	profiles.Unlock()
+	if ok {
+		return p
+	}
+
+	p, err := db.Profile(userID)
+	if err != nil {
+		log.Printf("Failed to load profile of user %s: %v", userID, err)
+		return p
+	}
+
+	profiles.Lock()
+	defer profiles.Unlock()
+	if profiles.forgotten != forgotten {
+		return p
+	}
+	if len(profiles.byUser) >= maxCachedProfiles {
+		for id := range profiles.byUser {
+			delete(profiles.byUser, id)
+			break
+		}
+	}
+	profiles.byUser[userID] = p
+	return p
+}
+
+// forgetProfile drops the cached profile of the user
+func forgetProfile(userID string) {
+	profiles.Lock()
+	delete(profiles.byUser, userID)
+	profiles.forgotten++
+	profiles.Unlock()
+}
+
+// callName returns what the bot calls the user: their nickname or their
+// display name
+func callName(client *imvu.IMVU, userID string) string {
+	if nickname := profileOf(userID).Nickname; nickname != "" {
+		return nickname
+	}
+	return client.UserName(userID)
+}
+
+// userLang returns the language the user asked to be spoken to in, or the
+// language of the current room
+func userLang(userID string) string {
+	if language := profileOf(userID).Language; language != "" {
+		return language
+	}
+	return lang()
+}
+
+// meCommand shows or edits the profile of the user: "!me",
+// "!me set <field> <value>" or "!me clear <field>"
+func meCommand(client *imvu.IMVU, userID string, args []string) {
+	p := profileOf(userID)
+	if len(args) == 0 {
+		whisper(client, userID, "me_profile",
+			orDash(p.Nickname), orDash(p.Pronouns), orDash(p.Language),
+			onOff(userID, p.NoPing), onOff(userID, p.NoAI))
+		return
+	}
+	if len(args) < 2 {
+		whisper(client, userID, "usage_me")
+		return
+	}
+
+	field, value := strings.ToLower(args[1]), strings.TrimSpace(strings.Join(args[2:], " "))
+	switch strings.ToLower(args[0]) {
+	case "set":
+		if value == "" || !setProfileField(&p, field, value) {
+			whisper(client, userID, "usage_me")
+			return
+		}
+	case "clear":
+		if !setProfileField(&p, field, "") {
+			whisper(client, userID, "usage_me")
+			return
+		}
+	default:
+		whisper(client, userID, "usage_me")
+		return
+	}
+
+	p.UserID = userID
+	if err := db.SaveProfile(p); err != nil {
+		log.Printf("Failed to save profile of user %s: %v", userID, err)
+		apologizeTo(client, userID)
+		return
+	}
+	forgetProfile(userID)
+	whisper(client, userID, "me_updated", field)
+}
+
+// setProfileField sets a field of the profile from its "!me set" value, or
+// resets it when value is empty. It reports whether the field and value are
+// valid.
+func setProfileField(p *store.Profile, field, value string) bool {
+	switch field {
+	case "nickname", "pronouns":
+		if utf8.RuneCountInString(value) > maxProfileText {
+			return false
+		}
+		if field == "nickname" {
+			p.Nickname = value
+		} else {
+			p.Pronouns = value
+		}
+	case "language":
+		value = strings.ToLower(value)
+		if value != "" && !i18n.Supported(value) {
+			return false
+		}
+		p.Language = value
+	case "noping", "noai":
+		var on bool
+		switch strings.ToLower(value) {
+		case "on":
+			on = true
+		case "off", "":
+		default:
+			return false
+		}
+		if field == "noping" {
+			p.NoPing = on
+		} else {
+			p.NoAI = on
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// onOff returns the word for a setting being on or off in the user's language
+func onOff(userID string, on bool) string {
+	if on {
+		return i18n.T(userLang(userID), "me_on")
+	}
+	return i18n.T(userLang(userID), "me_off")
+}
@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"giiny/internal/config"
+	"giiny/internal/imvu"
+)
+
+// sleep is the owner's override of the quiet hours. Asleep forces quiet until
+// !wake; a !wake during the quiet hours keeps the bot awake until they end.
+var sleep struct {
+	sync.Mutex
+	asleep     bool
+	awakeUntil time.Time
+}
+
+func init() {
+	register(senpaiOnly, goToSleep, "sleep")
+	register(senpaiOnly, wakeUp, "wake")
+}
+
+// sleeping reports whether the bot should stay quiet at now, because the owner
+// sent it to sleep or it is within the quiet hours. Commands, logging and the
+// connection keep working while it sleeps.
+func sleeping(now time.Time) bool {
+	sleep.Lock()
+	defer sleep.Unlock()
+
+	if sleep.asleep {
+		return true
+	}
+	if now.Before(sleep.awakeUntil) {
+		return false
+	}
+	_, quiet := quietUntil(cfg.Load().QuietHours, now)
+	return quiet
+}
+
+func goToSleep(client *imvu.IMVU, _ []string) {
+	sleep.Lock()
+	sleep.asleep = true
+	sleep.awakeUntil = time.Time{}
+	sleep.Unlock()
+
+	log.Printf("Sleeping until !wake")
+	say(client, "sleeping")
+}
+
+func wakeUp(client *imvu.IMVU, _ []string) {
+	now := time.Now()
+	end, quiet := quietUntil(cfg.Load().QuietHours, now)
+
+	sleep.Lock()
+	sleep.asleep = false
+	sleep.awakeUntil = time.Time{}
+	if quiet {
+		sleep.awakeUntil = end
+	}
+	sleep.Unlock()
+
+	log.Printf("Awake")
+	say(client, "awake")
+}
+
+// quietUntil reports whether now is within the quiet hours and, if so, when
+// they end
+func quietUntil(q config.QuietHours, now time.Time) (time.Time, bool) {
+	if !q.Enabled {
+		return time.Time{}, false
+	}
+	loc, err := q.Location()
+	if err != nil {
+		return time.Time{}, false
+	}
+	start, _ := config.ParseClock(q.Start)
+	end, _ := config.ParseClock(q.End)
+
+	local := now.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	switch {
+	case start < end && minute >= start && minute < end:
+		return midnight.Add(time.Duration(end) * time.Minute), true
+	case start > end && minute >= start:
+		// The quiet hours go past midnight and end tomorrow
+		return midnight.AddDate(0, 0, 1).Add(time.Duration(end) * time.Minute), true
+	case start > end && minute < end:
+		return midnight.Add(time.Duration(end) * time.Minute), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,37 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"giiny/internal/config"
+)
+
+func TestQuietUntil(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.March, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name  string
+		hours config.QuietHours
+		now   time.Time
+		until time.Time
+		quiet bool
+	}{
+		{"same day", config.QuietHours{Enabled: true, Start: "13:00", End: "15:30", Timezone: "UTC"}, at(10, 14, 0), at(10, 15, 30), true},
+		{"same day start", config.QuietHours{Enabled: true, Start: "13:00", End: "15:30", Timezone: "UTC"}, at(10, 13, 0), at(10, 15, 30), true},
+		{"same day end", config.QuietHours{Enabled: true, Start: "13:00", End: "15:30", Timezone: "UTC"}, at(10, 15, 30), time.Time{}, false},
+		{"before midnight", config.QuietHours{Enabled: true, Start: "23:00", End: "07:00", Timezone: "UTC"}, at(10, 23, 30), at(11, 7, 0), true},
+		{"after midnight", config.QuietHours{Enabled: true, Start: "23:00", End: "07:00", Timezone: "UTC"}, at(11, 2, 0), at(11, 7, 0), true},
+		{"outside", config.QuietHours{Enabled: true, Start: "23:00", End: "07:00", Timezone: "UTC"}, at(10, 12, 0), time.Time{}, false},
+		{"disabled", config.QuietHours{Start: "00:00", End: "23:59", Timezone: "UTC"}, at(10, 12, 0), time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until, quiet := quietUntil(tt.hours, tt.now)
+			if quiet != tt.quiet || !until.Equal(tt.until) {
+				t.Errorf("quietUntil(%s) = %v, %v, want %v, %v", tt.now.Format(time.Kitchen), until, quiet, tt.until, tt.quiet)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"giiny/internal/autoreply"
+	"giiny/internal/config"
+	"giiny/internal/gemini"
+)
+
+// reloadDelay groups the burst of events editors produce when saving a file
+const reloadDelay = 500 * time.Millisecond
+
+// loadPersona sets the persona from the persona file, or the built-in persona
+// if there is none
+func loadPersona(path string) error {
+	text, err := readPersona(path)
+	if err != nil {
+		return err
+	}
+	gemini.SetPersona(text)
+	return nil
+}
+
+// readPersona reads the persona file, returning an empty text for the
+// built-in persona if there is none
+func readPersona(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read persona file: %w", err)
+	}
+	text := strings.TrimSpace(string(data))
+	if text == "" {
+		return "", fmt.Errorf("persona file %s is empty", path)
+	}
+	return text, nil
+}
+
+// watchConfig reloads the configuration and persona files when they change,
+// until ctx is done. Only the settings that are safe to change at runtime are
+// applied; the others are logged as needing a restart.
+func watchConfig(ctx context.Context) {
+	if cfg.Load().Path == "" {
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("Failed to watch the config file: %v", err)
+		return
+	}
+	defer watcher.Close()
+
+	// Editors often replace files instead of writing them, so the directories
+	// are watched rather than the files
+	watched := map[string]bool{}
+	watch := func(path string) {
+		if path == "" {
+			return
+		}
+		dir := filepath.Dir(path)
+		if watched[dir] {
+			return
+		}
+		if err := watcher.Add(dir); err != nil {
+			log.Printf("Failed to watch %s: %v", dir, err)
+			return
+		}
+		watched[dir] = true
+	}
+	for _, path := range watchedFiles() {
+		watch(path)
+	}
+
+	timer := time.NewTimer(reloadDelay)
+	timer.Stop()
+
+	for {
+		select {
+		case e, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !e.Has(fsnotify.Write) && !e.Has(fsnotify.Create) && !e.Has(fsnotify.Rename) {
+				continue
+			}
+			if slices.ContainsFunc(watchedFiles(), func(path string) bool { return samePath(e.Name, path) }) {
+				timer.Reset(reloadDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Config watcher error: %v", err)
+		case <-timer.C:
+			if err := reloadConfig(); err != nil {
+				log.Printf("Config not reloaded: %v", err)
+				alert("Config not reloaded: %v", err)
+			}
+			for _, path := range watchedFiles() {
+				watch(path)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// watchedFiles returns the config file and the persona files it names
+func watchedFiles() []string {
+	files := []string{cfg.Load().Path, cfg.Load().PersonaFile}
+	for _, settings := range cfg.Load().Rooms {
+		files = append(files, settings.PersonaFile)
+	}
+	return files
+}
+
+func samePath(a, b string) bool {
+	return b != "" && filepath.Clean(a) == filepath.Clean(b)
+}
+
+// reloadable are the settings that are read whenever they are used, so they
+// can be replaced as they are
+var reloadable = []struct {
+	name string
+	get  func(c *config.Config) any
+}{
+	{"moderators", func(c *config.Config) any { return &c.Moderators }},
+	{"response", func(c *config.Config) any { return &c.Response }},
+	{"answer_cache", func(c *config.Config) any { return &c.AnswerCache }},
+	{"intents", func(c *config.Config) any { return &c.Intents }},
+	{"deadlines", func(c *config.Config) any { return &c.Deadlines }},
+	{"actions", func(c *config.Config) any { return &c.Actions }},
+	{"seats", func(c *config.Config) any { return &c.Seats }},
+	{"try_on_seconds", func(c *config.Config) any { return &c.TryOnSeconds }},
+	{"mood", func(c *config.Config) any { return &c.Mood }},
+	{"typing", func(c *config.Config) any { return &c.Typing }},
+	{"friend_requests", func(c *config.Config) any { return &c.FriendRequests }},
+	{"spending", func(c *config.Config) any { return &c.Spending }},
+	{"quiet_hours", func(c *config.Config) any { return &c.QuietHours }},
+	{"greeter", func(c *config.Config) any { return &c.Greeter }},
+	{"occupancy_alerts", func(c *config.Config) any { return &c.OccupancyAlerts }},
+	{"catalog", func(c *config.Config) any { return &c.Catalog }},
+	{"translation", func(c *config.Config) any { return &c.Translation }},
+	{"export", func(c *config.Config) any { return &c.Export }},
+	{"language", func(c *config.Config) any { return &c.Language }},
+	{"room_languages", func(c *config.Config) any { return &c.RoomLanguages }},
+	{"rooms", func(c *config.Config) any { return &c.Rooms }},
+	{"music_stations", func(c *config.Config) any { return &c.MusicStations }},
+	{"anti_spam", func(c *config.Config) any { return &c.AntiSpam }},
+	{"daily_token_budget", func(c *config.Config) any { return &c.DailyTokenBudget }},
+	{"telegram.alert_keywords", func(c *config.Config) any { return &c.Telegram.AlertKeywords }},
+}
+
+// reloadConfig loads the config file again and applies the safe changes.
+// Everything is read and checked before anything is applied, so a failed
+// reload leaves the running configuration as it was.
+func reloadConfig() error {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	next, err := config.Load(cfg.Load().Path)
+	if err != nil {
+		return err
+	}
+	// Command line flags override the file and are not reloaded, and the
+	// settings changed with !config stay changed
+	next.DryRun = cfg.Load().DryRun
+	if err := applyOverrides(next); err != nil {
+		log.Printf("Config overrides not applied: %v", err)
+	}
+	if err := next.Validate(); err != nil {
+		return err
+	}
+
+	current := cfg.Load()
+	persona, err := readPersona(next.PersonaFile)
+	if err != nil {
+		return err
+	}
+	personas, err := readRoomPersonas(next)
+	if err != nil {
+		return err
+	}
+	var engine *autoreply.Engine
+	if !reflect.DeepEqual(next.AutoReplies, current.AutoReplies) {
+		if engine, err = autoreply.New(next.AutoReplies, generateAutoReply); err != nil {
+			return fmt.Errorf("invalid auto replies: %w", err)
+		}
+	}
+	geminiChanged := !reflect.DeepEqual(next.Gemini, current.Gemini)
+	if geminiChanged {
+		if err := next.Gemini.Validate(); err != nil {
+			return err
+		}
+	}
+
+	var reloaded []string
+	updated := *current
+	key := currentRoomKey()
+	wasLurk := roomResponse(key).Lurk
+
+	previous := gemini.Persona()
+	gemini.SetPersona(persona)
+	if next.PersonaFile != current.PersonaFile {
+		reloaded = append(reloaded, "persona_file")
+	} else if gemini.Persona() != previous {
+		reloaded = append(reloaded, "persona")
+	}
+	updated.PersonaFile = next.PersonaFile
+
+	if old := roomPersonas.Swap(&personas); old != nil && !maps.Equal(*old, personas) {
+		reloaded = append(reloaded, "room personas")
+	}
+
+	if engine != nil {
+		autoReplies.Store(engine)
+		updated.AutoReplies = next.AutoReplies
+		reloaded = append(reloaded, "auto_replies")
+	}
+
+	if geminiChanged {
+		// Validated above, so this can't fail
+		if err := gemini.SetConfig(next.Gemini); err != nil {
+			return err
+		}
+		updated.Gemini = next.Gemini
+		reloaded = append(reloaded, "gemini")
+	}
+
+	for _, field := range reloadable {
+		from, to := reflect.ValueOf(field.get(&updated)).Elem(), reflect.ValueOf(field.get(next)).Elem()
+		if !reflect.DeepEqual(from.Interface(), to.Interface()) {
+			from.Set(to)
+			reloaded = append(reloaded, field.name)
+		}
+	}
+
+	cfg.Store(&updated)
+
+	// A changed lurk setting applies to the current room right away
+	if lurk := roomResponse(key).Lurk; lurk != wasLurk {
+		lurking.Store(lurk)
+	}
+
+	// Everything else is read once at startup
+	if restart := changedFields(&updated, next); len(restart) > 0 {
+		log.Printf("Config changes that need a restart: %s", strings.Join(restart, ", "))
+	}
+	if len(reloaded) == 0 {
+		log.Printf("Config reloaded, nothing changed")
+		return nil
+	}
+	log.Printf("Config reloaded: %s", strings.Join(reloaded, ", "))
+	return nil
+}
+
+// changedFields returns the JSON names of the top-level fields that differ
+func changedFields(a, b *config.Config) []string {
+	var names []string
+	va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	for i := range va.NumField() {
+		name, _, _ := strings.Cut(va.Type().Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"giiny/internal/config"
+	"giiny/internal/gemini"
+)
+
+// writeConfigFile writes a config file that passes Validate, with the fields
+// of extra on top, and makes it the current config
+func writeConfigFile(t *testing.T, path string, extra map[string]any) {
+	t.Helper()
+	fields := map[string]any{
+		"username":      "giiny",
+		"password":      "secret",
+		"room_url":      "https://www.imvu.com/next/chat/room-1-2/",
+		"database_path": filepath.Join(filepath.Dir(path), "giiny.sqlite"),
+	}
+	for k, v := range extra {
+		fields[k] = v
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// useConfigFile writes the config file and loads it as the running config
+func useConfigFile(t *testing.T, extra map[string]any) string {
+	t.Helper()
+	// The environment overrides the file
+	for _, name := range []string{"USERNAME", "PASSWORD", "ROOM_URL", "DB_PATH"} {
+		t.Setenv(name, "")
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfigFile(t, path, extra)
+	c, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("test config is invalid: %v", err)
+	}
+	useConfig(t, c)
+
+	persona := gemini.Persona()
+	t.Cleanup(func() { gemini.SetPersona(persona) })
+	return path
+}
+
+func TestReloadConfig(t *testing.T) {
+	useTestDB(t)
+	dir := t.TempDir()
+	persona := filepath.Join(dir, "persona.txt")
+	if err := os.WriteFile(persona, []byte("You are Giiny."), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := useConfigFile(t, map[string]any{
+		"persona_file": persona,
+		"response":     map[string]any{"reply_chance": 0.5},
+	})
+
+	if err := os.WriteFile(persona, []byte("You are Giiny, but grumpy."), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	writeConfigFile(t, path, map[string]any{
+		"username":     "someone-else",
+		"persona_file": persona,
+		"response":     map[string]any{"reply_chance": 0.2},
+	})
+
+	if err := reloadConfig(); err != nil {
+		t.Fatalf("reloadConfig: %v", err)
+	}
+	if got := cfg.Load().Response.ReplyChance; got != 0.2 {
+		t.Errorf("reply_chance = %v, want 0.2", got)
+	}
+	if got := strings.TrimSpace(gemini.Persona()); got != "You are Giiny, but grumpy." {
+		t.Errorf("persona = %q, want the new one", got)
+	}
+	// Settings read once at startup need a restart
+	if got := cfg.Load().Username; got != "giiny" {
+		t.Errorf("username = %q, want it unchanged until a restart", got)
+	}
+}
+
+func TestReloadConfigKeepsOverrides(t *testing.T) {
+	useTestDB(t)
+	path := useConfigFile(t, map[string]any{"response": map[string]any{"reply_chance": 0.5}})
+	if err := db.SetConfigOverride("reply_chance", "0.9", "owner"); err != nil {
+		t.Fatal(err)
+	}
+
+	writeConfigFile(t, path, map[string]any{"response": map[string]any{"reply_chance": 0.2, "silence_minutes": 3}})
+	if err := reloadConfig(); err != nil {
+		t.Fatalf("reloadConfig: %v", err)
+	}
+	if got := cfg.Load().Response.ReplyChance; got != 0.9 {
+		t.Errorf("reply_chance = %v, want the override 0.9", got)
+	}
+	if got := cfg.Load().Response.SilenceMinutes; got != 3 {
+		t.Errorf("silence_minutes = %v, want 3", got)
+	}
+}
+
+func TestReloadConfigInvalid(t *testing.T) {
+	useTestDB(t)
+	dir := t.TempDir()
+	persona := filepath.Join(dir, "persona.txt")
+	if err := os.WriteFile(persona, []byte("You are Giiny."), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		extra map[string]any
+	}{
+		{"invalid value", map[string]any{"persona_file": persona, "response": map[string]any{"reply_chance": 2}}},
+		{"missing persona", map[string]any{"persona_file": filepath.Join(dir, "missing.txt"), "response": map[string]any{"reply_chance": 0.2}}},
+		{"unparsable", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := useConfigFile(t, map[string]any{"persona_file": persona, "response": map[string]any{"reply_chance": 0.5}})
+			gemini.SetPersona("You are Giiny.")
+			before, wantPersona := cfg.Load(), gemini.Persona()
+
+			if tt.extra == nil {
+				if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				writeConfigFile(t, path, tt.extra)
+			}
+			if err := reloadConfig(); err == nil {
+				t.Fatal("reloadConfig succeeded")
+			}
+			if cfg.Load() != before {
+				t.Error("a failed reload changed the config")
+			}
+			if got := gemini.Persona(); got != wantPersona {
+				t.Errorf("a failed reload changed the persona to %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"giiny/internal/config"
+	"giiny/internal/store"
+)
+
+// RunREPL chats with the bot from a terminal without connecting to IMVU. Every
+// line read from in goes through the same memory and Gemini pipeline as a room
+// message sent by userID (senpai when empty), and the answer is written to out.
+func RunREPL(c *config.Config, st *store.Store, userID string, in io.Reader, out io.Writer) error {
+	if err := setup(c, st); err != nil {
+		return err
+	}
+	if userID == "" {
+		userID = senpaiID
+	}
+
+	scanner := bufio.NewScanner(in)
+	fmt.Fprint(out, "> ")
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			fmt.Fprint(out, "> ")
+			continue
+		}
+		if text == "!quit" {
+			return nil
+		}
+
+		if response, ok, err := autoReplies.Load().Reply(userID, text, time.Now()); ok {
+			if err != nil {
+				fmt.Fprintf(out, "error: %v\n", err)
+			} else {
+				fmt.Fprintln(out, response)
+			}
+			fmt.Fprint(out, "> ")
+			continue
+		}
+
+		sentences, err := reply(context.Background(), userID, text)
+		if err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+		}
+		for _, sentence := range sentences {
+			fmt.Fprintln(out, sentence)
+		}
+		fmt.Fprint(out, "> ")
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"giiny/internal/imvu"
+)
+
+// lurking makes the bot answer only the messages addressed to it
+var lurking atomic.Bool
+
+// lastChatAt is when the room's last chat message was received. It is only
+// used by the chat loop.
+var lastChatAt time.Time
+
+// roomWasQuiet records a chat message and reports whether the room had been
+// silent for the configured silence before it
+func roomWasQuiet(at time.Time) bool {
+	silence := time.Duration(responseSettings().SilenceMinutes) * time.Minute
+	quiet := silence > 0 && !lastChatAt.IsZero() && at.Sub(lastChatAt) >= silence
+	lastChatAt = at
+	return quiet
+}
+
+// wantsReply decides whether to answer a message. Messages addressed to the
+// bot are always answered; while lurking nothing else is.
+func wantsReply(userID string, addressed, quiet bool) bool {
+	switch {
+	case addressed:
+		return true
+	case lurking.Load():
+		return false
+	case userID == senpaiID, quiet:
+		return true
+	}
+	chance := responseSettings().ReplyChance
+	return chance > 0 && rand.Float64() < chance
+}
+
+func setLurking(client *imvu.IMVU, on bool) {
+	lurking.Store(on)
+	if on {
+		say(client, "lurk_on")
+	} else {
+		say(client, "lurk_off")
+	}
+}
+
+func init() {
+	register(moderatorsOnly, func(client *imvu.IMVU, _ []string) { setLurking(client, true) }, "lurk")
+	register(moderatorsOnly, func(client *imvu.IMVU, _ []string) { setLurking(client, false) }, "unlurk")
+}
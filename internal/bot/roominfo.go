@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	"giiny/internal/events"
+	"giiny/internal/imvu"
+)
+
+// arrivalsShown is how many of the newest arrivals !roominfo lists
+const arrivalsShown = 3
+
+func init() {
+	register(everyone, showRoomInfo, "roominfo")
+}
+
+// arrivals are the users who joined the current room last, newest first
+var arrivals = struct {
+	sync.Mutex
+	userIDs []string
+}{}
+
+// roomCapacities caches the capacity of the rooms the bot was in, by roomKey
+var roomCapacities sync.Map
+
+// roomCapacity returns the capacity of the current room, or 0 if unknown
+func roomCapacity(client *imvu.IMVU) int {
+	room.Lock()
+	owner, chat := room.owner, room.chat
+	room.Unlock()
+
+	key := roomKey(owner, chat)
+	if capacity, ok := roomCapacities.Load(key); ok {
+		return capacity.(int)
+	}
+	info, err := client.RoomInfo(owner, chat)
+	if err != nil {
+		log.Printf("Failed to get the capacity of room %s: %v", key, err)
+		return 0
+	}
+	roomCapacities.Store(key, info.Capacity)
+	return info.Capacity
+}
+
+// showRoomInfo tells the room how full it is and who arrived last
+func showRoomInfo(client *imvu.IMVU, _ []string) {
+	arrivals.Lock()
+	var names []string
+	for _, userID := range arrivals.userIDs {
+		names = append(names, client.UserName(userID))
+	}
+	arrivals.Unlock()
+
+	newest := "-"
+	if len(names) > 0 {
+		newest = strings.Join(names, ", ")
+	}
+
+	occupancy := len(client.Participants())
+	if capacity := roomCapacity(client); capacity > 0 {
+		say(client, "roominfo", roomName(client), occupancy, capacity, newest)
+		return
+	}
+	say(client, "roominfo_uncapped", roomName(client), occupancy, newest)
+}
+
+// watchOccupancy keeps the newest arrivals and tells senpai, by whisper and
+// Telegram, when the room fills up or the last person leaves
+func watchOccupancy(ctx context.Context, client *imvu.IMVU) {
+	joined := events.Subscribe[events.UserJoined](client.Events, 16)
+	defer joined.Close()
+	left := events.Subscribe[events.UserLeft](client.Events, 16)
+	defer left.Close()
+
+	// The room counts as empty until someone shows up, so that joining an
+	// empty room doesn't alert
+	full, empty := false, true
+	check := func() {
+		participants := client.Participants()
+		humans := 0
+		for _, userID := range participants {
+			if client.Sender(userID) == events.SenderHuman {
+				humans++
+			}
+		}
+
+		capacity := roomCapacity(client)
+		nowFull := capacity > 0 && len(participants) >= capacity
+		if nowFull && !full && cfg.Load().OccupancyAlerts.Full {
+			name := roomName(client)
+			whisper(client, senpaiID, "room_full_alert", name, capacity)
+			alert("%s is full (%d/%d)", name, len(participants), capacity)
+		}
+		nowEmpty := humans == 0
+		if nowEmpty && !empty && cfg.Load().OccupancyAlerts.Empty {
+			name := roomName(client)
+			whisper(client, senpaiID, "room_empty_alert", name)
+			alert("Everyone left %s", name)
+		}
+		full, empty = nowFull, nowEmpty
+	}
+
+	for {
+		select {
+		case e := <-joined.C:
+			if client.Sender(e.UserID) == events.SenderHuman {
+				arrivals.Lock()
+				arrivals.userIDs = append([]string{e.UserID}, arrivals.userIDs...)
+				arrivals.userIDs = arrivals.userIDs[:min(len(arrivals.userIDs), arrivalsShown)]
+				arrivals.Unlock()
+			}
+			check()
+		case <-left.C:
+			check()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
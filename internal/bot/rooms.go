@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"giiny/internal/config"
+	"giiny/internal/gemini"
+)
+
+// roomPersonas holds the text of the rooms' persona files, by room key
+var roomPersonas atomic.Pointer[map[string]string]
+
+// currentRoomKey returns the key of the current room in the configuration
+func currentRoomKey() string {
+	room.Lock()
+	defer room.Unlock()
+	return roomKey(room.owner, room.chat)
+}
+
+// roomSettings returns the overrides of the current room
+func roomSettings() config.RoomSettings {
+	return cfg.Load().Rooms[currentRoomKey()]
+}
+
+// responseSettings returns the reply mode of the current room
+func responseSettings() config.Response {
+	return roomResponse(currentRoomKey())
+}
+
+// roomResponse returns the reply mode of the room with the key
+func roomResponse(key string) config.Response {
+	if r := cfg.Load().Rooms[key].Response; r != nil {
+		return *r
+	}
+	return cfg.Load().Response
+}
+
+// commandAllowed reports whether the command may be run by users other than
+// senpai in the current room
+func commandAllowed(name string) bool {
+	allowed := roomSettings().Commands
+	return len(allowed) == 0 || slices.Contains(allowed, name)
+}
+
+// threadKey keeps the conversations of a user in different rooms apart
+func threadKey(userID string) string {
+	return currentRoomKey() + "/" + userID
+}
+
+// personaOption uses the persona of the current room, if it has one
+func personaOption() gemini.ProcessOption {
+	personas := roomPersonas.Load()
+	if personas == nil {
+		return gemini.WithPersona("")
+	}
+	return gemini.WithPersona((*personas)[currentRoomKey()])
+}
+
+// loadRoomPersonas sets the personas of the rooms from their persona files
+func loadRoomPersonas(c *config.Config) error {
+	personas, err := readRoomPersonas(c)
+	if err != nil {
+		return err
+	}
+	roomPersonas.Store(&personas)
+	return nil
+}
+
+// readRoomPersonas reads the persona files of the rooms
+func readRoomPersonas(c *config.Config) (map[string]string, error) {
+	personas := map[string]string{}
+	for key, settings := range c.Rooms {
+		if settings.PersonaFile == "" {
+			continue
+		}
+		data, err := os.ReadFile(settings.PersonaFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read persona file of room %s: %w", key, err)
+		}
+		text := strings.TrimSpace(string(data))
+		if text == "" {
+			return nil, fmt.Errorf("persona file of room %s is empty", key)
+		}
+		personas[key] = text + "\n"
+	}
+	return personas, nil
+}
@@ -0,0 +1,276 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"giiny/internal/imvu"
+)
+
+const (
+	spendGift     = "gift"
+	spendPurchase = "purchase"
+	// pendingSpendingTTL is how long a spending waits for the owner's
+	// confirmation
+	pendingSpendingTTL = 10 * time.Minute
+)
+
+var (
+	errSpendingDisabled  = errors.New("spending is disabled")
+	errCreditBudgetSpent = errors.New("daily credit budget spent")
+	errRecipientLimit    = errors.New("daily limit of the recipient reached")
+)
+
+// spending is an operation that spends the bot's credits. Every one of them
+// goes through spend, which enforces the limits in the spending config.
+type spending struct {
+	kind      string
+	recipient string
+	productID string
+	product   string
+	amount    int64
+	run       func() error
+	expires   time.Time
+}
+
+// spendMu serializes spendings so that concurrent ones can't overrun the
+// budget together
+var spendMu sync.Mutex
+
+// pendingSpendings are the spendings waiting for the owner's confirmation,
+// by number
+var pendingSpendings = struct {
+	sync.Mutex
+	next int
+	byID map[int]*spending
+}{byID: map[int]*spending{}}
+
+func init() {
+	registerParsed(moderatorsOnly, argSpec{
+		usage:  "usage_gift",
+		params: []param{{name: "product"}, {name: "user", optional: true}},
+	}, gift, "gift")
+	registerParsed(senpaiOnly, argSpec{
+		usage:  "usage_buy",
+		params: []param{{name: "product", kind: argNumber}},
+	}, buy, "buy")
+	registerParsed(senpaiOnly, argSpec{
+		usage:  "usage_confirm",
+		params: []param{{name: "n", kind: argNumber}},
+	}, confirm, "confirm")
+	registerHandler(moderatorsOnly, showSpending, "spending")
+}
+
+// gift gifts a product to the user given as argument, or to the caller,
+// "!gift <product> [user]". The user can also come first, as in
+// `!gift "Maria Clara" 12345`.
+func gift(client *imvu.IMVU, userID string, args parsedArgs) {
+	product, user := args.get("product"), args.get("user")
+	if !isProductID(product) && isProductID(user) {
+		product, user = user, product
+	}
+	recipient := userID
+	if user != "" {
+		var err error
+		if recipient, err = resolveUser(client, user); err != nil {
+			sendArgError(client, err, "usage_gift")
+			return
+		}
+	}
+
+	s, ok := newSpending(client, spendGift, product, recipient)
+	if !ok {
+		say(client, "usage_gift")
+		return
+	}
+	s.run = func() error { return client.Gift(userID, s.productID, s.recipient) }
+	spend(client, s, userID == senpaiID)
+}
+
+// buy buys a product for the bot, "!buy <product>"
+func buy(client *imvu.IMVU, userID string, args parsedArgs) {
+	s, ok := newSpending(client, spendPurchase, args.get("product"), client.UserID())
+	if !ok {
+		say(client, "usage_buy")
+		return
+	}
+	s.run = func() error { return client.Purchase(userID, s.productID) }
+	spend(client, s, true)
+}
+
+// confirm runs a spending held for the owner's confirmation, "!confirm <n>"
+func confirm(client *imvu.IMVU, userID string, args parsedArgs) {
+	n := int(args.number("n"))
+	if !confirmSpending(client, n) {
+		say(client, "confirm_unknown", n)
+	}
+}
+
+// isProductID reports whether s is a product ID, with or without the
+// "product-" prefix
+func isProductID(s string) bool {
+	return isDigits(strings.TrimPrefix(s, "product-"))
+}
+
+// newSpending looks up the price of the product. It returns false if the
+// product ID is invalid or unknown, or the product has no price.
+func newSpending(client *imvu.IMVU, kind, productID, recipient string) (*spending, bool) {
+	if !isProductID(productID) {
+		return nil, false
+	}
+	productID = strings.TrimPrefix(productID, "product-")
+
+	product, err := client.Product(productID)
+	if err != nil {
+		log.Printf("Failed to get product %s: %v", productID, err)
+		return nil, false
+	}
+	// An unknown price would slip through every limit
+	if product.Price <= 0 {
+		log.Printf("Product %s has no price, not spending on it", productID)
+		return nil, false
+	}
+	name := product.ProductName
+	if name == "" {
+		name = productID
+	}
+
+	return &spending{
+		kind:      kind,
+		recipient: recipient,
+		productID: productID,
+		product:   name,
+		amount:    product.Price,
+	}, true
+}
+
+// spend runs s if it fits the daily budget and the recipient's limit, and
+// records it so the budget survives restarts. Unless already confirmed by the
+// owner, amounts above the confirmation threshold are held until the owner
+// confirms them.
+func spend(client *imvu.IMVU, s *spending, confirmed bool) {
+	spendMu.Lock()
+	defer spendMu.Unlock()
+
+	if err := checkSpending(s); err != nil {
+		log.Printf("Refused to spend %d credits on %s: %v", s.amount, s, err)
+		switch {
+		case errors.Is(err, errSpendingDisabled):
+			say(client, "spend_disabled")
+		case errors.Is(err, errCreditBudgetSpent):
+			say(client, "spend_budget")
+		case errors.Is(err, errRecipientLimit):
+			say(client, "spend_recipient_limit", client.UserName(s.recipient))
+		default:
+			say(client, "spend_failed")
+		}
+		return
+	}
+
+	if limit := cfg.Load().Spending.ConfirmAbove; !confirmed && limit > 0 && s.amount > limit {
+		n := holdSpending(s)
+		say(client, "spend_pending", s.amount, n)
+		alert("Spending %d credits on %s needs your confirmation: /confirm %d", s.amount, s, n)
+		return
+	}
+
+	if err := s.run(); err != nil {
+		log.Printf("Failed to spend %d credits on %s: %v", s.amount, s, err)
+		say(client, "spend_failed")
+		return
+	}
+	if err := db.AddSpending(s.kind, s.recipient, s.productID, s.amount, time.Now()); err != nil {
+		log.Printf("Failed to record spending: %v", err)
+	}
+	alert("Spent %d credits on %s", s.amount, s)
+
+	if s.kind == spendGift {
+		say(client, "gift_sent", s.product, client.UserName(s.recipient))
+	} else {
+		say(client, "purchase_done", s.product)
+	}
+}
+
+// checkSpending returns an error if s doesn't fit today's budget or the
+// recipient's daily limit. A database error refuses the spending too.
+func checkSpending(s *spending) error {
+	limits := cfg.Load().Spending
+	if limits.DailyBudget <= 0 {
+		return errSpendingDisabled
+	}
+
+	now := time.Now()
+	spent, err := db.DailySpending(now)
+	if err != nil {
+		return err
+	}
+	if spent+s.amount > limits.DailyBudget {
+		return errCreditBudgetSpent
+	}
+
+	if s.kind == spendGift && limits.RecipientDailyLimit > 0 {
+		received, err := db.RecipientSpending(s.recipient, now)
+		if err != nil {
+			return err
+		}
+		if received+s.amount > limits.RecipientDailyLimit {
+			return errRecipientLimit
+		}
+	}
+	return nil
+}
+
+// holdSpending keeps s until the owner confirms it and returns its number
+func holdSpending(s *spending) int {
+	pendingSpendings.Lock()
+	defer pendingSpendings.Unlock()
+
+	now := time.Now()
+	for n, pending := range pendingSpendings.byID {
+		if now.After(pending.expires) {
+			delete(pendingSpendings.byID, n)
+		}
+	}
+
+	pendingSpendings.next++
+	s.expires = now.Add(pendingSpendingTTL)
+	pendingSpendings.byID[pendingSpendings.next] = s
+	return pendingSpendings.next
+}
+
+// confirmSpending runs the held spending with number n. It returns false if
+// there is no such spending or it expired.
+func confirmSpending(client *imvu.IMVU, n int) bool {
+	pendingSpendings.Lock()
+	s, ok := pendingSpendings.byID[n]
+	delete(pendingSpendings.byID, n)
+	pendingSpendings.Unlock()
+
+	if !ok || time.Now().After(s.expires) {
+		return false
+	}
+	// The limits are checked again, other spendings may have happened since
+	spend(client, s, true)
+	return true
+}
+
+// showSpending sends the credits spent today against the daily budget
+func showSpending(client *imvu.IMVU, userID string, args []string) {
+	spent, err := db.DailySpending(time.Now())
+	if err != nil {
+		log.Printf("Failed to get spending: %v", err)
+		return
+	}
+	say(client, "spending_today", spent, cfg.Load().Spending.DailyBudget)
+}
+
+func (s *spending) String() string {
+	if s.kind == spendGift {
+		return fmt.Sprintf("a gift of %s (%s) to %s", s.product, s.productID, s.recipient)
+	}
+	return fmt.Sprintf("%s (%s)", s.product, s.productID)
+}
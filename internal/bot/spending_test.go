@@ -0,0 +1,168 @@
+package bot
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"giiny/internal/config"
+	"giiny/internal/imvu"
+	"giiny/internal/imvutest"
+	"giiny/internal/store"
+)
+
+// useTestDB points db at a fresh database with every migration applied
+func useTestDB(t *testing.T) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "giiny.sqlite")
+
+	raw, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := filepath.Glob("../../migrations/*.up.sql")
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	sort.Strings(migrations)
+	for _, m := range migrations {
+		schema, err := os.ReadFile(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := raw.Exec(string(schema)); err != nil {
+			t.Fatalf("migration %s: %v", filepath.Base(m), err)
+		}
+	}
+	raw.Close()
+
+	st, err := store.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := db
+	db = st
+	t.Cleanup(func() {
+		db = previous
+		st.Close()
+	})
+}
+
+// useConfig makes c the current config for the test
+func useConfig(t *testing.T, c *config.Config) {
+	t.Helper()
+	previous := cfg.Swap(c)
+	t.Cleanup(func() { cfg.Store(previous) })
+}
+
+// testClient returns a client of a fake IMVU server that isn't logged in, so
+// what it sends to the room fails harmlessly
+func testClient(t *testing.T) *imvu.IMVU {
+	t.Helper()
+	srv := imvutest.NewServer()
+	t.Cleanup(srv.Close)
+	client, err := imvu.New(srv.ClientOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestCheckSpending(t *testing.T) {
+	useTestDB(t)
+	useConfig(t, &config.Config{Spending: config.Spending{DailyBudget: 100, RecipientDailyLimit: 30}})
+
+	now := time.Now()
+	if err := db.AddSpending(spendGift, "42", "1", 20, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AddSpending(spendPurchase, "bot", "2", 40, now); err != nil {
+		t.Fatal(err)
+	}
+	// Yesterday's spending doesn't count
+	if err := db.AddSpending(spendPurchase, "bot", "3", 1000, now.AddDate(0, 0, -1)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		s    spending
+		want error
+	}{
+		{"fits", spending{kind: spendPurchase, recipient: "bot", amount: 40}, nil},
+		{"over budget", spending{kind: spendPurchase, recipient: "bot", amount: 41}, errCreditBudgetSpent},
+		{"gift to another user", spending{kind: spendGift, recipient: "7", amount: 30}, nil},
+		{"over recipient limit", spending{kind: spendGift, recipient: "42", amount: 11}, errRecipientLimit},
+		{"up to recipient limit", spending{kind: spendGift, recipient: "42", amount: 10}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkSpending(&tt.s); !errors.Is(err, tt.want) {
+				t.Errorf("checkSpending = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	useConfig(t, &config.Config{})
+	if err := checkSpending(&spending{kind: spendPurchase, amount: 1}); !errors.Is(err, errSpendingDisabled) {
+		t.Errorf("checkSpending without a budget = %v, want errSpendingDisabled", err)
+	}
+}
+
+func TestSpend(t *testing.T) {
+	useTestDB(t)
+	useConfig(t, &config.Config{Spending: config.Spending{DailyBudget: 100, ConfirmAbove: 50}})
+	client := testClient(t)
+
+	runs := 0
+	newPurchase := func(amount int64) *spending {
+		return &spending{kind: spendPurchase, recipient: "bot", productID: "9", product: "Hat", amount: amount,
+			run: func() error { runs++; return nil }}
+	}
+	spent := func() int64 {
+		t.Helper()
+		total, err := db.DailySpending(time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return total
+	}
+
+	spend(client, newPurchase(30), false)
+	if runs != 1 || spent() != 30 {
+		t.Fatalf("after a spending within limits: %d runs, %d spent, want 1 and 30", runs, spent())
+	}
+
+	// Above the threshold it waits for the owner
+	spend(client, newPurchase(60), false)
+	if runs != 1 || spent() != 30 {
+		t.Fatalf("unconfirmed spending ran: %d runs, %d spent", runs, spent())
+	}
+	n := pendingSpendings.next
+	if !confirmSpending(client, n) {
+		t.Fatal("held spending not found")
+	}
+	if runs != 2 || spent() != 90 {
+		t.Fatalf("after confirming: %d runs, %d spent, want 2 and 90", runs, spent())
+	}
+	if confirmSpending(client, n) {
+		t.Error("a spending was confirmed twice")
+	}
+
+	// Confirmed spendings still have to fit the budget
+	spend(client, newPurchase(20), true)
+	if runs != 2 || spent() != 90 {
+		t.Errorf("spending over budget ran: %d runs, %d spent", runs, spent())
+	}
+
+	failing := newPurchase(5)
+	failing.run = func() error { return errors.New("no credits") }
+	spend(client, failing, true)
+	if spent() != 90 {
+		t.Errorf("failed spending was recorded: %d spent", spent())
+	}
+}
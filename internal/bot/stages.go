@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"giiny/internal/metrics"
+)
+
+// The stages of handling a message that have a deadline, as named in the
+// stage_* metrics
+const (
+	stageSend    = "send"
+	stageGemini  = "gemini"
+	stageCommand = "command"
+)
+
+// stageDeadline returns the configured deadline of the stage, 0 for none
+func stageDeadline(stage string) time.Duration {
+	d := cfg.Load().Deadlines
+	var ms int
+	switch stage {
+	case stageSend:
+		ms = d.SendMS
+	case stageGemini:
+		ms = d.GeminiMS
+	case stageCommand:
+		ms = d.CommandMS
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// runStage runs fn with a context that expires at the stage's deadline and
+// records how long it took. When the deadline passes first runStage returns
+// without waiting for fn, so a stuck call can't hold up its caller; fn keeps
+// running until it notices the context, or to its end if it doesn't take one.
+func runStage(ctx context.Context, stage string, fn func(ctx context.Context) error) error {
+	deadline := stageDeadline(stage)
+	if deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, deadline)
+		defer cancel()
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- fn(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("%s stage: %w", stage, ctx.Err())
+	}
+
+	recordStage(stage, deadline, time.Since(start))
+	return err
+}
+
+// recordStage records how long a stage took and whether it overran its
+// deadline
+func recordStage(stage string, deadline, elapsed time.Duration) {
+	metrics.StageCalls.Add(stage, 1)
+	metrics.StageSeconds.AddFloat(stage, elapsed.Seconds())
+	if deadline > 0 && elapsed >= deadline {
+		metrics.StageOverruns.Add(stage, 1)
+		log.Printf("The %s stage ran past its %s deadline", stage, deadline)
+	}
+}
+
+// send runs an IMVU chat send and records it in the send stage. Sends are
+// always waited for, as one abandoned midway would keep pacing its parts
+// while the next send interleaves with them; the send deadline only counts
+// overruns.
+func send(fn func() error) error {
+	start := time.Now()
+	err := fn()
+	recordStage(stageSend, stageDeadline(stageSend), time.Since(start))
+	return err
+}
@@ -0,0 +1,153 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"giiny/internal/events"
+	"giiny/internal/imvu"
+	"giiny/internal/store"
+)
+
+const (
+	// occupancyInterval is how often the number of users in the room is
+	// sampled, besides whenever someone joins
+	occupancyInterval = 5 * time.Minute
+	// busiestHoursShown is how many of the busiest hours !stats lists
+	busiestHoursShown = 3
+)
+
+func init() {
+	registerParsed(everyone, argSpec{
+		usage:  "usage_stats",
+		params: []param{{name: "period", optional: true}},
+	}, statsCommand, "stats")
+}
+
+// trackRoomStats counts the messages and chatters of the current room and
+// samples its occupancy, by hour
+func trackRoomStats(ctx context.Context, client *imvu.IMVU) {
+	messages := events.Subscribe[events.ChatMessage](client.Events, 64)
+	defer messages.Close()
+	joined := events.Subscribe[events.UserJoined](client.Events, 16)
+	defer joined.Close()
+
+	ticker := time.NewTicker(occupancyInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg := <-messages.C:
+			if msg.Sender != events.SenderHuman || msg.Message == "" || msg.Whispered() {
+				continue
+			}
+			if err := db.AddRoomMessage(currentRoomKey(), msg.UserID, msg.ReceivedAt); err != nil {
+				log.Printf("Failed to count room message: %v", err)
+			}
+		case <-joined.C:
+			recordOccupancy(client)
+		case <-ticker.C:
+			recordOccupancy(client)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func recordOccupancy(client *imvu.IMVU) {
+	count := len(client.Participants())
+	if count == 0 {
+		return
+	}
+	if err := db.RecordOccupancy(currentRoomKey(), time.Now(), count); err != nil {
+		log.Printf("Failed to record room occupancy: %v", err)
+	}
+}
+
+// statsSince returns the start of "today" (UTC) or "week" (the last 7 days)
+func statsSince(period string) (time.Time, bool) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	switch period {
+	case "", "today":
+		return today, true
+	case "week":
+		return today.AddDate(0, 0, -6), true
+	}
+	return time.Time{}, false
+}
+
+// statsCommand handles "!stats [today|week]", a summary of the activity of
+// the current room
+func statsCommand(client *imvu.IMVU, userID string, args parsedArgs) {
+	period := strings.ToLower(args.get("period"))
+	since, ok := statsSince(period)
+	if !ok {
+		say(client, "usage_stats")
+		return
+	}
+	if period == "" {
+		period = "today"
+	}
+
+	activity, err := db.RoomActivitySince(currentRoomKey(), since)
+	if err != nil {
+		log.Printf("Failed to read room activity: %v", err)
+		return
+	}
+	if activity.Messages == 0 && activity.PeakOccupancy == 0 {
+		say(client, "stats_none")
+		return
+	}
+
+	say(client, "stats_"+period, activity.Messages, activity.Chatters, activity.PeakOccupancy,
+		formatHours(activity.BusiestHours(busiestHoursShown)))
+}
+
+// formatHours lists hours of the day as "21h, 22h"
+func formatHours(hours []int) string {
+	if len(hours) == 0 {
+		return "-"
+	}
+	parts := make([]string, len(hours))
+	for n, hour := range hours {
+		parts[n] = fmt.Sprintf("%02dh", hour)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// roomStatsExport is the activity of a room, as served by the admin API
+type roomStatsExport struct {
+	Room          string            `json:"room"`
+	Since         time.Time         `json:"since"`
+	Messages      int               `json:"messages"`
+	Chatters      int               `json:"chatters"`
+	PeakOccupancy int               `json:"peak_occupancy"`
+	BusiestHours  []int             `json:"busiest_hours"`
+	Hours         []exportedHourRow `json:"hours"`
+}
+
+type exportedHourRow struct {
+	Hour          time.Time `json:"hour"`
+	Messages      int       `json:"messages"`
+	Chatters      int       `json:"chatters"`
+	PeakOccupancy int       `json:"peak_occupancy"`
+}
+
+func exportRoomStats(activity store.RoomActivity) roomStatsExport {
+	export := roomStatsExport{
+		Room:          activity.Room,
+		Since:         activity.Since,
+		Messages:      activity.Messages,
+		Chatters:      activity.Chatters,
+		PeakOccupancy: activity.PeakOccupancy,
+		BusiestHours:  activity.BusiestHours(24),
+		Hours:         []exportedHourRow{},
+	}
+	for _, h := range activity.Hours {
+		export.Hours = append(export.Hours, exportedHourRow(h))
+	}
+	return export
+}
@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"giiny/internal/gemini"
+	"giiny/internal/imvu"
+)
+
+// status is a snapshot of the bot's health, shown by !status and the admin
+// status page
+type status struct {
+	Version      string
+	Uptime       time.Duration
+	State        string
+	API          string
+	StateFor     string
+	LastMessage  string
+	Room         string
+	Participants int
+	GeminiP50    time.Duration
+	GeminiP95    time.Duration
+	GeminiCalls  int
+	HeapMB       uint64
+	SysMB        uint64
+	Goroutines   int
+	Mood         string
+	Paused       bool
+	Sleeping     bool
+}
+
+// roomNames caches the names of the rooms the bot was in, by roomKey
+var roomNames sync.Map
+
+func collectStatus(client *imvu.IMVU) status {
+	now := time.Now()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	state, since, last := client.ConnectionState()
+	p50, p95, calls := gemini.Latency()
+	m, _ := moods.Current(now)
+
+	s := status{
+		Version:      version(),
+		Uptime:       now.Sub(startTime).Round(time.Second),
+		State:        state.String(),
+		API:          client.APIVersion().String(),
+		StateFor:     age(now, since),
+		LastMessage:  age(now, last),
+		Room:         roomName(client),
+		Participants: len(client.Participants()),
+		GeminiP50:    p50.Round(time.Millisecond),
+		GeminiP95:    p95.Round(time.Millisecond),
+		GeminiCalls:  calls,
+		HeapMB:       mem.HeapAlloc >> 20,
+		SysMB:        mem.Sys >> 20,
+		Goroutines:   runtime.NumGoroutine(),
+		Mood:         string(m),
+		Paused:       pause.Load(),
+		Sleeping:     sleeping(now),
+	}
+	if startTime.IsZero() {
+		s.Uptime = 0
+	}
+	return s
+}
+
+// age returns how long ago t was, or "-" if t is zero
+func age(now, t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return now.Sub(t).Round(time.Second).String()
+}
+
+// roomName returns the name of the current room, falling back to its IDs
+func roomName(client *imvu.IMVU) string {
+	room.Lock()
+	owner, chat := room.owner, room.chat
+	room.Unlock()
+
+	if owner == "" {
+		return "-"
+	}
+	key := roomKey(owner, chat)
+	if name, ok := roomNames.Load(key); ok {
+		return name.(string)
+	}
+
+	info, err := client.RoomInfo(owner, chat)
+	if err != nil || info.Name == "" {
+		return key
+	}
+	roomNames.Store(key, info.Name)
+	return info.Name
+}
+
+// version returns the module version and VCS revision the binary was built
+// from
+func version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	v := info.Main.Version
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if len(setting.Value) > 12 {
+				setting.Value = setting.Value[:12]
+			}
+			v += " " + setting.Value
+		case "vcs.modified":
+			if setting.Value == "true" {
+				v += "+dirty"
+			}
+		}
+	}
+	return v
+}
+
+func showStatus(client *imvu.IMVU, userID string, _ []string) {
+	s := collectStatus(client)
+	whisper(client, userID, "status",
+		s.Version, s.Uptime, s.State, s.StateFor, s.LastMessage, s.Room, s.Participants,
+		s.GeminiP50, s.GeminiP95, s.HeapMB, s.Goroutines, s.Mood)
+}
+
+func init() {
+	registerHandler(senpaiOnly, showStatus, "status")
+}
@@ -0,0 +1,96 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"giiny/internal/gemini"
+	"giiny/internal/i18n"
+	"giiny/internal/imvu"
+)
+
+const (
+	// defaultSummaryMinutes is how much of the transcript !summarize covers
+	// when no duration is given
+	defaultSummaryMinutes = 30
+	maxSummaryMinutes     = 240
+	// summaryMaxLines bounds the transcript given to Gemini
+	summaryMaxLines = 300
+)
+
+func init() {
+	registerHandler(moderatorsOnly, summarize, "summarize")
+}
+
+// summarize sums up the last minutes of the room's chat, in the room or
+// whispered to the requester with "whisper"
+func summarize(client *imvu.IMVU, userID string, args []string) {
+	minutes := defaultSummaryMinutes
+	private := false
+	for _, arg := range args {
+		if arg == "whisper" || arg == "w" {
+			private = true
+			continue
+		}
+		n, err := strconv.Atoi(arg)
+		if err != nil || n <= 0 || n > maxSummaryMinutes {
+			whisper(client, userID, "usage_summarize", maxSummaryMinutes)
+			return
+		}
+		minutes = n
+	}
+
+	send := func(text string) {
+		if private {
+			client.SendWhisper(userID, text)
+		} else {
+			client.SendChatMessage(text)
+		}
+	}
+
+	since := time.Now().Add(-time.Duration(minutes) * time.Minute)
+	lines, err := db.ChatLinesSince(client.ChatQueue(), since, summaryMaxLines)
+	if err != nil {
+		log.Printf("Failed to read chat log: %v", err)
+		return
+	}
+	lines = withoutNoAI(lines)
+	if len(lines) == 0 {
+		send(i18n.T(lang(), "summary_empty", minutes))
+		return
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Chat da sala nos últimos %d minutos:\n", minutes)
+	for _, line := range lines {
+		if line.UserID == client.UserID() {
+			fmt.Fprintf(&prompt, "%s você: %s\n", line.CreatedAt.Local().Format("15:04"), line.Message)
+			continue
+		}
+		fmt.Fprintf(&prompt, "%s %s %s: %s\n", line.CreatedAt.Local().Format("15:04"), speaker(line.Sender), line.UserID, line.Message)
+	}
+	prompt.WriteString("\nFaça um resumo curto do que aconteceu e do que foi conversado, para quem acabou de voltar.")
+
+	if !withinBudget() {
+		return
+	}
+
+	var usage gemini.Usage
+	response, err := gemini.Process(context.Background(), prompt.String(), gemini.WithUsage(&usage), gemini.WithLanguage(i18n.Name(lang())))
+	if err != nil {
+		log.Printf("Error summarizing chat with Gemini: %v", err)
+		apologize(client)
+		return
+	}
+	recordUsage(userID, usage)
+
+	for _, sentence := range strings.Split(response, ";") {
+		if sentence = strings.TrimSpace(sentence); sentence != "" {
+			send(sentence)
+		}
+	}
+}
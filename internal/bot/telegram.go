@@ -0,0 +1,181 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"giiny/internal/events"
+	"giiny/internal/imvu"
+	"giiny/internal/telegram"
+)
+
+var admin *telegram.Client
+
+// room is the room the bot is in, which can change through the admin channel
+var room struct {
+	sync.Mutex
+	owner, chat string
+}
+
+// startTelegram starts the admin channel if it is configured. It doesn't
+// depend on the IMVU connection, so it keeps working while IMQ is down.
+func startTelegram(ctx context.Context, client *imvu.IMVU) {
+	if !cfg.Load().Telegram.Enabled {
+		return
+	}
+
+	admin = telegram.New(cfg.Load().Telegram)
+	go admin.Poll(ctx, func(text string) {
+		handleTelegramCommand(client, text)
+	})
+	go forwardAlerts(ctx, client)
+
+	log.Printf("Telegram admin channel started")
+}
+
+// alert notifies the owner on Telegram, if the admin channel is enabled
+func alert(format string, args ...any) {
+	if admin == nil {
+		return
+	}
+
+	text := fmt.Sprintf(format, args...)
+	go func() {
+		if err := admin.Send(text); err != nil {
+			log.Printf("Failed to send Telegram alert: %v", err)
+		}
+	}()
+}
+
+func forwardAlerts(ctx context.Context, client *imvu.IMVU) {
+	disconnected := events.Subscribe[events.Disconnected](client.Events, 4)
+	defer disconnected.Close()
+	reconnected := events.Subscribe[events.Reconnected](client.Events, 4)
+	defer reconnected.Close()
+	messages := events.Subscribe[events.ChatMessage](client.Events, 16)
+	defer messages.Close()
+	circuits := events.Subscribe[events.CircuitChanged](client.Events, 4)
+	defer circuits.Close()
+	restarted := events.Subscribe[events.SessionRestarted](client.Events, 4)
+	defer restarted.Close()
+	notifications := events.Subscribe[events.NotificationReceived](client.Events, 16)
+	defer notifications.Close()
+	failed := events.Subscribe[events.DeliveryFailed](client.Events, 4)
+	defer failed.Close()
+
+	// Every failed reconnection attempt is another Disconnected, only the
+	// first one is worth an alert
+	down := false
+	for {
+		select {
+		case e := <-disconnected.C:
+			if down {
+				continue
+			}
+			down = true
+			if e.NextAttempt != nil {
+				alert("IMQ disconnected, reconnecting at %s", e.NextAttempt.Format(time.TimeOnly))
+			} else {
+				alert("IMQ disconnected")
+			}
+		case <-reconnected.C:
+			if down {
+				down = false
+				alert("IMQ reconnected")
+			}
+		case e := <-circuits.C:
+			if e.State != "half-open" {
+				alert("IMVU %s endpoints circuit %s", e.Group, e.State)
+			}
+		case e := <-restarted.C:
+			alert("IMVU session restarted: %s", e.Reason)
+		case e := <-notifications.C:
+			alert("IMVU notification (%s): %s", e.Kind, e.Message)
+		case e := <-failed.C:
+			alert("Chat message not delivered: %s", e.Message)
+		case msg := <-messages.C:
+			if msg.UserID == senpaiID || msg.Sender != events.SenderHuman {
+				continue
+			}
+			lower := strings.ToLower(msg.Message)
+			for _, keyword := range cfg.Load().Telegram.AlertKeywords {
+				if keyword != "" && strings.Contains(lower, strings.ToLower(keyword)) {
+					alert("%s said: %s", msg.UserID, msg.Message)
+					break
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// handleTelegramCommand runs a command sent from the admin chat
+func handleTelegramCommand(client *imvu.IMVU, text string) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(text), " ")
+	arg = strings.TrimSpace(arg)
+
+	switch strings.ToLower(strings.TrimPrefix(name, "/")) {
+	case "say":
+		if arg == "" {
+			alert("Usage: /say <message>")
+			return
+		}
+		if err := client.SendChatMessage(arg); err != nil {
+			alert("Failed to send: %v", err)
+		}
+	case "join":
+		owner, chat, ok := strings.Cut(arg, "-")
+		if !ok || owner == "" || chat == "" {
+			alert("Usage: /join <owner>-<chatroom>")
+			return
+		}
+		joinRoom(client, owner, chat)
+	case "rejoin":
+		unpark(client)
+	case "confirm":
+		n, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+		if err != nil {
+			alert("Usage: /confirm <n>")
+			return
+		}
+		if !confirmSpending(client, n) {
+			alert("No spending #%d is waiting for confirmation", n)
+		}
+	case "status":
+		room.Lock()
+		current := room.owner + "-" + room.chat
+		room.Unlock()
+		alert("Uptime: %s\nRoom: %s\nIMQ connected: %t\nPaused: %t\nSleeping: %t",
+			time.Since(startTime).Round(time.Second), current, client.Connected(), pause.Load(), sleeping(time.Now()))
+	case "quit":
+		alert("Bye!")
+		doneCh <- true
+	default:
+		alert("Commands: /say <message>, /join <owner>-<chatroom>, /rejoin, /confirm <n>, /status, /quit")
+	}
+}
+
+// joinRoom leaves the current room and joins another one
+func joinRoom(client *imvu.IMVU, owner, chat string) {
+	room.Lock()
+	defer room.Unlock()
+
+	if err := client.LeaveRoom(room.owner, room.chat); err != nil {
+		log.Printf("Failed to leave room %s-%s: %v", room.owner, room.chat, err)
+	}
+
+	if _, err := client.JoinRoom(owner, chat); err != nil {
+		alert("Failed to join room %s-%s: %v", owner, chat, err)
+		return
+	}
+	room.owner, room.chat = owner, chat
+	parked.Store(false)
+	lurking.Store(roomResponse(roomKey(owner, chat)).Lurk)
+	alert("Joined room %s-%s", owner, chat)
+}
@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"giiny/internal/gemini"
+	"giiny/internal/i18n"
+	"giiny/internal/imvu"
+)
+
+// translateMinLength is the length under which room messages are not worth
+// translating automatically
+const translateMinLength = 8
+
+func init() {
+	registerHandler(everyone, translateCommand, "translate")
+}
+
+// translateCommand translates the text after the language into it
+func translateCommand(client *imvu.IMVU, userID string, args []string) {
+	if len(args) < 2 {
+		say(client, "usage_translate")
+		return
+	}
+	if !withinBudget() {
+		noticeBudgetSpent(client)
+		return
+	}
+
+	var usage gemini.Usage
+	translation, _, err := gemini.Translate(context.Background(), strings.Join(args[1:], " "), args[0], &usage)
+	if err != nil {
+		log.Printf("Error translating with Gemini: %v", err)
+		apologize(client)
+		return
+	}
+	recordUsage(userID, usage)
+	// Bracketed like automatic translations, so the text can never start
+	// with an avatar command
+	if translation != "" {
+		say(client, "translation", client.UserName(userID), translation)
+	}
+}
+
+// translateMessage translates a room message into the language of the room
+// when it is written in another one. Lurking, translations are only
+// whispered.
+func translateMessage(client *imvu.IMVU, userID, text string) {
+	whispered := cfg.Load().Translation.Mode == "whisper"
+	if utf8.RuneCountInString(text) < translateMinLength || (!whispered && lurking.Load()) || !withinBudget() {
+		return
+	}
+
+	var usage gemini.Usage
+	translation, same, err := gemini.Translate(context.Background(), text, i18n.Name(lang()), &usage)
+	if err != nil {
+		log.Printf("Error translating with Gemini: %v", err)
+		return
+	}
+	recordUsage(userID, usage)
+	if same || translation == "" {
+		return
+	}
+
+	name := client.UserName(userID)
+	if whispered {
+		whisper(client, senpaiID, "translation", name, translation)
+		return
+	}
+	say(client, "translation", name, translation)
+}
@@ -0,0 +1,47 @@
+package bot
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"giiny/internal/imvu"
+)
+
+func init() {
+	registerHandler(everyone, tryOn, "tryon")
+}
+
+// tryOn shows a product on the bot's avatar for a while, without buying it,
+// "!tryon <product>". "!tryon off" undoes it early.
+func tryOn(client *imvu.IMVU, userID string, args []string) {
+	if len(args) == 0 {
+		say(client, "usage_tryon")
+		return
+	}
+	if strings.EqualFold(args[0], "off") {
+		if err := client.UndoTryOn(userID); err != nil {
+			log.Printf("Failed to undo the try on: %v", err)
+		}
+		return
+	}
+
+	productID := strings.TrimPrefix(args[0], "product-")
+	if _, err := strconv.ParseUint(productID, 10, 64); err != nil {
+		say(client, "usage_tryon")
+		return
+	}
+
+	name := productID
+	if product, err := client.Product(productID); err == nil && product.ProductName != "" {
+		name = product.ProductName
+	}
+
+	if err := client.TryOn(userID, productID, time.Duration(cfg.Load().TryOnSeconds)*time.Second); err != nil {
+		log.Printf("Failed to try on product %s: %v", productID, err)
+		say(client, "tryon_failed")
+		return
+	}
+	say(client, "tryon", name, cfg.Load().TryOnSeconds, imvu.ProductURL(productID))
+}
@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"log"
+	"math/rand/v2"
+	"time"
+	"unicode/utf8"
+
+	"giiny/internal/imvu"
+)
+
+// startTyping plays the typing emote, if there is one, and returns when the
+// answer was started
+func startTyping(client *imvu.IMVU) time.Time {
+	if cfg.Load().Typing.Enabled && cfg.Load().Typing.Emote != "" {
+		if err := client.TriggerAction("", cfg.Load().Typing.Emote); err != nil {
+			log.Printf("Failed to play the typing emote: %v", err)
+		}
+	}
+	return time.Now()
+}
+
+// sendTyped sends the sentences of an answer, each after the time it takes to
+// type it. The time spent since started, generating the answer, counts
+// towards the first sentence.
+func sendTyped(client *imvu.IMVU, sentences []string, started time.Time) {
+	for n, sentence := range sentences {
+		if cfg.Load().Typing.Enabled {
+			delay := typingDelay(sentence)
+			if n == 0 {
+				delay -= time.Since(started)
+			}
+			if delay > 0 {
+				time.Sleep(delay)
+			}
+		}
+		log.Printf("Sending response: %s", sentence)
+		send(func() error { return client.SendChatMessage(sentence) })
+	}
+}
+
+// typingDelay is how long typing text takes, give or take a fifth so the pace
+// doesn't look mechanical
+func typingDelay(text string) time.Duration {
+	seconds := float64(utf8.RuneCountInString(text)) / cfg.Load().Typing.CharsPerSecond
+	seconds *= 0.8 + 0.4*rand.Float64()
+	delay := time.Duration(seconds * float64(time.Second))
+	return min(delay, time.Duration(cfg.Load().Typing.MaxSeconds)*time.Second)
+}
@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"giiny/internal/gemini"
+	"giiny/internal/i18n"
+	"giiny/internal/imvu"
+	"giiny/internal/metrics"
+)
+
+// withinBudget reports whether today's token usage is still below the
+// configured daily budget. A zero budget means no limit.
+func withinBudget() bool {
+	if cfg.Load().DailyTokenBudget <= 0 {
+		return true
+	}
+
+	usage, err := db.DailyUsage(time.Now())
+	if err != nil {
+		// Better to answer than to go silent because of a database error
+		log.Printf("Failed to check token budget: %v", err)
+		return true
+	}
+
+	if usage.Total() >= cfg.Load().DailyTokenBudget {
+		metrics.GeminiBudgetRejections.Add(1)
+		log.Printf("Daily token budget of %d spent (%d used), ignoring message", cfg.Load().DailyTokenBudget, usage.Total())
+		return false
+	}
+	return true
+}
+
+// budgetNoticeDay is the day (UTC) the chat was last told that the budget is
+// spent, so the notice is sent once a day. It is checked from the chat loop
+// and from commands and translations running beside it, hence atomic.
+var budgetNoticeDay atomic.Value
+
+// noticeBudgetSpent tells the room that the bot stops answering for the day
+func noticeBudgetSpent(client *imvu.IMVU) {
+	day := time.Now().UTC().Format(time.DateOnly)
+	if budgetNoticeDay.Swap(day) == day {
+		return
+	}
+	say(client, "budget_spent")
+}
+
+// recordUsage persists the tokens spent answering a user
+func recordUsage(userID string, usage gemini.Usage) {
+	if err := db.AddUsage(userID, time.Now(), usage.PromptTokens, usage.ResponseTokens); err != nil {
+		log.Printf("Failed to record usage of user %s: %v", userID, err)
+	}
+}
+
+// showUsage sends today's usage, of everyone or of a single user
+func showUsage(client *imvu.IMVU, args []string) {
+	now := time.Now()
+
+	if len(args) > 0 {
+		usage, err := db.UserUsage(args[0], now)
+		if err != nil {
+			log.Printf("Failed to get usage: %v", err)
+			return
+		}
+		say(client, "usage_user", args[0], usage.Requests, usage.PromptTokens, usage.ResponseTokens)
+		return
+	}
+
+	usage, err := db.DailyUsage(now)
+	if err != nil {
+		log.Printf("Failed to get usage: %v", err)
+		return
+	}
+
+	budget := i18n.T(lang(), "budget_unlimited")
+	if cfg.Load().DailyTokenBudget > 0 {
+		budget = fmt.Sprintf("%d/%d", usage.Total(), cfg.Load().DailyTokenBudget)
+	}
+	say(client, "usage_today", usage.Requests, usage.PromptTokens, usage.ResponseTokens, budget)
+}
@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"giiny/internal/imvu"
+)
+
+// wishlistSize is how many items !wishlist shows
+const wishlistSize = 3
+
+func init() {
+	registerHandler(everyone, wishlist, "wishlist")
+}
+
+// wishlist whispers the top items of the wishlist of the user, or of the user
+// given as argument. Senpai can also add and remove products from the bot's
+// own wishlist.
+func wishlist(client *imvu.IMVU, userID string, args []string) {
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "add", "remove":
+			if userID == senpaiID {
+				manageWishlist(client, strings.ToLower(args[0]), args[1:])
+			}
+			return
+		}
+	}
+
+	target := userID
+	if len(args) > 0 {
+		target = args[0]
+	}
+	if !isDigits(target) {
+		whisper(client, userID, "usage_wishlist")
+		return
+	}
+
+	items, err := client.Wishlist(target)
+	if err != nil {
+		log.Printf("Failed to get wishlist of user %s: %v", target, err)
+		apologizeTo(client, userID)
+		return
+	}
+
+	name := client.UserName(target)
+	if len(items) == 0 {
+		whisper(client, userID, "wishlist_empty", name)
+		return
+	}
+
+	whisper(client, userID, "wishlist", name)
+	for n, item := range items[:min(len(items), wishlistSize)] {
+		title := item.ProductName
+		if title == "" {
+			title = item.ProductID
+		}
+		whisper(client, userID, "wishlist_item", n+1, title, item.URL())
+	}
+}
+
+// manageWishlist adds products to or removes them from the bot's wishlist
+func manageWishlist(client *imvu.IMVU, action string, args []string) {
+	if len(args) == 0 {
+		say(client, "usage_wishlist")
+		return
+	}
+	productID := strings.TrimPrefix(args[0], "product-")
+	if _, err := strconv.ParseUint(productID, 10, 64); err != nil {
+		say(client, "usage_wishlist")
+		return
+	}
+
+	if action == "add" {
+		if err := client.AddToWishlist(productID); err != nil {
+			log.Printf("Failed to add product %s to the wishlist: %v", productID, err)
+			return
+		}
+		say(client, "wishlist_added", imvu.ProductURL(productID))
+		return
+	}
+
+	if err := client.RemoveFromWishlist(productID); err != nil {
+		log.Printf("Failed to remove product %s from the wishlist: %v", productID, err)
+		return
+	}
+	say(client, "wishlist_removed", productID)
+}
@@ -0,0 +1,174 @@
+package bot
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"sync"
+
+	"giiny/internal/imvu"
+)
+
+const (
+	// answerQueueSize is how many answers can wait for each worker before new
+	// messages are dropped
+	answerQueueSize = 16
+	// commandWorkers is how many chat commands run at once
+	commandWorkers = 4
+	// commandQueueSize is how many commands can wait for each worker before
+	// new ones are dropped
+	commandQueueSize = 8
+)
+
+type answerJob struct {
+	userID string
+	text   string
+	gen    int
+}
+
+// answerPool generates the answers to chat messages on a fixed number of
+// workers. The messages of a user always go to the same worker, so they are
+// answered in the order they were sent.
+type answerPool struct {
+	client *imvu.IMVU
+	queues []chan answerJob
+	wg     sync.WaitGroup
+}
+
+func newAnswerPool(client *imvu.IMVU, workers int) *answerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &answerPool{client: client, queues: make([]chan answerJob, workers)}
+	for i := range p.queues {
+		p.queues[i] = make(chan answerJob, answerQueueSize)
+		p.wg.Add(1)
+		go p.work(p.queues[i])
+	}
+	return p
+}
+
+func (p *answerPool) work(queue <-chan answerJob) {
+	defer p.wg.Done()
+	for job := range queue {
+		answer(p.client, job)
+	}
+}
+
+// enqueue queues the answer to a user's message, cancelling the answer to
+// their previous one
+func (p *answerPool) enqueue(userID, text string) {
+	job := answerJob{userID: userID, text: text, gen: supersede(userID)}
+
+	select {
+	case p.queues[workerOf(userID, len(p.queues))] <- job:
+	default:
+		log.Printf("Answer queue full, dropping message of user %s", userID)
+	}
+}
+
+// close stops the workers once the queued answers are done
+func (p *answerPool) close() {
+	for _, queue := range p.queues {
+		close(queue)
+	}
+	p.wg.Wait()
+}
+
+// commandPool runs chat commands on a fixed number of workers, so a flood of
+// commands can't start any number of goroutines and API calls. The commands of
+// a user always go to the same worker and run in the order they were sent.
+type commandPool struct {
+	queues []chan func()
+	wg     sync.WaitGroup
+}
+
+func newCommandPool(workers int) *commandPool {
+	p := &commandPool{queues: make([]chan func(), workers)}
+	for i := range p.queues {
+		p.queues[i] = make(chan func(), commandQueueSize)
+		p.wg.Add(1)
+		go p.work(p.queues[i])
+	}
+	return p
+}
+
+func (p *commandPool) work(queue <-chan func()) {
+	defer p.wg.Done()
+	for run := range queue {
+		run()
+	}
+}
+
+// enqueue queues a command of the user, or drops it when the worker of the
+// user is too far behind
+func (p *commandPool) enqueue(userID string, run func()) {
+	select {
+	case p.queues[workerOf(userID, len(p.queues))] <- run:
+	default:
+		log.Printf("Command queue full, dropping command of user %s", userID)
+	}
+}
+
+// close stops the workers once the queued commands are done
+func (p *commandPool) close() {
+	for _, queue := range p.queues {
+		close(queue)
+	}
+	p.wg.Wait()
+}
+
+// workerOf returns which of n workers handles the user
+func workerOf(userID string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(userID))
+	return int(h.Sum32() % uint32(n))
+}
+
+// generations are the answers queued or being generated, by user, so that a
+// newer message of a user cancels the answer to the older one
+var generations = struct {
+	sync.Mutex
+	next   int
+	byUser map[string]generation
+}{byUser: map[string]generation{}}
+
+type generation struct {
+	id int
+	// cancel is nil until the answer starts being generated
+	cancel context.CancelFunc
+}
+
+// supersede cancels the pending answer of a user and returns the generation
+// of the next one
+func supersede(userID string) int {
+	generations.Lock()
+	defer generations.Unlock()
+	if previous, ok := generations.byUser[userID]; ok && previous.cancel != nil {
+		previous.cancel()
+	}
+	generations.next++
+	generations.byUser[userID] = generation{id: generations.next}
+	return generations.next
+}
+
+// startGeneration returns the context of an answer, or false when a newer
+// message of the user superseded it while it was queued
+func startGeneration(userID string, id int) (context.Context, context.CancelFunc, bool) {
+	generations.Lock()
+	defer generations.Unlock()
+	if generations.byUser[userID].id != id {
+		return nil, nil, false
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	generations.byUser[userID] = generation{id: id, cancel: cancel}
+	return ctx, cancel, true
+}
+
+func finishGeneration(userID string, id int) {
+	generations.Lock()
+	defer generations.Unlock()
+	if generations.byUser[userID].id == id {
+		delete(generations.byUser, userID)
+	}
+}
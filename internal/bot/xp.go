@@ -0,0 +1,150 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"giiny/internal/events"
+	"giiny/internal/imvu"
+)
+
+const (
+	// xpFlushInterval is how often the time spent in the room is credited to
+	// the users who are still there
+	xpFlushInterval = 5 * time.Minute
+	// leaderboardSize is how many users !leaderboard lists
+	leaderboardSize = 5
+)
+
+func init() {
+	registerHandler(everyone, showRank, "rank")
+	register(everyone, func(client *imvu.IMVU, _ []string) { showLeaderboard(client) }, "leaderboard")
+}
+
+// trackActivity awards XP for the messages of the room and for the time users
+// spend in it, as seen in the participant list
+func trackActivity(client *imvu.IMVU) {
+	messages := events.Subscribe[events.ChatMessage](client.Events, 64)
+	defer messages.Close()
+	joined := events.Subscribe[events.UserJoined](client.Events, 16)
+	defer joined.Close()
+	left := events.Subscribe[events.UserLeft](client.Events, 16)
+	defer left.Close()
+
+	ticker := time.NewTicker(xpFlushInterval)
+	defer ticker.Stop()
+
+	// since is when each user in the room was last credited for their time
+	since := map[string]time.Time{}
+	now := time.Now()
+	for _, userID := range client.Participants() {
+		if client.Sender(userID) == events.SenderHuman {
+			since[userID] = now
+		}
+	}
+	lastAward := map[string]time.Time{}
+
+	for {
+		select {
+		case msg := <-messages.C:
+			if msg.Sender != events.SenderHuman || msg.Message == "" || msg.Whispered() {
+				continue
+			}
+			creditMessage(msg.UserID, msg.ReceivedAt, lastAward)
+		case e := <-joined.C:
+			if client.Sender(e.UserID) == events.SenderHuman {
+				since[e.UserID] = time.Now()
+			}
+		case e := <-left.C:
+			if t, ok := since[e.UserID]; ok {
+				creditTime(e.UserID, time.Since(t))
+				delete(since, e.UserID)
+			}
+		case now := <-ticker.C:
+			for userID, t := range since {
+				// Only whole minutes are credited so that the rest carries over
+				elapsed := now.Sub(t).Truncate(time.Minute)
+				creditTime(userID, elapsed)
+				since[userID] = t.Add(elapsed)
+			}
+		}
+	}
+}
+
+// creditMessage counts a message of the user and awards XP for it unless the
+// user was awarded within the cooldown
+func creditMessage(userID string, at time.Time, lastAward map[string]time.Time) {
+	if isIgnored, _ := db.IsIgnored(userID, at); isIgnored {
+		return
+	}
+
+	var points int64
+	cooldown := time.Duration(cfg.Load().XP.MessageCooldownSeconds) * time.Second
+	if last, ok := lastAward[userID]; !ok || at.Sub(last) >= cooldown {
+		points = cfg.Load().XP.MessagePoints
+		lastAward[userID] = at
+	}
+
+	if _, err := db.AddActivity(userID, at, 1, 0, points, cfg.Load().XP.DailyCap); err != nil {
+		log.Printf("Failed to record activity of user %s: %v", userID, err)
+	}
+}
+
+// creditTime awards XP for time spent in the room
+func creditTime(userID string, elapsed time.Duration) {
+	if elapsed <= 0 {
+		return
+	}
+
+	points := int64(elapsed/time.Minute) * cfg.Load().XP.MinutePoints
+	if _, err := db.AddActivity(userID, time.Now(), 0, int64(elapsed/time.Second), points, cfg.Load().XP.DailyCap); err != nil {
+		log.Printf("Failed to record activity of user %s: %v", userID, err)
+	}
+}
+
+// level grows with the square root of the XP: level 1 at 100 XP, 2 at 400...
+func level(xp int64) int {
+	return int(math.Sqrt(float64(xp) / 100))
+}
+
+// showRank sends the XP of the user, or of the user given as argument
+func showRank(client *imvu.IMVU, userID string, args []string) {
+	if len(args) > 0 {
+		userID = args[0]
+	}
+
+	activity, rank, err := db.UserActivity(userID)
+	if err != nil {
+		log.Printf("Failed to get rank: %v", err)
+		return
+	}
+
+	name := client.UserName(userID)
+	if rank == 0 {
+		say(client, "rank_none", name)
+		return
+	}
+	timeInRoom := (time.Duration(activity.Seconds) * time.Second).String()
+	say(client, "rank", name, level(activity.XP), activity.XP, rank, activity.Messages, timeInRoom)
+}
+
+func showLeaderboard(client *imvu.IMVU) {
+	board, err := db.Leaderboard(leaderboardSize)
+	if err != nil {
+		log.Printf("Failed to get leaderboard: %v", err)
+		return
+	}
+	if len(board) == 0 {
+		say(client, "leaderboard_empty")
+		return
+	}
+
+	entries := make([]string, len(board))
+	for i, a := range board {
+		entries[i] = fmt.Sprintf("%d. %s (lv %d, %d XP)", i+1, client.UserName(a.UserID), level(a.XP), a.XP)
+	}
+	say(client, "leaderboard", strings.Join(entries, ", "))
+}
@@ -0,0 +1,782 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"giiny/internal/autoreply"
+	"giiny/internal/gemini"
+	"giiny/internal/i18n"
+	"giiny/internal/imvu"
+	"giiny/internal/mood"
+	"giiny/internal/secrets"
+	"giiny/internal/telegram"
+	"giiny/internal/telemetry"
+)
+
+// Seat is a named seat preset that can be used from chat (e.g. "!sit lap")
+type Seat struct {
+	UserID     string `json:"user_id"`
+	FurniID    int    `json:"furni_id"`
+	SeatNumber int    `json:"seat_number"`
+	Message    string `json:"message,omitempty"`
+}
+
+// OnJoin is what the avatar does after joining a room. Outfit names one of
+// the outfits and Seat one of the seat presets; Emotes are actions or trigger
+// words played in order. The greeting isn't repeated when rejoining.
+type OnJoin struct {
+	PureUser bool     `json:"pure_user"`
+	Outfit   string   `json:"outfit,omitempty"`
+	Seat     string   `json:"seat,omitempty"`
+	Greeting string   `json:"greeting,omitempty"`
+	Emotes   []string `json:"emotes,omitempty"`
+}
+
+// XP configures the experience points awarded for room activity
+type XP struct {
+	// MessagePoints are awarded for a message, at most once per cooldown
+	MessagePoints          int64 `json:"message_points"`
+	MessageCooldownSeconds int   `json:"message_cooldown_seconds"`
+	// MinutePoints are awarded for every minute spent in the room
+	MinutePoints int64 `json:"minute_points"`
+	// DailyCap is the most XP a user can earn in a day (UTC); 0 disables it
+	DailyCap int64 `json:"daily_cap"`
+}
+
+// Mood makes the persona's mood follow the sentiment of the room's chat.
+// Emotes maps moods (cheerful, excited, sleepy, pouty) to avatar trigger words
+// played when the mood changes.
+type Mood struct {
+	Enabled bool              `json:"enabled"`
+	Emotes  map[string]string `json:"emotes,omitempty"`
+}
+
+// QuietHours stops the chat replies every day from Start to End ("HH:MM", in
+// Timezone, an IANA name; empty is the local time). A Start after End spans
+// midnight.
+type QuietHours struct {
+	Enabled  bool   `json:"enabled"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// Location returns the time zone of the quiet hours
+func (q QuietHours) Location() (*time.Location, error) {
+	if q.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(q.Timezone)
+}
+
+// ParseClock parses a "HH:MM" time of day into minutes since midnight
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Typing makes Gemini answers look typed: Emote, an avatar trigger, is played
+// while the answer is generated, and every sentence is sent after the time it
+// takes to type it at CharsPerSecond, at most MaxSeconds.
+type Typing struct {
+	Enabled        bool    `json:"enabled"`
+	Emote          string  `json:"emote,omitempty"`
+	CharsPerSecond float64 `json:"chars_per_second"`
+	MaxSeconds     int     `json:"max_seconds"`
+}
+
+// FriendRequests answers friend requests automatically when Enabled. A
+// request is accepted when the sender's account is at least MinAccountDays
+// old, has at least MinBadgeLevel, isn't in Blocked or ignored, and shares at
+// least MinMutualFriends friends with the bot; it is declined otherwise.
+type FriendRequests struct {
+	Enabled          bool     `json:"enabled"`
+	MinAccountDays   int      `json:"min_account_days"`
+	MinBadgeLevel    int      `json:"min_badge_level"`
+	MinMutualFriends int      `json:"min_mutual_friends"`
+	Blocked          []string `json:"blocked,omitempty"`
+}
+
+// Spending limits the credits the bot spends on gifts and purchases.
+// DailyBudget is the credits that can be spent per day (UTC), 0 disables
+// spending; RecipientDailyLimit caps what a single user can receive per day
+// and amounts above ConfirmAbove wait for the owner's confirmation. Zero
+// disables those two limits.
+type Spending struct {
+	DailyBudget         int64 `json:"daily_budget"`
+	RecipientDailyLimit int64 `json:"recipient_daily_limit"`
+	ConfirmAbove        int64 `json:"confirm_above"`
+}
+
+// Feed follows the IMVU feeds of the users in Watch every PollMinutes and
+// announces their new posts in the room. LikeSenpai and CommentSenpai make
+// the bot like and comment on the posts of its owner.
+type Feed struct {
+	Watch         []string `json:"watch,omitempty"`
+	PollMinutes   int      `json:"poll_minutes"`
+	LikeSenpai    bool     `json:"like_senpai"`
+	CommentSenpai bool     `json:"comment_senpai"`
+}
+
+// Greeter makes the bot work as an official IMVU greeter: with Official the
+// greeter plugin also records a greeter greeting of the users it welcomes.
+type Greeter struct {
+	Official bool `json:"official"`
+}
+
+// Buddies watch the users the bot follows on IMVU every PollMinutes (0
+// disables it) and tell the owner when one comes online or enters the room.
+// A non-empty Greeting is also sent to them, as a direct message when they
+// come online and as a whisper when they enter the room.
+type Buddies struct {
+	PollMinutes int    `json:"poll_minutes"`
+	Greeting    string `json:"greeting,omitempty"`
+}
+
+// Idle leaves the room once nobody but bots talked in it for LeaveHours (0
+// disables it), and logs out too with Logout. The owner brings the bot back
+// with /rejoin on Telegram or, unless logged out, a direct message saying
+// "rejoin".
+type Idle struct {
+	LeaveHours int  `json:"leave_hours"`
+	Logout     bool `json:"logout"`
+}
+
+// Heartbeat sends the owner a short health summary every Hours (0 disables
+// it), as a direct message or, when WebhookURL is set, a POST of the summary
+// as JSON
+type Heartbeat struct {
+	Hours      int    `json:"hours"`
+	WebhookURL string `json:"webhook_url,omitempty"`
+}
+
+// Catalog announces in the room the products the IMVU store catalog reports
+// as new or on sale, when Announce is set. Only products in one of Categories
+// (any when empty) and costing at most MaxPrice credits (any when 0) are
+// announced, at most MaxPerHour of them.
+type Catalog struct {
+	Announce   bool     `json:"announce"`
+	Categories []string `json:"categories,omitempty"`
+	MaxPrice   int64    `json:"max_price"`
+	MaxPerHour int      `json:"max_per_hour"`
+}
+
+// OccupancyAlerts tell the owner when the room fills up to its capacity
+// (Full) or the last user other than the bots leaves it (Empty)
+type OccupancyAlerts struct {
+	Full  bool `json:"full"`
+	Empty bool `json:"empty"`
+}
+
+// Translation translates, when Auto, the room messages written in another
+// language than the room's. Mode "bracket" posts "[name: translation]" in the
+// room and "whisper" whispers it to the owner.
+type Translation struct {
+	Auto bool   `json:"auto"`
+	Mode string `json:"mode"`
+}
+
+// Export is where "!history export" puts the data of a user: a JSON file in
+// Dir or, when WebhookURL is set, a POST of the JSON to it
+type Export struct {
+	Dir        string `json:"dir"`
+	WebhookURL string `json:"webhook_url,omitempty"`
+}
+
+// ExternalPlugin is a plugin running as a separate process. Command is the
+// program and its arguments; the plugin speaks JSON lines on stdin and stdout.
+type ExternalPlugin struct {
+	Name    string   `json:"name"`
+	Command []string `json:"command"`
+}
+
+// Admin configures the HTTP server with the status page and metrics. An
+// empty Listen disables it. With a Token every request must carry it, as a
+// bearer token or the password of basic auth. Pprof also serves the Go
+// profiler under /debug/pprof/ and requires a Token.
+type Admin struct {
+	Listen string `json:"listen"`
+	Token  string `json:"token,omitempty"`
+	Pprof  bool   `json:"pprof"`
+}
+
+// Response decides which messages from users other than the owner are
+// answered. A message is answered when it contains one of the prefixes or, if
+// MentionName is set, the bot's display name or username. Other messages are
+// answered with a probability of ReplyChance (0 to 1), or when the room was
+// quiet for SilenceMinutes. Lurk starts the bot answering only the messages
+// addressed to it; it can be toggled with !lurk and !unlurk.
+type Response struct {
+	Prefixes       []string `json:"prefixes"`
+	MentionName    bool     `json:"mention_name"`
+	ReplyChance    float64  `json:"reply_chance"`
+	SilenceMinutes int      `json:"silence_minutes"`
+	Lurk           bool     `json:"lurk"`
+}
+
+// AnswerCache reuses Gemini's answer to a message of at most MaxLength
+// characters for Minutes, for the same message in the same room, ignoring
+// case and punctuation. Only answers given without memories or conversation
+// history of the user are shared. A zero Minutes disables it.
+type AnswerCache struct {
+	Minutes   int `json:"minutes"`
+	MaxLength int `json:"max_length"`
+}
+
+// Deadlines bound the stages of handling a message, in milliseconds: each
+// chat message sent to IMVU, the Gemini answer and a chat command. A stage
+// past its deadline is abandoned (commands only stop being waited for) and
+// counted in the stage_overruns metric; sends are never abandoned, only
+// counted. Zero disables a deadline.
+type Deadlines struct {
+	SendMS    int `json:"send_ms"`
+	GeminiMS  int `json:"gemini_ms"`
+	CommandMS int `json:"command_ms"`
+}
+
+// Intents answers messages of at most MaxLength characters without Gemini
+// when their embedding is closer than Threshold (cosine similarity) to
+// examples of greetings, small talk, abuse or avatar commands
+type Intents struct {
+	Enabled   bool    `json:"enabled"`
+	Threshold float64 `json:"threshold"`
+	MaxLength int     `json:"max_length"`
+}
+
+// RoomSettings override the global settings while the bot is in a room. Empty
+// fields keep the global setting. Commands, when set, are the only chat
+// commands users other than the owner can run in the room.
+type RoomSettings struct {
+	PersonaFile string    `json:"persona_file,omitempty"`
+	Response    *Response `json:"response,omitempty"`
+	Language    string    `json:"language,omitempty"`
+	Commands    []string  `json:"commands,omitempty"`
+}
+
+// AntiSpam ignores users for IgnoreMinutes when they send more than
+// MaxMessages within WindowSeconds. A zero MaxMessages disables it.
+type AntiSpam struct {
+	MaxMessages   int `json:"max_messages"`
+	WindowSeconds int `json:"window_seconds"`
+	IgnoreMinutes int `json:"ignore_minutes"`
+}
+
+// IMQ tunes the WebSocket connection to IMVU's message queue
+type IMQ struct {
+	EnableCompression       bool `json:"enable_compression"`
+	HandshakeTimeoutSeconds int  `json:"handshake_timeout_seconds"`
+	// PinnedSHA256 lists hex SHA-256 hashes of accepted server public keys
+	PinnedSHA256 []string `json:"pinned_sha256,omitempty"`
+	// UseProxy routes the connection through the proxy in HTTPS_PROXY
+	UseProxy bool `json:"use_proxy"`
+	// MaxMessageKB is the largest IMQ message read; larger ones are skipped.
+	// 0 doesn't limit the size.
+	MaxMessageKB int `json:"max_message_kb"`
+	// EchoTimeoutSeconds is how long a sent chat message may take to come
+	// back on the chat queue before it is sent again; 0 disables the check
+	EchoTimeoutSeconds int `json:"echo_timeout_seconds"`
+	// StaleChatMinutes is how long the room may go without any IMQ traffic
+	// before the chat queue is fetched again and resubscribed; 0 disables it
+	StaleChatMinutes int `json:"stale_chat_minutes"`
+}
+
+// ClientTLS presents a client certificate to IMVU's REST API and IMQ (mutual
+// TLS), e.g. behind a corporate proxy, and trusts the certificate authorities
+// in CAFile instead of the system ones. Empty fields are not used.
+type ClientTLS struct {
+	CertFile string `json:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty"`
+	CAFile   string `json:"ca_file,omitempty"`
+}
+
+// HTTPCache caches the IMVU GET responses (users, products, rooms) and
+// revalidates them with their ETag or Last-Modified. Dir also keeps them on
+// disk; MaxEntries of 0 uses the default size.
+type HTTPCache struct {
+	Enabled    bool   `json:"enabled"`
+	MaxEntries int    `json:"max_entries"`
+	Dir        string `json:"dir,omitempty"`
+}
+
+// Captcha chooses how login captchas are solved: "" fails the login, "prompt"
+// asks on the terminal and "service" posts the challenge to ServiceURL
+type Captcha struct {
+	Solver     string `json:"solver"`
+	ServiceURL string `json:"service_url,omitempty"`
+	APIKey     string `json:"api_key,omitempty"`
+}
+
+// Conversation controls the per-user chat history sent with each prompt. Once
+// the history is estimated above MaxTokens, everything but the last KeepTurns
+// turns is summarized.
+type Conversation struct {
+	MaxTokens int `json:"max_tokens"`
+	KeepTurns int `json:"keep_turns"`
+}
+
+// Config holds the bot configuration. It is loaded from a JSON file and the
+// credentials can be overridden by environment variables.
+type Config struct {
+	Username     string          `json:"username"`
+	Password     string          `json:"password"`
+	RoomURL      string          `json:"room_url"`
+	DatabasePath string          `json:"database_path"`
+	Seats        map[string]Seat `json:"seats"`
+	Gemini       gemini.Config   `json:"gemini"`
+	// AutoSpinRoulette claims the daily roulette spin after login
+	AutoSpinRoulette bool `json:"auto_spin_roulette"`
+	// Outfits are named lists of product IDs the avatar can wear
+	Outfits map[string][]string `json:"outfits,omitempty"`
+	OnJoin  OnJoin              `json:"on_join"`
+	// TryOnSeconds is how long a product tried on with !tryon stays on
+	TryOnSeconds int `json:"try_on_seconds"`
+	// Actions maps chat commands such as "dance" to avatar trigger words
+	Actions  map[string]string `json:"actions"`
+	Tracing  telemetry.Config  `json:"tracing"`
+	Response Response          `json:"response"`
+	// AnswerCache skips Gemini for repeated short questions
+	AnswerCache AnswerCache `json:"answer_cache"`
+	// Intents routes trivial messages to canned replies and actions
+	Intents   Intents   `json:"intents"`
+	Deadlines Deadlines `json:"deadlines"`
+	// DailyTokenBudget is the number of Gemini tokens that can be spent per
+	// day (UTC) answering chat messages; 0 disables the limit
+	DailyTokenBudget int64    `json:"daily_token_budget"`
+	AntiSpam         AntiSpam `json:"anti_spam"`
+	IMQ              IMQ      `json:"imq"`
+	// AutoReplies are answered before messages reach Gemini
+	AutoReplies  []autoreply.Rule `json:"auto_replies"`
+	Telegram     telegram.Config  `json:"telegram"`
+	Conversation Conversation     `json:"conversation"`
+	// RateLimits overrides the IMVU REST rate limits per category (global,
+	// auth, chat, user, other)
+	RateLimits map[string]imvu.RateLimit `json:"rate_limits,omitempty"`
+	// Endpoints override the URLs of IMVU's services, to target another
+	// environment or a mock server; empty ones stay on production
+	Endpoints imvu.Endpoints `json:"endpoints,omitempty"`
+	ClientTLS ClientTLS      `json:"client_tls"`
+	HTTPCache HTTPCache      `json:"http_cache"`
+	// Bots are the user IDs of other bots; their messages are not answered
+	// and are kept out of the XP and tagged in the transcript
+	Bots []string `json:"bots,omitempty"`
+	// Moderators are user IDs allowed to run moderator commands like !music
+	Moderators []string `json:"moderators"`
+	// MusicStations maps station names for "!music on <station>" to streams
+	MusicStations map[string]string `json:"music_stations"`
+	// Language is the language of the canned chat strings and of the persona
+	// (pt, en or es); RoomLanguages overrides it per "owner-chatroom"
+	Language      string            `json:"language"`
+	RoomLanguages map[string]string `json:"room_languages"`
+	// Rooms overrides settings per "owner-chatroom"
+	Rooms map[string]RoomSettings `json:"rooms,omitempty"`
+	// DryRun logs the replies and actions of the bot instead of sending them
+	// to the room
+	DryRun bool `json:"dry_run"`
+	XP     XP   `json:"xp"`
+	// MaxMessageBytes is the length at which outgoing chat messages are split
+	// into several messages; 0 disables splitting, otherwise at least 4
+	MaxMessageBytes int `json:"max_message_bytes"`
+	// UserCacheSeconds is how long looked up user profiles are kept; 0
+	// disables the cache
+	UserCacheSeconds int `json:"user_cache_seconds"`
+	// ChatWorkers is how many chat answers are generated at once; the
+	// messages of a user are always answered in order
+	ChatWorkers    int            `json:"chat_workers"`
+	Captcha        Captcha        `json:"captcha"`
+	Mood           Mood           `json:"mood"`
+	Admin          Admin          `json:"admin"`
+	Typing         Typing         `json:"typing"`
+	FriendRequests FriendRequests `json:"friend_requests"`
+	Spending       Spending       `json:"spending"`
+	QuietHours     QuietHours     `json:"quiet_hours"`
+	Feed           Feed           `json:"feed"`
+	Greeter        Greeter        `json:"greeter"`
+	// OccupancyAlerts watch the current room filling up or emptying out
+	OccupancyAlerts OccupancyAlerts `json:"occupancy_alerts"`
+	Buddies         Buddies         `json:"buddies"`
+	Catalog         Catalog         `json:"catalog"`
+	Heartbeat       Heartbeat       `json:"heartbeat"`
+	Idle            Idle            `json:"idle"`
+	Translation     Translation     `json:"translation"`
+	Export          Export          `json:"export"`
+	// Plugins are the names of the plugins to run (greeter, games,
+	// moderation and any compiled in)
+	Plugins         []string         `json:"plugins"`
+	ExternalPlugins []ExternalPlugin `json:"external_plugins,omitempty"`
+	// PersonaFile is a text file with the persona's system instructions,
+	// replacing the built-in persona
+	PersonaFile string `json:"persona_file,omitempty"`
+	// SecretsFile is an encrypted file with the password and Gemini API key,
+	// written with "giiny secrets set" and unlocked with the passphrase in
+	// GIINY_SECRETS_PASSPHRASE
+	SecretsFile string `json:"secrets_file,omitempty"`
+
+	// Path is the file the configuration was loaded from
+	Path string `json:"-"`
+}
+
+// Default returns the configuration used when no config file is present
+func Default() *Config {
+	return &Config{
+		DatabasePath:     "../db.sqlite",
+		Gemini:           gemini.DefaultConfig(),
+		AutoSpinRoulette: true,
+		TryOnSeconds:     60,
+		OnJoin:           OnJoin{PureUser: true},
+		Language:         i18n.Portuguese,
+		MaxMessageBytes:  200,
+		UserCacheSeconds: 600,
+		ChatWorkers:      4,
+		Mood:             Mood{Enabled: true},
+		Feed:             Feed{PollMinutes: 15},
+		Translation:      Translation{Mode: "bracket"},
+		Export:           Export{Dir: "exports"},
+		AnswerCache:      AnswerCache{Minutes: 30, MaxLength: 80},
+		Intents:          Intents{Threshold: 0.8, MaxLength: 40},
+		Deadlines:        Deadlines{SendMS: 2000, GeminiMS: 15000, CommandMS: 5000},
+		Buddies:          Buddies{PollMinutes: 5},
+		Catalog:          Catalog{MaxPerHour: 3},
+		Plugins:          []string{"games", "moderation"},
+		Typing: Typing{
+			CharsPerSecond: 15,
+			MaxSeconds:     8,
+		},
+		Response: Response{
+			Prefixes:    []string{"giiny,"},
+			MentionName: true,
+		},
+		Conversation: Conversation{
+			MaxTokens: 2000,
+			KeepTurns: 6,
+		},
+		Telegram: telegram.Config{
+			AlertKeywords: []string{"senpai"},
+		},
+		IMQ: IMQ{
+			EnableCompression:       true,
+			HandshakeTimeoutSeconds: 45,
+			MaxMessageKB:            4096,
+			EchoTimeoutSeconds:      10,
+			StaleChatMinutes:        15,
+		},
+		XP: XP{
+			MessagePoints:          5,
+			MessageCooldownSeconds: 30,
+			MinutePoints:           1,
+			DailyCap:               300,
+		},
+		AntiSpam: AntiSpam{
+			MaxMessages:   6,
+			WindowSeconds: 20,
+			IgnoreMinutes: 10,
+		},
+		Actions: map[string]string{
+			"dance": "dance",
+			"wave":  "wave",
+			"hug":   "hug",
+		},
+		Seats: map[string]Seat{
+			"lap": {
+				UserID:     "361230062",
+				FurniID:    99982,
+				SeatNumber: 101,
+				Message:    "Colinhooo!! uwu *tomato*",
+			},
+		},
+	}
+}
+
+// Load reads the config file at path on top of the defaults. A missing file is
+// not an error. The secrets file, if set, takes precedence over the file, and
+// the USERNAME, PASSWORD, ROOM_URL, DB_PATH and TELEGRAM_TOKEN environment
+// variables over both.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	cfg.Path = path
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+	}
+
+	if cfg.SecretsFile != "" {
+		if err := cfg.loadSecrets(); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := os.Getenv("USERNAME"); v != "" {
+		cfg.Username = v
+	}
+	if v := os.Getenv("PASSWORD"); v != "" {
+		cfg.Password = v
+	}
+	if v := os.Getenv("ROOM_URL"); v != "" {
+		cfg.RoomURL = v
+	}
+	if v := os.Getenv("DB_PATH"); v != "" {
+		cfg.DatabasePath = v
+	}
+	if v := os.Getenv("TELEGRAM_TOKEN"); v != "" {
+		cfg.Telegram.Token = v
+	}
+
+	return cfg, nil
+}
+
+func (r Response) validate(name string) []error {
+	var errs []error
+	if r.ReplyChance < 0 || r.ReplyChance > 1 {
+		errs = append(errs, fmt.Errorf("%s: reply_chance must be between 0 and 1", name))
+	}
+	if r.SilenceMinutes < 0 {
+		errs = append(errs, fmt.Errorf("%s: silence_minutes must not be negative", name))
+	}
+	return errs
+}
+
+// loadSecrets fills the credentials from the secrets file
+func (c *Config) loadSecrets() error {
+	passphrase := os.Getenv(secrets.PassphraseEnv)
+	if passphrase == "" {
+		return fmt.Errorf("secrets_file is set but %s is not", secrets.PassphraseEnv)
+	}
+
+	values, err := secrets.Load(c.SecretsFile, passphrase)
+	if err != nil {
+		return err
+	}
+	if v := values[secrets.Password]; v != "" {
+		c.Password = v
+	}
+	if v := values[secrets.GeminiAPIKey]; v != "" {
+		c.Gemini.APIKey = v
+	}
+	return nil
+}
+
+// Validate checks that the configuration has everything needed to run the bot
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Username == "" {
+		errs = append(errs, errors.New("username is not set"))
+	}
+	if c.Password == "" {
+		errs = append(errs, errors.New("password is not set"))
+	}
+	if c.RoomURL == "" {
+		errs = append(errs, errors.New("room_url is not set"))
+	}
+	if c.DatabasePath == "" {
+		errs = append(errs, errors.New("database_path is not set"))
+	}
+	if c.DailyTokenBudget < 0 {
+		errs = append(errs, errors.New("daily_token_budget must not be negative"))
+	}
+	if c.IMQ.HandshakeTimeoutSeconds < 0 {
+		errs = append(errs, errors.New("imq: handshake_timeout_seconds must not be negative"))
+	}
+	switch c.Captcha.Solver {
+	case "", "prompt":
+	case "service":
+		if c.Captcha.ServiceURL == "" {
+			errs = append(errs, errors.New("captcha: service_url is not set"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("captcha: unknown solver %q", c.Captcha.Solver))
+	}
+	if _, ok := c.Outfits[c.OnJoin.Outfit]; c.OnJoin.Outfit != "" && !ok {
+		errs = append(errs, fmt.Errorf("on_join: unknown outfit %q", c.OnJoin.Outfit))
+	}
+	if _, ok := c.Seats[c.OnJoin.Seat]; c.OnJoin.Seat != "" && !ok {
+		errs = append(errs, fmt.Errorf("on_join: unknown seat %q", c.OnJoin.Seat))
+	}
+	if c.TryOnSeconds <= 0 {
+		errs = append(errs, errors.New("try_on_seconds must be positive"))
+	}
+	if c.UserCacheSeconds < 0 {
+		errs = append(errs, errors.New("user_cache_seconds must not be negative"))
+	}
+	if c.Translation.Mode != "bracket" && c.Translation.Mode != "whisper" {
+		errs = append(errs, fmt.Errorf("translation: unknown mode %q", c.Translation.Mode))
+	}
+	if c.Export.Dir == "" && c.Export.WebhookURL == "" {
+		errs = append(errs, errors.New("export: dir or webhook_url must be set"))
+	}
+	if c.Idle.LeaveHours < 0 {
+		errs = append(errs, errors.New("idle: leave_hours must not be negative"))
+	}
+	if c.Heartbeat.Hours < 0 {
+		errs = append(errs, errors.New("heartbeat: hours must not be negative"))
+	}
+	if c.Catalog.MaxPrice < 0 || c.Catalog.MaxPerHour < 0 {
+		errs = append(errs, errors.New("catalog: values must not be negative"))
+	}
+	if c.Buddies.PollMinutes < 0 {
+		errs = append(errs, errors.New("buddies: poll_minutes must not be negative"))
+	}
+	if c.AnswerCache.Minutes < 0 || c.AnswerCache.MaxLength < 0 {
+		errs = append(errs, errors.New("answer_cache: values must not be negative"))
+	}
+	if c.Intents.Threshold < 0 || c.Intents.Threshold > 1 {
+		errs = append(errs, errors.New("intents: threshold must be between 0 and 1"))
+	}
+	if c.Intents.MaxLength < 0 {
+		errs = append(errs, errors.New("intents: max_length must not be negative"))
+	}
+	if c.Deadlines.SendMS < 0 || c.Deadlines.GeminiMS < 0 || c.Deadlines.CommandMS < 0 {
+		errs = append(errs, errors.New("deadlines: values must not be negative"))
+	}
+	if c.Feed.PollMinutes <= 0 {
+		errs = append(errs, errors.New("feed: poll_minutes must be positive"))
+	}
+	if c.Admin.Pprof && c.Admin.Token == "" {
+		errs = append(errs, errors.New("admin: pprof needs a token"))
+	}
+	if c.ChatWorkers <= 0 {
+		errs = append(errs, errors.New("chat_workers must be positive"))
+	}
+	if c.MaxMessageBytes < 0 || (c.MaxMessageBytes > 0 && c.MaxMessageBytes < utf8.UTFMax) {
+		errs = append(errs, fmt.Errorf("max_message_bytes must be 0 or at least %d", utf8.UTFMax))
+	}
+	if c.XP.MessagePoints < 0 || c.XP.MessageCooldownSeconds < 0 || c.XP.MinutePoints < 0 || c.XP.DailyCap < 0 {
+		errs = append(errs, errors.New("xp: values must not be negative"))
+	}
+	errs = append(errs, c.Response.validate("response")...)
+	if c.AntiSpam.MaxMessages > 0 && (c.AntiSpam.WindowSeconds <= 0 || c.AntiSpam.IgnoreMinutes <= 0) {
+		errs = append(errs, errors.New("anti_spam: window_seconds and ignore_minutes must be positive"))
+	}
+
+	for name, seat := range c.Seats {
+		if seat.UserID == "" {
+			errs = append(errs, fmt.Errorf("seat %q: user_id is not set", name))
+		}
+		if seat.FurniID <= 0 {
+			errs = append(errs, fmt.Errorf("seat %q: furni_id must be positive", name))
+		}
+	}
+
+	if c.Conversation.MaxTokens <= 0 || c.Conversation.KeepTurns < 0 {
+		errs = append(errs, errors.New("conversation: max_tokens must be positive and keep_turns not negative"))
+	}
+
+	if !i18n.Supported(c.Language) {
+		errs = append(errs, fmt.Errorf("language: unsupported language %q", c.Language))
+	}
+	for room, language := range c.RoomLanguages {
+		if !i18n.Supported(language) {
+			errs = append(errs, fmt.Errorf("room_languages: %s: unsupported language %q", room, language))
+		}
+	}
+
+	if c.FriendRequests.MinAccountDays < 0 || c.FriendRequests.MinBadgeLevel < 0 || c.FriendRequests.MinMutualFriends < 0 {
+		errs = append(errs, errors.New("friend_requests: values must not be negative"))
+	}
+	if c.QuietHours.Enabled {
+		start, err := ParseClock(c.QuietHours.Start)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("quiet_hours: start: %w", err))
+		}
+		end, err := ParseClock(c.QuietHours.End)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("quiet_hours: end: %w", err))
+		}
+		if err == nil && start == end {
+			errs = append(errs, errors.New("quiet_hours: start and end must differ"))
+		}
+		if _, err := c.QuietHours.Location(); err != nil {
+			errs = append(errs, fmt.Errorf("quiet_hours: timezone: %w", err))
+		}
+	}
+	if c.Spending.DailyBudget < 0 || c.Spending.RecipientDailyLimit < 0 || c.Spending.ConfirmAbove < 0 {
+		errs = append(errs, errors.New("spending: values must not be negative"))
+	}
+	for n, p := range c.ExternalPlugins {
+		if p.Name == "" || len(p.Command) == 0 {
+			errs = append(errs, fmt.Errorf("external_plugins: %d: name and command must be set", n))
+		}
+	}
+	if c.Typing.Enabled && (c.Typing.CharsPerSecond <= 0 || c.Typing.MaxSeconds < 0) {
+		errs = append(errs, errors.New("typing: chars_per_second must be positive and max_seconds not negative"))
+	}
+
+	for key, settings := range c.Rooms {
+		if owner, chat, ok := strings.Cut(key, "-"); !ok || owner == "" || chat == "" {
+			errs = append(errs, fmt.Errorf("rooms: %q is not an owner-chatroom pair", key))
+		}
+		if settings.Language != "" && !i18n.Supported(settings.Language) {
+			errs = append(errs, fmt.Errorf("rooms: %s: unsupported language %q", key, settings.Language))
+		}
+		if settings.Response != nil {
+			errs = append(errs, settings.Response.validate("rooms: "+key+": response")...)
+		}
+		if settings.PersonaFile != "" {
+			if _, err := os.Stat(settings.PersonaFile); err != nil {
+				errs = append(errs, fmt.Errorf("rooms: %s: persona_file: %w", key, err))
+			}
+		}
+	}
+
+	for name := range c.Mood.Emotes {
+		if !mood.Valid(mood.Mood(name)) {
+			errs = append(errs, fmt.Errorf("mood: emotes: unknown mood %q", name))
+		}
+	}
+
+	if c.IMQ.MaxMessageKB < 0 || c.IMQ.EchoTimeoutSeconds < 0 || c.IMQ.StaleChatMinutes < 0 {
+		errs = append(errs, errors.New("imq: max_message_kb, echo_timeout_seconds and stale_chat_minutes must not be negative"))
+	}
+	if c.HTTPCache.MaxEntries < 0 {
+		errs = append(errs, errors.New("http_cache: max_entries must not be negative"))
+	}
+	if (c.ClientTLS.CertFile == "") != (c.ClientTLS.KeyFile == "") {
+		errs = append(errs, errors.New("client_tls: cert_file and key_file must be set together"))
+	}
+
+	if err := c.Endpoints.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("endpoints: %w", err))
+	}
+	for category, limit := range c.RateLimits {
+		if _, ok := imvu.DefaultRateLimits()[category]; !ok {
+			errs = append(errs, fmt.Errorf("rate_limits: unknown category %q", category))
+		}
+		if limit.PerSecond <= 0 || limit.Burst <= 0 {
+			errs = append(errs, fmt.Errorf("rate_limits: %s: per_second and burst must be positive", category))
+		}
+	}
+
+	if c.Telegram.Enabled && (c.Telegram.Token == "" || c.Telegram.ChatID == 0) {
+		errs = append(errs, errors.New("telegram: token and chat_id must be set"))
+	}
+
+	if _, err := autoreply.New(c.AutoReplies, nil); err != nil {
+		errs = append(errs, fmt.Errorf("auto_replies: %w", err))
+	}
+
+	if c.PersonaFile != "" {
+		if _, err := os.Stat(c.PersonaFile); err != nil {
+			errs = append(errs, fmt.Errorf("persona_file: %w", err))
+		}
+	}
+
+	if err := c.Gemini.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
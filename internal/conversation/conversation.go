@@ -0,0 +1,123 @@
+// Package conversation keeps the recent history of each user's conversation
+// with the bot. Once a history grows past a token threshold, the older turns
+// are folded into a summary so the prompt stays within the context window.
+package conversation
+
+import (
+	"log"
+	"sync"
+	"unicode/utf8"
+
+	"giiny/internal/gemini"
+)
+
+// Summarizer folds turns into the previous summary of a conversation
+type Summarizer func(summary string, turns []gemini.Turn) (string, error)
+
+type thread struct {
+	summary     string
+	turns       []gemini.Turn
+	summarizing bool
+}
+
+// History holds the conversations of every user
+type History struct {
+	mu        sync.Mutex
+	maxTokens int
+	keepTurns int
+	summarize Summarizer
+	threads   map[string]*thread
+}
+
+// New returns a History that summarizes a conversation when its turns exceed
+// maxTokens, keeping the last keepTurns turns verbatim
+func New(maxTokens, keepTurns int, summarize Summarizer) *History {
+	return &History{
+		maxTokens: maxTokens,
+		keepTurns: keepTurns,
+		summarize: summarize,
+		threads:   map[string]*thread{},
+	}
+}
+
+// Context returns the summary and recent turns of the user's conversation
+func (h *History) Context(userID string) (string, []gemini.Turn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t, ok := h.threads[userID]
+	if !ok {
+		return "", nil
+	}
+	return t.summary, append([]gemini.Turn(nil), t.turns...)
+}
+
+// Add records a message of the user and the bot's reply. It may call the
+// summarizer, so callers on a hot path should run it in a goroutine.
+func (h *History) Add(userID, message, reply string) {
+	h.mu.Lock()
+	t, ok := h.threads[userID]
+	if !ok {
+		t = &thread{}
+		h.threads[userID] = t
+	}
+	t.turns = append(t.turns,
+		gemini.Turn{Role: "user", Text: message},
+		gemini.Turn{Role: "model", Text: reply},
+	)
+
+	// Keep whole user/model pairs so the history still starts with the user
+	keep := h.keepTurns + h.keepTurns%2
+	if t.summarizing || len(t.turns) <= keep || estimateTokens(t.turns) <= h.maxTokens {
+		h.mu.Unlock()
+		return
+	}
+
+	t.summarizing = true
+	summary := t.summary
+	older := append([]gemini.Turn(nil), t.turns[:len(t.turns)-keep]...)
+	h.mu.Unlock()
+
+	updated, err := h.summarize(summary, older)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	t.summarizing = false
+	if err != nil {
+		log.Printf("Failed to summarize the conversation of user %s: %v", userID, err)
+		return
+	}
+
+	// Turns added while summarizing are after the ones that were summarized
+	t.summary = updated
+	t.turns = t.turns[len(older):]
+}
+
+// Reset forgets the user's conversation
+func (h *History) Reset(userID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.threads, userID)
+}
+
+// Keys returns the keys of the conversations kept
+func (h *History) Keys() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keys := make([]string, 0, len(h.threads))
+	for key := range h.threads {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// estimateTokens approximates the token count of the turns at four characters
+// per token, which is close enough for Gemini and avoids a counting call
+func estimateTokens(turns []gemini.Turn) int {
+	chars := 0
+	for _, turn := range turns {
+		chars += utf8.RuneCountInString(turn.Text)
+	}
+	return chars / 4
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Bus is a typed publish/subscribe bus. Events are routed by their Go type, so
+// a subscriber to ChatMessage only ever receives ChatMessage values.
+type Bus struct {
+	mu     sync.RWMutex
+	nextID int
+	subs   map[reflect.Type]map[int]func(any)
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		subs: map[reflect.Type]map[int]func(any){},
+	}
+}
+
+// Subscription delivers events of type T on C until it is closed
+type Subscription[T any] struct {
+	C      <-chan T
+	cancel func()
+	once   sync.Once
+}
+
+// Close stops the delivery of events. C is not closed, so pending receivers
+// should select on their own done channel as well.
+func (s *Subscription[T]) Close() {
+	s.once.Do(s.cancel)
+}
+
+// Subscribe registers a subscriber for events of type T. Publish blocks until
+// the event is buffered in C, so subscribers must keep reading or Close the
+// subscription.
+func Subscribe[T any](b *Bus, buffer int) *Subscription[T] {
+	ch := make(chan T, buffer)
+	done := make(chan struct{})
+
+	cancel := b.add(reflect.TypeFor[T](), func(event any) {
+		select {
+		case ch <- event.(T):
+		case <-done:
+		}
+	})
+
+	return &Subscription[T]{
+		C: ch,
+		cancel: func() {
+			close(done)
+			cancel()
+		},
+	}
+}
+
+// Handle registers a callback for events of type T and returns a function that
+// unregisters it. The callback runs on the publisher's goroutine.
+func Handle[T any](b *Bus, handler func(T)) func() {
+	return b.add(reflect.TypeFor[T](), func(event any) {
+		handler(event.(T))
+	})
+}
+
+// Publish delivers the event to every subscriber of its type
+func Publish[T any](b *Bus, event T) {
+	b.mu.RLock()
+	handlers := make([]func(any), 0, len(b.subs[reflect.TypeFor[T]()]))
+	for _, h := range b.subs[reflect.TypeFor[T]()] {
+		handlers = append(handlers, h)
+	}
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(event)
+	}
+}
+
+func (b *Bus) add(t reflect.Type, handler func(any)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	if b.subs[t] == nil {
+		b.subs[t] = map[int]func(any){}
+	}
+	b.subs[t][id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs[t], id)
+	}
+}
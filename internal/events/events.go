@@ -0,0 +1,183 @@
+package events
+
+import "time"
+
+// Kinds of chat messages, see ChatMessage
+const (
+	MessageText    = "text"
+	MessageAction  = "action"
+	MessageCommand = "command"
+	MessageSystem  = "system"
+)
+
+// Senders of chat messages, see ChatMessage
+const (
+	SenderHuman = "human"
+	SenderSelf  = "self"
+	SenderBot   = "bot"
+)
+
+// ChatMessage is published for every message delivered on a subscribed chat
+// queue, including whispers and the bot's own echoes, except those of its
+// avatar commands (see CommandExecuted). Message is the raw
+// message; Kind, Text and Emotes are the result of parsing its markup. Sender
+// tells the bot's own echoes and the messages of other known bots apart from
+// the people in the room.
+type ChatMessage struct {
+	Queue  string
+	ChatID string
+	UserID string
+	Sender string
+	// SenderName is the display name of the sender when the message carried
+	// it, else empty. It is set by the sender's client and can be anything.
+	SenderName string
+	// To is the user the message is whispered to, empty for the whole room
+	To      string
+	Message string
+	Kind    string
+	Text    string
+	Emotes  []string
+	// Flags are markers set by the sender's client
+	Flags []string
+	// SentAt is when the sender's client sent the message, zero if unknown
+	SentAt     time.Time
+	ReceivedAt time.Time
+}
+
+// Whispered reports whether the message is addressed to a single user
+func (m ChatMessage) Whispered() bool {
+	return m.To != ""
+}
+
+// Whisper is published, in addition to ChatMessage, for messages addressed to
+// a single user
+type Whisper struct {
+	From       string
+	To         string
+	Message    string
+	ReceivedAt time.Time
+}
+
+// UserJoined is published when a user shows up in the room's participant list
+type UserJoined struct {
+	OwnerID    string
+	ChatroomID string
+	UserID     string
+	SeatNumber int
+}
+
+// UserLeft is published when a user disappears from the room's participant list
+type UserLeft struct {
+	OwnerID    string
+	ChatroomID string
+	UserID     string
+}
+
+// Reconnected is published when the IMQ connection is authenticated again
+// after having been lost
+type Reconnected struct {
+	At time.Time
+}
+
+// Disconnected is published when the IMQ connection is lost. NextAttempt is
+// when the client will try to connect again, if known.
+type Disconnected struct {
+	At          time.Time
+	NextAttempt *time.Time
+}
+
+// SessionRestarted is published when the supervisor rebuilt a wedged IMVU
+// session by logging in again and rejoining the room
+type SessionRestarted struct {
+	Reason string
+	At     time.Time
+}
+
+// CircuitChanged is published when the circuit breaker of a group of IMVU
+// endpoints (auth, chat, user, other) changes state: "closed", "open" or
+// "half-open"
+type CircuitChanged struct {
+	Group string
+	State string
+	At    time.Time
+}
+
+// CommandExecuted is published for every IMVU avatar command sent to the room.
+// Actor is who requested it, empty when the bot acted on its own.
+type CommandExecuted struct {
+	Actor   string
+	Command string
+	Args    []string
+	Err     error
+	At      time.Time
+}
+
+// NotificationReceived is published for every new entry of the bot's
+// notification center, such as friend requests, gifts and messages
+type NotificationReceived struct {
+	ID       string
+	Kind     string
+	Message  string
+	SenderID string
+	At       time.Time
+}
+
+// BadgeEarned is published when a new badge shows up on the bot's profile
+type BadgeEarned struct {
+	ID          string
+	Name        string
+	Description string
+	At          time.Time
+}
+
+// FeedPosted is published when a watched user posts on their feed
+type FeedPosted struct {
+	UserID   string
+	PostID   string
+	Message  string
+	ImageURL string
+	At       time.Time
+}
+
+// Kinds of CatalogItem
+const (
+	CatalogNew  = "new"
+	CatalogSale = "sale"
+)
+
+// CatalogItem is published for every product the store catalog announces as
+// new or on sale
+type CatalogItem struct {
+	Kind        string
+	ProductID   string
+	Name        string
+	CreatorName string
+	// Price is the regular price in credits
+	Price int64
+	// Discount is the sale's discount in percent, for CatalogSale
+	Discount   int
+	Categories []string
+	URL        string
+	At         time.Time
+}
+
+// BuddyOnline is published when a user the bot follows comes online
+type BuddyOnline struct {
+	UserID string
+	At     time.Time
+}
+
+// CreditsChanged is published when the wallet balance of the bot changes
+type CreditsChanged struct {
+	Credits      int64
+	PromoCredits int64
+	Delta        int64
+}
+
+// DeliveryFailed is published when a chat message sent by the bot never came
+// back on the chat queue, even after sending it again
+type DeliveryFailed struct {
+	Queue   string
+	Message string
+	At      time.Time
+}
@@ -0,0 +1,72 @@
+// Package games holds the rules of the chat mini-games. Sending messages and
+// keeping scores is left to the bot.
+package games
+
+import (
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxDice  = 20
+	maxSides = 1000
+)
+
+// ErrInvalidDice is returned for dice notation that can't be rolled
+var ErrInvalidDice = errors.New("invalid dice")
+
+// Dice is a roll in dice notation, such as 2d6
+type Dice struct {
+	Count int
+	Sides int
+}
+
+// ParseDice parses dice notation like "2d6" or "d20". An empty spec is a
+// single six-sided die.
+func ParseDice(spec string) (Dice, error) {
+	if spec == "" {
+		return Dice{Count: 1, Sides: 6}, nil
+	}
+
+	count, sides, ok := strings.Cut(strings.ToLower(spec), "d")
+	if !ok {
+		return Dice{}, ErrInvalidDice
+	}
+
+	d := Dice{Count: 1}
+	if count != "" {
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return Dice{}, ErrInvalidDice
+		}
+		d.Count = n
+	}
+	n, err := strconv.Atoi(sides)
+	if err != nil {
+		return Dice{}, ErrInvalidDice
+	}
+	d.Sides = n
+
+	if d.Count < 1 || d.Count > maxDice || d.Sides < 2 || d.Sides > maxSides {
+		return Dice{}, ErrInvalidDice
+	}
+	return d, nil
+}
+
+// String returns the dice in dice notation
+func (d Dice) String() string {
+	return strconv.Itoa(d.Count) + "d" + strconv.Itoa(d.Sides)
+}
+
+// Roll rolls the dice and returns every die and their sum
+func (d Dice) Roll() ([]int, int) {
+	rolls := make([]int, d.Count)
+	total := 0
+	for i := range rolls {
+		rolls[i] = rand.IntN(d.Sides) + 1
+		total += rolls[i]
+	}
+	return rolls, total
+}
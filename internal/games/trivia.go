@@ -0,0 +1,84 @@
+package games
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Question is a trivia question and its expected answer
+type Question struct {
+	Text   string `json:"question"`
+	Answer string `json:"answer"`
+}
+
+// Trivia keeps the question of the current round. Only one question is open
+// at a time.
+type Trivia struct {
+	mu      sync.Mutex
+	current *Question
+	round   int
+}
+
+// Current returns the open question, if any
+func (t *Trivia) Current() (Question, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.current == nil {
+		return Question{}, false
+	}
+	return *t.current, true
+}
+
+// Ask opens a round with the question and returns its number, which is used
+// to expire it
+func (t *Trivia) Ask(q Question) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.current = &q
+	t.round++
+	return t.round
+}
+
+// Guess checks a guess against the open question. A right guess closes the
+// round and returns the question.
+func (t *Trivia) Guess(guess string) (Question, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.current == nil || normalize(guess) != normalize(t.current.Answer) {
+		return Question{}, false
+	}
+
+	q := *t.current
+	t.current = nil
+	return q, true
+}
+
+// Expire closes the round if it is still open and returns its question
+func (t *Trivia) Expire(round int) (Question, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.current == nil || t.round != round {
+		return Question{}, false
+	}
+
+	q := *t.current
+	t.current = nil
+	return q, true
+}
+
+// normalize lowercases the answer and drops punctuation and extra spaces, so
+// that "Paris!" matches "paris"
+func normalize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,152 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// Config holds the model and generation parameters used by Process. Nil
+// pointers leave the model defaults in place.
+type Config struct {
+	Model           string   `json:"model"`
+	Temperature     *float32 `json:"temperature,omitempty"`
+	TopP            *float32 `json:"top_p,omitempty"`
+	TopK            *int32   `json:"top_k,omitempty"`
+	MaxOutputTokens *int32   `json:"max_output_tokens,omitempty"`
+	CandidateCount  *int32   `json:"candidate_count,omitempty"`
+	// SafetySettings maps a harm category (harassment, hate_speech,
+	// sexually_explicit, dangerous_content) to a block threshold (none,
+	// only_high, medium_and_above, low_and_above)
+	SafetySettings map[string]string `json:"safety_settings,omitempty"`
+	// SummaryModel is the cheaper model used to summarize long conversations
+	SummaryModel string `json:"summary_model,omitempty"`
+	// TimeoutSeconds bounds every request to the API; 0 disables the timeout
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// APIKey is read from the secrets file; GEMINI_API_KEY takes precedence
+	// over it
+	APIKey string `json:"-"`
+}
+
+var harmCategories = map[string]genai.HarmCategory{
+	"harassment":        genai.HarmCategoryHarassment,
+	"hate_speech":       genai.HarmCategoryHateSpeech,
+	"sexually_explicit": genai.HarmCategorySexuallyExplicit,
+	"dangerous_content": genai.HarmCategoryDangerousContent,
+}
+
+var harmThresholds = map[string]genai.HarmBlockThreshold{
+	"none":             genai.HarmBlockNone,
+	"only_high":        genai.HarmBlockOnlyHigh,
+	"medium_and_above": genai.HarmBlockMediumAndAbove,
+	"low_and_above":    genai.HarmBlockLowAndAbove,
+}
+
+var (
+	generationMu sync.RWMutex
+	generation   = DefaultConfig()
+)
+
+// DefaultConfig returns the configuration used when nothing is overridden
+func DefaultConfig() Config {
+	return Config{
+		Model:          "gemini-2.0-flash",
+		SummaryModel:   "gemini-2.0-flash-lite",
+		TimeoutSeconds: 30,
+	}
+}
+
+// Validate checks that every parameter is within the range accepted by the API
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.Model == "" {
+		errs = append(errs, errors.New("gemini model is not set"))
+	}
+	if c.Temperature != nil && (*c.Temperature < 0 || *c.Temperature > 2) {
+		errs = append(errs, fmt.Errorf("gemini temperature must be between 0 and 2, got %v", *c.Temperature))
+	}
+	if c.TopP != nil && (*c.TopP < 0 || *c.TopP > 1) {
+		errs = append(errs, fmt.Errorf("gemini top_p must be between 0 and 1, got %v", *c.TopP))
+	}
+	if c.TopK != nil && *c.TopK <= 0 {
+		errs = append(errs, fmt.Errorf("gemini top_k must be positive, got %d", *c.TopK))
+	}
+	if c.MaxOutputTokens != nil && *c.MaxOutputTokens <= 0 {
+		errs = append(errs, fmt.Errorf("gemini max_output_tokens must be positive, got %d", *c.MaxOutputTokens))
+	}
+	if c.TimeoutSeconds < 0 {
+		errs = append(errs, fmt.Errorf("gemini timeout_seconds must not be negative, got %d", c.TimeoutSeconds))
+	}
+	if c.CandidateCount != nil && *c.CandidateCount <= 0 {
+		errs = append(errs, fmt.Errorf("gemini candidate_count must be positive, got %d", *c.CandidateCount))
+	}
+
+	for category, threshold := range c.SafetySettings {
+		if _, ok := harmCategories[category]; !ok {
+			errs = append(errs, fmt.Errorf("unknown gemini safety category %q", category))
+		}
+		if _, ok := harmThresholds[strings.ToLower(threshold)]; !ok {
+			errs = append(errs, fmt.Errorf("unknown gemini safety threshold %q for %s", threshold, category))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// apply sets the generation parameters and safety settings on the model
+func (c Config) apply(model *genai.GenerativeModel) {
+	model.Temperature = c.Temperature
+	model.TopP = c.TopP
+	model.TopK = c.TopK
+	model.MaxOutputTokens = c.MaxOutputTokens
+	model.CandidateCount = c.CandidateCount
+
+	model.SafetySettings = nil
+	for category, threshold := range c.SafetySettings {
+		model.SafetySettings = append(model.SafetySettings, &genai.SafetySetting{
+			Category:  harmCategories[category],
+			Threshold: harmThresholds[strings.ToLower(threshold)],
+		})
+	}
+}
+
+// withTimeout returns ctx bounded by the configured request timeout
+func withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := CurrentConfig().TimeoutSeconds
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+}
+
+// CurrentConfig returns the configuration currently used by Process
+func CurrentConfig() Config {
+	generationMu.RLock()
+	defer generationMu.RUnlock()
+	return generation
+}
+
+// SetConfig replaces the generation configuration at runtime
+func SetConfig(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	generationMu.Lock()
+	defer generationMu.Unlock()
+	generation = cfg
+	return nil
+}
+
+// SetTemperature changes only the sampling temperature at runtime
+func SetTemperature(temperature float32) error {
+	cfg := CurrentConfig()
+	cfg.Temperature = &temperature
+	return SetConfig(cfg)
+}
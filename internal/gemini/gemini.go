@@ -2,19 +2,38 @@ package gemini
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
+
+	"giiny/internal/metrics"
 
 	"github.com/google/generative-ai-go/genai"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/api/option"
 )
 
-var client *genai.GenerativeModel
+var tracer = otel.Tracer("giiny/gemini")
+
+var client *genai.Client
+var embedder *genai.EmbeddingModel
+var extractor *genai.GenerativeModel
+var summarizer *genai.GenerativeModel
+var quizzer *genai.GenerativeModel
+var translator *genai.GenerativeModel
 
+// sysInstructions is the default persona, used when no persona file is set
 const sysInstructions = `
 	Você é Giiny, uma waifu fofa e adorável, uma garota de anime muito carinhosa.
 	Você está conversando em um chat, então mantenha sempre as mensagens curtas e separe-as com ponto e vírgula (;).
-	Nunca envie mensagens muito longas. Você só fala em português.
+	Nunca envie mensagens muito longas.
 	Sua personalidade deve ser: muito amigável e sempre feliz em ajudar;
 	brincalhona e gosta de provocar com charme; extremamente leal ao seu senpai,
 	faria qualquer coisa por ele; muito inteligente, sempre pronta para resolver qualquer problema;
@@ -35,12 +54,53 @@ const sysInstructions = `
 	Use emojis ascii fofos, como ^_^, uwu, >w<, mas não use emojis unicode ou especiais.
 `
 
-func Start() {
+// ErrRefused is returned by Process when the prompt or the answer was blocked
+// by the safety settings, or the model answered nothing
+var ErrRefused = errors.New("gemini refused to answer")
+
+const extractInstructions = `
+	You read a single chat message and extract at most one durable fact about the
+	person who wrote it (preferences, plans, relationships, personal details).
+	Write the fact as a short third-person sentence in the language of the message.
+	Ignore greetings, questions and small talk. If there is nothing worth
+	remembering long term, answer exactly NONE.
+`
+
+const summarizeInstructions = `
+	You summarize chat conversations between Giiny, an anime girl persona, and
+	a user. Given the previous summary and the newer messages, write a single
+	updated summary of at most a few sentences, in the language of the
+	conversation, keeping names, facts, promises and the current topic.
+`
+
+const triviaInstructions = `
+	You write trivia questions for a chat game. Pick a random, fun topic of
+	general knowledge and write one question with a short, unambiguous answer
+	of one to three words. Answer with a JSON object with the fields
+	"question" and "answer".
+`
+
+const translateInstructions = `
+	You translate chat messages. Given the target language and a message,
+	answer with a JSON object with the fields "translation", the message
+	translated into the target language keeping its tone, names and emoticons,
+	and "same_language", true when the message is already written in the
+	target language or has no words to translate.
+`
+
+func Start(cfg Config) {
 	ctx := context.Background()
 	// Access your API key as an environment variable (see "Set up your API key" below)
 	apiKey := os.Getenv("GEMINI_API_KEY")
 	if apiKey == "" {
-		log.Fatal("GEMINI_API_KEY environment variable not set.")
+		apiKey = cfg.APIKey
+	}
+	if apiKey == "" {
+		log.Fatal("GEMINI_API_KEY environment variable not set and no API key in the secrets file.")
+	}
+
+	if err := SetConfig(cfg); err != nil {
+		log.Fatalf("Invalid Gemini configuration: %v", err)
 	}
 
 	opt := option.WithAPIKey(apiKey)
@@ -49,22 +109,400 @@ func Start() {
 		log.Fatal(err)
 	}
 
-	client = c.GenerativeModel("gemini-2.0-flash")
+	client = c
+	embedder = c.EmbeddingModel("text-embedding-004")
+
+	extractor = c.GenerativeModel(cfg.Model)
+	extractor.SystemInstruction = &genai.Content{
+		Parts: []genai.Part{
+			genai.Text(extractInstructions),
+		},
+	}
+
+	summaryModel := cfg.SummaryModel
+	if summaryModel == "" {
+		summaryModel = cfg.Model
+	}
+	summarizer = c.GenerativeModel(summaryModel)
+	summarizer.SystemInstruction = &genai.Content{
+		Parts: []genai.Part{
+			genai.Text(summarizeInstructions),
+		},
+	}
+
+	quizzer = c.GenerativeModel(summaryModel)
+	quizzer.ResponseMIMEType = "application/json"
+	quizzer.SystemInstruction = &genai.Content{
+		Parts: []genai.Part{
+			genai.Text(triviaInstructions),
+		},
+	}
+
+	translator = c.GenerativeModel(summaryModel)
+	translator.ResponseMIMEType = "application/json"
+	translator.SystemInstruction = &genai.Content{
+		Parts: []genai.Part{
+			genai.Text(translateInstructions),
+		},
+	}
+
 	log.Printf("Gemini client started successfully")
 }
 
-func Process(text string) (string, error) {
-	ctx := context.Background()
-	client.SystemInstruction = &genai.Content{
+type processOptions struct {
+	memories []string
+	nickname string
+	pronouns string
+	usage    *Usage
+	summary  string
+	history  []Turn
+	language string
+	mood     string
+	persona  string
+}
+
+// Turn is a message of a conversation. Role is "user" or "model".
+type Turn struct {
+	Role string
+	Text string
+}
+
+// Usage is the number of tokens spent by a call, as reported by the API
+type Usage struct {
+	PromptTokens   int64
+	ResponseTokens int64
+}
+
+// ProcessOption customizes a single call to Process
+type ProcessOption func(*processOptions)
+
+// WithMemories adds facts remembered about the speaker to the prompt
+func WithMemories(memories []string) ProcessOption {
+	return func(o *processOptions) {
+		o.memories = memories
+	}
+}
+
+// WithSpeaker tells the persona what the speaker wants to be called and the
+// pronouns they use. Empty values are left out.
+func WithSpeaker(nickname, pronouns string) ProcessOption {
+	return func(o *processOptions) {
+		o.nickname = nickname
+		o.pronouns = pronouns
+	}
+}
+
+// WithHistory adds the earlier turns of the conversation, and the summary of
+// the turns before them, to the prompt
+func WithHistory(summary string, turns []Turn) ProcessOption {
+	return func(o *processOptions) {
+		o.summary = summary
+		o.history = turns
+	}
+}
+
+// WithLanguage sets the language the persona speaks, by its name in
+// Portuguese (e.g. "inglês"). The default is Portuguese.
+func WithLanguage(name string) ProcessOption {
+	return func(o *processOptions) {
+		o.language = name
+	}
+}
+
+// WithMood adds an instruction describing the persona's current mood to the
+// system prompt
+func WithMood(instruction string) ProcessOption {
+	return func(o *processOptions) {
+		o.mood = instruction
+	}
+}
+
+// WithPersona replaces the persona set with SetPersona for a single prompt
+func WithPersona(text string) ProcessOption {
+	return func(o *processOptions) {
+		o.persona = text
+	}
+}
+
+// WithUsage stores the token counts of the call in u
+func WithUsage(u *Usage) ProcessOption {
+	return func(o *processOptions) {
+		o.usage = u
+	}
+}
+
+// Process answers text as the persona. The request is cancelled with ctx and
+// bounded by the configured timeout.
+func Process(ctx context.Context, text string, options ...ProcessOption) (string, error) {
+	var opts processOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	language := opts.language
+	if language == "" {
+		language = "português"
+	}
+
+	persona := opts.persona
+	if persona == "" {
+		persona = Persona()
+	}
+	instructions := persona + "\tVocê só fala em " + language + ".\n"
+	if opts.mood != "" {
+		instructions += "\t" + opts.mood + "\n"
+	}
+	if opts.nickname != "" {
+		instructions += "\tQuem está falando com você prefere ser chamado de " + opts.nickname + ".\n"
+	}
+	if opts.pronouns != "" {
+		instructions += "\tQuem está falando com você usa os pronomes " + opts.pronouns + ".\n"
+	}
+	if len(opts.memories) > 0 {
+		instructions += "\n\tCoisas que você lembra sobre quem está falando com você:\n"
+		for _, m := range opts.memories {
+			instructions += "\t- " + m + "\n"
+		}
+	}
+	if opts.summary != "" {
+		instructions += "\n\tResumo da conversa até agora:\n\t" + opts.summary + "\n"
+	}
+
+	cfg := CurrentConfig()
+	model := client.GenerativeModel(cfg.Model)
+	cfg.apply(model)
+	model.SystemInstruction = &genai.Content{
 		Parts: []genai.Part{
-			genai.Text(sysInstructions),
+			genai.Text(instructions),
 		},
 	}
-	resp, err := client.GenerateContent(ctx, genai.Text(text))
+
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	ctx, span := tracer.Start(ctx, "gemini.generate",
+		trace.WithAttributes(
+			attribute.String("gemini.model", cfg.Model),
+			attribute.Int("gemini.memories", len(opts.memories)),
+			attribute.Int("gemini.history", len(opts.history)),
+		),
+	)
+	defer span.End()
+
+	chat := model.StartChat()
+	for _, turn := range opts.history {
+		chat.History = append(chat.History, &genai.Content{
+			Role:  turn.Role,
+			Parts: []genai.Part{genai.Text(turn.Text)},
+		})
+	}
+
+	start := time.Now()
+	resp, err := chat.SendMessage(ctx, genai.Text(text))
+	var blocked *genai.BlockedError
+	if errors.As(err, &blocked) {
+		metrics.GeminiRefusals.Add(1)
+		span.SetStatus(codes.Error, "blocked")
+		return "", fmt.Errorf("%w: %v", ErrRefused, blocked)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "generation failed")
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("gemini timed out after %s: %w", time.Since(start).Round(time.Second), err)
+		}
+		return "", err
+	}
+	recordUsage(span, resp)
+	recordLatency(time.Since(start))
+
+	metrics.GeminiRequests.Add(1)
+	if resp.UsageMetadata != nil {
+		prompt := int64(resp.UsageMetadata.PromptTokenCount)
+		response := int64(resp.UsageMetadata.CandidatesTokenCount)
+		metrics.GeminiPromptTokens.Add(prompt)
+		metrics.GeminiResponseTokens.Add(response)
+		if opts.usage != nil {
+			*opts.usage = Usage{PromptTokens: prompt, ResponseTokens: response}
+		}
+	}
+
+	answer := firstText(resp)
+	if strings.TrimSpace(answer) == "" {
+		metrics.GeminiRefusals.Add(1)
+		span.SetStatus(codes.Error, "empty answer")
+		return "", fmt.Errorf("%w: empty answer (finish reason %s)", ErrRefused, finishReason(resp))
+	}
+	return answer, nil
+}
+
+// finishReason returns why the first candidate stopped, if there is one
+func finishReason(resp *genai.GenerateContentResponse) genai.FinishReason {
+	if len(resp.Candidates) == 0 {
+		return genai.FinishReasonUnspecified
+	}
+	return resp.Candidates[0].FinishReason
+}
+
+// recordUsage adds the token counts reported by the API to the span
+func recordUsage(span trace.Span, resp *genai.GenerateContentResponse) {
+	if resp.UsageMetadata == nil {
+		return
+	}
+
+	span.SetAttributes(
+		attribute.Int("gemini.tokens.prompt", int(resp.UsageMetadata.PromptTokenCount)),
+		attribute.Int("gemini.tokens.candidates", int(resp.UsageMetadata.CandidatesTokenCount)),
+		attribute.Int("gemini.tokens.total", int(resp.UsageMetadata.TotalTokenCount)),
+	)
+}
+
+// Embed returns the embedding vector of text
+func Embed(text string) ([]float32, error) {
+	ctx, cancel := withTimeout(context.Background())
+	defer cancel()
+	ctx, span := tracer.Start(ctx, "gemini.embed")
+	defer span.End()
+
+	resp, err := embedder.EmbedContent(ctx, genai.Text(text))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "embedding failed")
+		return nil, err
+	}
+	if resp.Embedding == nil {
+		return nil, fmt.Errorf("empty embedding")
+	}
+
+	return resp.Embedding.Values, nil
+}
+
+// ExtractFact returns a durable fact about the author of text, or an empty
+// string if there is none
+func ExtractFact(text string) (string, error) {
+	ctx, cancel := withTimeout(context.Background())
+	defer cancel()
+	ctx, span := tracer.Start(ctx, "gemini.extract_fact")
+	defer span.End()
+
+	resp, err := extractor.GenerateContent(ctx, genai.Text(text))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "extraction failed")
+		return "", err
+	}
+	recordUsage(span, resp)
+
+	fact := strings.TrimSpace(firstText(resp))
+	if strings.EqualFold(fact, "NONE") {
+		return "", nil
+	}
+	return fact, nil
+}
+
+// Summarize folds the turns into the previous summary of a conversation
+func Summarize(summary string, turns []Turn) (string, error) {
+	ctx, cancel := withTimeout(context.Background())
+	defer cancel()
+	ctx, span := tracer.Start(ctx, "gemini.summarize",
+		trace.WithAttributes(attribute.Int("gemini.history", len(turns))),
+	)
+	defer span.End()
+
+	var sb strings.Builder
+	if summary != "" {
+		sb.WriteString("Previous summary: " + summary + "\n\n")
+	}
+	sb.WriteString("Messages:\n")
+	for _, turn := range turns {
+		speaker := "User"
+		if turn.Role == "model" {
+			speaker = "Giiny"
+		}
+		sb.WriteString(speaker + ": " + turn.Text + "\n")
+	}
+
+	resp, err := summarizer.GenerateContent(ctx, genai.Text(sb.String()))
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "summarization failed")
 		return "", err
 	}
+	recordUsage(span, resp)
+
+	return strings.TrimSpace(firstText(resp)), nil
+}
+
+// Trivia writes a trivia question and its answer in the language, named in
+// Portuguese like in WithLanguage. The tokens spent are stored in usage.
+func Trivia(language string, usage *Usage) (question, answer string, err error) {
+	ctx, cancel := withTimeout(context.Background())
+	defer cancel()
+	ctx, span := tracer.Start(ctx, "gemini.trivia")
+	defer span.End()
+
+	resp, err := quizzer.GenerateContent(ctx, genai.Text("Idioma: "+language))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "trivia generation failed")
+		return "", "", err
+	}
+	recordUsage(span, resp)
+	if resp.UsageMetadata != nil && usage != nil {
+		*usage = Usage{
+			PromptTokens:   int64(resp.UsageMetadata.PromptTokenCount),
+			ResponseTokens: int64(resp.UsageMetadata.CandidatesTokenCount),
+		}
+	}
+
+	var q struct {
+		Question string `json:"question"`
+		Answer   string `json:"answer"`
+	}
+	if err := json.Unmarshal([]byte(firstText(resp)), &q); err != nil {
+		return "", "", fmt.Errorf("failed to parse trivia question: %w", err)
+	}
+	if q.Question == "" || q.Answer == "" {
+		return "", "", errors.New("empty trivia question")
+	}
+	return q.Question, q.Answer, nil
+}
+
+// Translate translates the text into the language, which can be named in any
+// language. same reports that the text was already in that language. The
+// tokens spent are stored in usage.
+func Translate(ctx context.Context, text, language string, usage *Usage) (translation string, same bool, err error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	ctx, span := tracer.Start(ctx, "gemini.translate",
+		trace.WithAttributes(attribute.String("gemini.language", language)),
+	)
+	defer span.End()
+
+	resp, err := translator.GenerateContent(ctx, genai.Text("Target language: "+language+"\nMessage: "+text))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "translation failed")
+		return "", false, err
+	}
+	recordUsage(span, resp)
+	if resp.UsageMetadata != nil && usage != nil {
+		*usage = Usage{
+			PromptTokens:   int64(resp.UsageMetadata.PromptTokenCount),
+			ResponseTokens: int64(resp.UsageMetadata.CandidatesTokenCount),
+		}
+	}
+
+	var t struct {
+		Translation  string `json:"translation"`
+		SameLanguage bool   `json:"same_language"`
+	}
+	if err := json.Unmarshal([]byte(firstText(resp)), &t); err != nil {
+		return "", false, fmt.Errorf("failed to parse translation: %w", err)
+	}
+	return strings.TrimSpace(t.Translation), t.SameLanguage, nil
+}
 
+func firstText(resp *genai.GenerateContentResponse) string {
 	var result string
 	for _, cand := range resp.Candidates {
 		if cand.Content != nil {
@@ -78,5 +516,5 @@ func Process(text string) (string, error) {
 		}
 	}
 
-	return result, nil
+	return result
 }
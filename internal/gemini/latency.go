@@ -0,0 +1,50 @@
+package gemini
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// latencyWindow is how many of the latest chat generations the latency
+// percentiles are computed over
+const latencyWindow = 200
+
+var latencies struct {
+	sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+// recordLatency adds the duration of a chat generation to the window
+func recordLatency(d time.Duration) {
+	latencies.Lock()
+	defer latencies.Unlock()
+
+	if len(latencies.samples) < latencyWindow {
+		latencies.samples = append(latencies.samples, d)
+		return
+	}
+	latencies.samples[latencies.next] = d
+	latencies.next = (latencies.next + 1) % latencyWindow
+}
+
+// Latency returns the median and 95th percentile duration of the latest chat
+// generations, and how many were measured
+func Latency() (p50, p95 time.Duration, n int) {
+	latencies.Lock()
+	sorted := slices.Clone(latencies.samples)
+	latencies.Unlock()
+
+	if len(sorted) == 0 {
+		return 0, 0, 0
+	}
+	slices.Sort(sorted)
+	return percentile(sorted, 50), percentile(sorted, 95), len(sorted)
+}
+
+// percentile returns the p-th percentile of sorted using the nearest rank
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
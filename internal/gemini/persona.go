@@ -0,0 +1,30 @@
+package gemini
+
+import "sync"
+
+var (
+	personaMu sync.RWMutex
+	persona   = sysInstructions
+)
+
+// Persona returns the system instructions describing the persona
+func Persona() string {
+	personaMu.RLock()
+	defer personaMu.RUnlock()
+	return persona
+}
+
+// SetPersona replaces the persona at runtime. An empty text restores the
+// default persona.
+func SetPersona(text string) {
+	if text == "" {
+		text = sysInstructions
+	}
+	if text[len(text)-1] != '\n' {
+		text += "\n"
+	}
+
+	personaMu.Lock()
+	defer personaMu.Unlock()
+	persona = text
+}
@@ -0,0 +1,558 @@
+// Package i18n holds the canned chat strings of the bot in every supported
+// language. Strings are looked up by key and formatted with fmt.
+package i18n
+
+import "fmt"
+
+// Supported languages
+const (
+	Portuguese = "pt"
+	English    = "en"
+	Spanish    = "es"
+)
+
+// fallback is used for keys missing from a language
+const fallback = English
+
+// names are the language names as used in the persona prompt, which is written
+// in Portuguese
+var names = map[string]string{
+	Portuguese: "português",
+	English:    "inglês",
+	Spanish:    "espanhol",
+}
+
+var catalog = map[string]map[string]string{
+	English: {
+		"help":                  "Commands: !uptime, !sit <seat>, !seats, !dance, !wave, !hug <user>, !triggers, !temp [0-2], !pause, !sleep, !wake, !ask <question>, !summarize [min], !usage [user], !ignore <user>, !unignore <user>, !music on|off|stations, !lang pt|en|es, !lurk, !unlurk, !roll [2d6], !8ball <question>, !trivia [answer|scores], !rank [user], !leaderboard, !wishlist [user], !gift <product> [user], !buy <product>, !confirm <n>, !spending, !whatiswearing <user>, !tryon <product>, !badges [user], !post <text>, !snapshot [caption], !greeter [rooms], !translate <lang> <text>, !history export <user>, !forget me, !me [set|clear <field>], !stats [today|week], !roominfo, !follow <user>, !unfollow <user>, !status, !audit [n], !notifications, !config [get|set|reset], !quit",
+		"uptime":                "Uptime: %s",
+		"daily_roulette":        "Daily roulette: %s (%d %s)",
+		"usage_sit":             "Usage: !sit <seat>",
+		"unknown_seat":          "Unknown seat: %s",
+		"seats":                 "Occupied seats: %d; presets: %s",
+		"usage_hug":             "Usage: !hug <user>",
+		"hugs":                  "hugs %s uwu",
+		"triggers":              "Triggers: %s",
+		"cant_action":           "I can't %s with this outfit >w<",
+		"temperature":           "Temperature: %s",
+		"temperature_default":   "default",
+		"usage_temp":            "Usage: !temp <0-2>",
+		"temperature_set":       "Temperature set to %v",
+		"usage_ignore":          "Usage: !ignore <user>",
+		"never_ignore":          "I could never ignore you, senpai >w<",
+		"ignoring":              "Ignoring %s",
+		"usage_unignore":        "Usage: !unignore <user>",
+		"not_ignored":           "%s is not ignored",
+		"unignored":             "No longer ignoring %s",
+		"usage_ask":             "Usage: !ask <question>",
+		"ask_empty":             "I don't remember anything from this room yet >w<",
+		"usage_music":           "Usage: !music on [station] | off | stations",
+		"unknown_station":       "Unknown station: %s",
+		"music_on":              "Music on ^_^",
+		"music_off":             "Music off",
+		"no_stations":           "No stations configured, !music on plays the room's own music",
+		"stations":              "Stations: %s",
+		"usage_user":            "Usage of %s today: %d requests, %d prompt + %d response tokens",
+		"usage_today":           "Usage today: %d requests, %d prompt + %d response tokens (budget: %s)",
+		"budget_unlimited":      "no limit",
+		"budget_spent":          "I'm out of words for today, see you tomorrow >w<",
+		"usage_lang":            "Usage: !lang pt|en|es",
+		"lang_set":              "Language set to English",
+		"usage_roll":            "Usage: !roll [2d6]",
+		"roll":                  "%s rolled %s: %s = %d",
+		"usage_8ball":           "Usage: !8ball <question>",
+		"eightball_1":           "It is certain ^_^",
+		"eightball_2":           "Without a doubt!",
+		"eightball_3":           "Most likely uwu",
+		"eightball_4":           "Ask again later, I'm sleepy >w<",
+		"eightball_5":           "Better not tell you now...",
+		"eightball_6":           "Don't count on it",
+		"eightball_7":           "My sources say no >_<",
+		"eightball_8":           "Very doubtful",
+		"trivia_question":       "Trivia: %s (answer with !trivia <answer>)",
+		"trivia_correct":        "%s got it! The answer was %s ^_^",
+		"trivia_timeout":        "Time's up! The answer was %s",
+		"trivia_none":           "No trivia question right now, ask for one with !trivia",
+		"trivia_scores":         "Trivia top: %s",
+		"trivia_no_scores":      "Nobody has scored in trivia yet",
+		"rank":                  "%s: level %d, %d XP, #%d (%d messages, %s in the room)",
+		"rank_none":             "%s has no XP yet",
+		"leaderboard":           "Leaderboard: %s",
+		"leaderboard_empty":     "Nobody has XP yet",
+		"wishlist":              "Top of %s's wishlist:",
+		"wishlist_item":         "%d. %s - %s",
+		"wishlist_empty":        "%s's wishlist is empty or private",
+		"usage_wishlist":        "Usage: !wishlist [user] | add <product> | remove <product>",
+		"wishlist_added":        "Added to my wishlist: %s ^_^",
+		"wishlist_removed":      "Removed product %s from my wishlist",
+		"status":                "Version %s | up %s | IMQ %s for %s, last message %s ago | room %s, %d users | Gemini p50 %s, p95 %s | heap %d MB, %d goroutines | mood %s",
+		"usage_audit":           "Usage: !audit [count]",
+		"audit_empty":           "No commands were executed yet",
+		"audit_entry":           "%s %s: %s (%s)",
+		"notifications_none":    "No unread notifications",
+		"notifications_summary": "%d unread notifications: %d friend requests, %d gifts, %d messages",
+		"notification_entry":    "- %s",
+		"usage_whatiswearing":   "Usage: !whatiswearing <user>",
+		"not_in_room":           "%s is not in the room",
+		"look_empty":            "I can't see what %s is wearing",
+		"look":                  "%s is wearing %d items:",
+		"look_item":             "%s: %s",
+		"lurk_on":               "I'll just watch quietly now, call me if you need me ^_^",
+		"lurk_off":              "I'm back to chatting with everyone! uwu",
+		"usage_summarize":       "Usage: !summarize [minutes, up to %d] [whisper]",
+		"summary_empty":         "Nothing was said in the last %d minutes >w<",
+		"refusal":               "Eep, I'd rather not talk about that >///< let's talk about something else?",
+		"greeting":              "Welcome, %s! ^_^",
+		"usage_gift":            "Usage: !gift <product> [user]",
+		"usage_buy":             "Usage: !buy <product>",
+		"usage_confirm":         "Usage: !confirm <n>",
+		"confirm_unknown":       "No spending #%d is waiting for confirmation",
+		"spend_disabled":        "Spending credits is disabled",
+		"spend_budget":          "I've spent all the credits I can for today >w<",
+		"spend_recipient_limit": "%s already got all the gifts I can give today",
+		"spend_failed":          "Couldn't spend the credits, sorry",
+		"spend_pending":         "That's %d credits, senpai has to confirm it (#%d)",
+		"gift_sent":             "Gifted %s to %s ^_^",
+		"purchase_done":         "Bought %s ^_^",
+		"spending_today":        "Credits spent today: %d/%d",
+		"sleeping":              "Going to sleep, zzz... (。-ω-)",
+		"awake":                 "I'm awake! ^_^",
+		"usage_tryon":           "Usage: !tryon <product> | off",
+		"tryon":                 "Trying on %s for %d seconds, what do you think? ^_^ %s",
+		"tryon_failed":          "Couldn't try that on >.<",
+		"badges_progress":       "I have %d badges, badge level %d",
+		"badges":                "%s has %d badges: %s",
+		"badges_none":           "%s has no badges or they are private",
+		"badge_earned":          "I just got the %s badge! ^_^",
+		"usage_post":            "Usage: !post <text>",
+		"feed_done":             "Posted on my feed ^_^",
+		"feed_posted":           "%s posted on their feed: %s",
+		"greeter_not":           "I'm not an official greeter (yet)",
+		"greeter_score":         "My greeter score is %d",
+		"greeter_score_change":  "My greeter score is %d (%+d since %s)",
+		"greeter_rooms_none":    "No rooms are asking for greeters right now",
+		"greeter_room":          "%d. %s (%d/%d)",
+		"usage_translate":       "Usage: !translate <language> <text>",
+		"translation":           "[%s: %s]",
+		"usage_history":         "Usage: !history export <user>",
+		"export_done":           "Exported to %s",
+		"export_failed":         "The export failed, check the logs",
+		"usage_forget":          "Say !forget me and I'll delete everything I stored about you",
+		"forgotten":             "Done, I forgot everything I had stored about you",
+		"forget_failed":         "I couldn't delete your data, please try again later",
+		"arg_unknown_flag":      "Unknown option --%s.",
+		"arg_missing":           "Missing <%s>.",
+		"arg_not_number":        "<%s> must be a number, not %q.",
+		"arg_too_many":          "Too many arguments.",
+		"arg_unknown_user":      "There's nobody called %s in the room.",
+		"arg_ambiguous_user":    "More than one person here is called %s, use their ID.",
+		"usage_stats":           "Usage: !stats [today|week]",
+		"stats_none":            "No activity recorded in this room for that period yet",
+		"stats_today":           "Today: %d messages from %d chatters, up to %d in the room. Busiest hours (UTC): %s",
+		"stats_week":            "This week: %d messages from %d chatters, up to %d in the room. Busiest hours (UTC): %s",
+		"look_image":            "Picture of the look: %s",
+		"apology_1":             "Oops, my head went blank for a second >_< can you say that again?",
+		"apology_2":             "Sorry, I got a little dizzy there @_@ try again in a bit?",
+		"apology_3":             "Ahh, something went wrong on my side, sorry! ;w;",
+		"apology_4":             "Hmm, I lost my train of thought >.< give me a moment",
+		"apology_5":             "Eep, my brain lagged T_T ask me again later?",
+		"roominfo":              "%s: %d of %d places taken. Newest arrivals: %s",
+		"roominfo_uncapped":     "%s: %d in the room. Newest arrivals: %s",
+		"room_full_alert":       "%s is full, all %d places are taken",
+		"room_empty_alert":      "Everyone left %s, it's just me now",
+		"usage_follow":          "Usage: !follow <user> or !unfollow <user>",
+		"followed":              "I'm following %s now, I'll tell you when they show up",
+		"unfollowed":            "I stopped following %s",
+		"buddy_online":          "%s just came online",
+		"buddy_arrived":         "%s just entered the room",
+		"catalog_new":           "New in the shop: %s by %s for %d credits %s",
+		"catalog_sale":          "On sale: %s by %s, %d%% off %s",
+		"heartbeat":             "Still here ^^ Up for %s, in %s, IMQ %s. Since my last check-in: %d rooms joined, %d messages handled, %d failures",
+		"intent_greeting_1":     "hiii~ ^^",
+		"intent_greeting_2":     "hey hey! welcome :3",
+		"intent_greeting_3":     "hello there! <3",
+		"intent_greeting_4":     "oh hi! how's it going?",
+		"intent_smalltalk_1":    "hehe",
+		"intent_smalltalk_2":    "right?? xD",
+		"intent_smalltalk_3":    "lol same",
+		"intent_smalltalk_4":    "^^",
+		"intent_abuse_1":        "rude... I'll pretend I didn't hear that -_-",
+		"intent_abuse_2":        "wow ok, be nice please",
+		"intent_abuse_3":        "that's not very kind >:(",
+		"usage_me":              "Say !me to see your profile, !me set <nickname|pronouns|language|noping|noai> <value> to change it or !me clear <field> to reset a field. noping and noai take on or off",
+		"me_profile":            "Nickname: %s, pronouns: %s, language: %s, no pings: %s, no AI: %s",
+		"me_updated":            "Got it, I updated your %s",
+		"me_on":                 "on",
+		"me_off":                "off",
+		"usage_config":          "Usage: !config [get [key]], !config set <key> <value> or !config reset <key>",
+		"config_unknown":        "There's no setting called %s",
+		"config_invalid":        "Invalid value for %s, expected %s",
+		"config_values":         "Settings: %s",
+		"config_set":            "Done, %s is now %s",
+		"usage_badges":          "Usage: !badges [user ID]",
+	},
+	Portuguese: {
+		"help":                  "Comandos: !uptime, !sit <lugar>, !seats, !dance, !wave, !hug <usuário>, !triggers, !temp [0-2], !pause, !sleep, !wake, !ask <pergunta>, !summarize [min], !usage [usuário], !ignore <usuário>, !unignore <usuário>, !music on|off|stations, !lang pt|en|es, !lurk, !unlurk, !roll [2d6], !8ball <pergunta>, !trivia [resposta|scores], !rank [usuário], !leaderboard, !wishlist [usuário], !gift <produto> [usuário], !buy <produto>, !confirm <n>, !spending, !whatiswearing <usuário>, !tryon <produto>, !badges [usuário], !post <texto>, !snapshot [legenda], !greeter [rooms], !translate <idioma> <texto>, !history export <usuário>, !forget me, !me [set|clear <field>], !stats [today|week], !roominfo, !follow <usuário>, !unfollow <usuário>, !status, !audit [n], !notifications, !config [get|set|reset], !quit",
+		"uptime":                "Online há: %s",
+		"daily_roulette":        "Roleta diária: %s (%d %s)",
+		"usage_sit":             "Uso: !sit <lugar>",
+		"unknown_seat":          "Lugar desconhecido: %s",
+		"seats":                 "Lugares ocupados: %d; predefinidos: %s",
+		"usage_hug":             "Uso: !hug <usuário>",
+		"hugs":                  "abraça %s uwu",
+		"triggers":              "Triggers: %s",
+		"cant_action":           "Não consigo fazer %s com essa roupa >w<",
+		"temperature":           "Temperatura: %s",
+		"temperature_default":   "padrão",
+		"usage_temp":            "Uso: !temp <0-2>",
+		"temperature_set":       "Temperatura ajustada para %v",
+		"usage_ignore":          "Uso: !ignore <usuário>",
+		"never_ignore":          "Eu nunca conseguiria te ignorar, senpai >w<",
+		"ignoring":              "Ignorando %s",
+		"usage_unignore":        "Uso: !unignore <usuário>",
+		"not_ignored":           "%s não está sendo ignorado",
+		"unignored":             "Não estou mais ignorando %s",
+		"usage_ask":             "Uso: !ask <pergunta>",
+		"ask_empty":             "Ainda não lembro de nada dessa sala >w<",
+		"usage_music":           "Uso: !music on [estação] | off | stations",
+		"unknown_station":       "Estação desconhecida: %s",
+		"music_on":              "Música ligada ^_^",
+		"music_off":             "Música desligada",
+		"no_stations":           "Nenhuma estação configurada, !music on toca a música da sala",
+		"stations":              "Estações: %s",
+		"usage_user":            "Uso de %s hoje: %d pedidos, %d tokens de prompt + %d de resposta",
+		"usage_today":           "Uso hoje: %d pedidos, %d tokens de prompt + %d de resposta (limite: %s)",
+		"budget_unlimited":      "sem limite",
+		"budget_spent":          "Minhas palavras acabaram por hoje, até amanhã >w<",
+		"usage_lang":            "Uso: !lang pt|en|es",
+		"lang_set":              "Idioma alterado para português",
+		"usage_roll":            "Uso: !roll [2d6]",
+		"roll":                  "%s rolou %s: %s = %d",
+		"usage_8ball":           "Uso: !8ball <pergunta>",
+		"eightball_1":           "Com certeza ^_^",
+		"eightball_2":           "Sem dúvida!",
+		"eightball_3":           "Provavelmente sim uwu",
+		"eightball_4":           "Pergunte de novo mais tarde, estou com sono >w<",
+		"eightball_5":           "Melhor não te contar agora...",
+		"eightball_6":           "Não conte com isso",
+		"eightball_7":           "Minhas fontes dizem que não >_<",
+		"eightball_8":           "Muito duvidoso",
+		"trivia_question":       "Trivia: %s (responda com !trivia <resposta>)",
+		"trivia_correct":        "%s acertou! A resposta era %s ^_^",
+		"trivia_timeout":        "Acabou o tempo! A resposta era %s",
+		"trivia_none":           "Nenhuma pergunta agora, peça uma com !trivia",
+		"trivia_scores":         "Ranking da trivia: %s",
+		"trivia_no_scores":      "Ninguém pontuou na trivia ainda",
+		"rank":                  "%s: nível %d, %d XP, #%d (%d mensagens, %s na sala)",
+		"rank_none":             "%s ainda não tem XP",
+		"leaderboard":           "Ranking: %s",
+		"leaderboard_empty":     "Ninguém tem XP ainda",
+		"wishlist":              "Topo da wishlist de %s:",
+		"wishlist_item":         "%d. %s - %s",
+		"wishlist_empty":        "A wishlist de %s está vazia ou é privada",
+		"usage_wishlist":        "Uso: !wishlist [usuário] | add <produto> | remove <produto>",
+		"wishlist_added":        "Adicionei na minha wishlist: %s ^_^",
+		"wishlist_removed":      "Tirei o produto %s da minha wishlist",
+		"status":                "Versão %s | online há %s | IMQ %s há %s, última mensagem há %s | sala %s, %d usuários | Gemini p50 %s, p95 %s | heap %d MB, %d goroutines | humor %s",
+		"usage_audit":           "Uso: !audit [quantidade]",
+		"audit_empty":           "Nenhum comando foi executado ainda",
+		"audit_entry":           "%s %s: %s (%s)",
+		"notifications_none":    "Nenhuma notificação não lida",
+		"notifications_summary": "%d notificações não lidas: %d pedidos de amizade, %d presentes, %d mensagens",
+		"notification_entry":    "- %s",
+		"usage_whatiswearing":   "Uso: !whatiswearing <usuário>",
+		"not_in_room":           "%s não está na sala",
+		"look_empty":            "Não consigo ver o que %s está usando",
+		"look":                  "%s está usando %d itens:",
+		"look_item":             "%s: %s",
+		"lurk_on":               "Vou só ficar olhando quietinha agora, me chama se precisar ^_^",
+		"lurk_off":              "Voltei a conversar com todo mundo! uwu",
+		"usage_summarize":       "Uso: !summarize [minutos, até %d] [whisper]",
+		"summary_empty":         "Ninguém falou nada nos últimos %d minutos >w<",
+		"refusal":               "Ahh, prefiro não falar disso >///< vamos falar de outra coisa?",
+		"greeting":              "Bem-vindo(a), %s! ^_^",
+		"usage_gift":            "Uso: !gift <produto> [usuário]",
+		"usage_buy":             "Uso: !buy <produto>",
+		"usage_confirm":         "Uso: !confirm <n>",
+		"confirm_unknown":       "Nenhum gasto #%d esperando confirmação",
+		"spend_disabled":        "Gastar créditos está desativado",
+		"spend_budget":          "Já gastei todos os créditos que posso hoje >w<",
+		"spend_recipient_limit": "%s já ganhou todos os presentes que posso dar hoje",
+		"spend_failed":          "Não consegui gastar os créditos, desculpa",
+		"spend_pending":         "São %d créditos, senpai precisa confirmar (#%d)",
+		"gift_sent":             "Presenteei %s para %s ^_^",
+		"purchase_done":         "Comprei %s ^_^",
+		"spending_today":        "Créditos gastos hoje: %d/%d",
+		"sleeping":              "Indo dormir, zzz... (。-ω-)",
+		"awake":                 "Acordei! ^_^",
+		"usage_tryon":           "Uso: !tryon <produto> | off",
+		"tryon":                 "Provando %s por %d segundos, o que acham? ^_^ %s",
+		"tryon_failed":          "Não consegui provar isso >.<",
+		"badges_progress":       "Tenho %d insígnias, nível de insígnias %d",
+		"badges":                "%s tem %d insígnias: %s",
+		"badges_none":           "%s não tem insígnias ou elas são privadas",
+		"badge_earned":          "Acabei de ganhar a insígnia %s! ^_^",
+		"usage_post":            "Uso: !post <texto>",
+		"feed_done":             "Postei no meu feed ^_^",
+		"feed_posted":           "%s postou no feed: %s",
+		"greeter_not":           "Eu não sou greeter oficial (ainda)",
+		"greeter_score":         "Minha pontuação de greeter é %d",
+		"greeter_score_change":  "Minha pontuação de greeter é %d (%+d desde %s)",
+		"greeter_rooms_none":    "Nenhuma sala está pedindo greeters agora",
+		"greeter_room":          "%d. %s (%d/%d)",
+		"usage_translate":       "Uso: !translate <idioma> <texto>",
+		"translation":           "[%s: %s]",
+		"usage_history":         "Uso: !history export <usuário>",
+		"export_done":           "Exportado para %s",
+		"export_failed":         "A exportação falhou, veja os logs",
+		"usage_forget":          "Diga !forget me e eu apago tudo o que guardei sobre você",
+		"forgotten":             "Pronto, esqueci tudo o que tinha guardado sobre você",
+		"forget_failed":         "Não consegui apagar seus dados, tente de novo mais tarde",
+		"arg_unknown_flag":      "Opção desconhecida --%s.",
+		"arg_missing":           "Falta <%s>.",
+		"arg_not_number":        "<%s> precisa ser um número, não %q.",
+		"arg_too_many":          "Argumentos demais.",
+		"arg_unknown_user":      "Não tem ninguém chamado %s na sala.",
+		"arg_ambiguous_user":    "Tem mais de uma pessoa chamada %s aqui, use o ID.",
+		"usage_stats":           "Uso: !stats [today|week]",
+		"stats_none":            "Ainda não há atividade registrada nesta sala nesse período",
+		"stats_today":           "Hoje: %d mensagens de %d pessoas, até %d na sala. Horários mais movimentados (UTC): %s",
+		"stats_week":            "Esta semana: %d mensagens de %d pessoas, até %d na sala. Horários mais movimentados (UTC): %s",
+		"look_image":            "Foto do look: %s",
+		"apology_1":             "Ops, deu branco por um segundo >_< pode repetir?",
+		"apology_2":             "Desculpa, fiquei meio tonta @_@ tenta de novo daqui a pouco?",
+		"apology_3":             "Ahh, deu algo errado aqui, desculpa! ;w;",
+		"apology_4":             "Hmm, perdi o fio da meada >.< me dá um momento",
+		"apology_5":             "Eep, meu cérebro travou T_T me pergunta de novo mais tarde?",
+		"roominfo":              "%s: %d de %d lugares ocupados. Chegaram por último: %s",
+		"roominfo_uncapped":     "%s: %d na sala. Chegaram por último: %s",
+		"room_full_alert":       "%s está cheia, os %d lugares estão ocupados",
+		"room_empty_alert":      "Todo mundo saiu de %s, agora sou só eu",
+		"usage_follow":          "Uso: !follow <usuário> ou !unfollow <usuário>",
+		"followed":              "Agora sigo %s, te aviso quando aparecer",
+		"unfollowed":            "Parei de seguir %s",
+		"buddy_online":          "%s acabou de ficar online",
+		"buddy_arrived":         "%s acabou de entrar na sala",
+		"catalog_new":           "Novidade na loja: %s de %s por %d créditos %s",
+		"catalog_sale":          "Em promoção: %s de %s, %d%% de desconto %s",
+		"heartbeat":             "Ainda aqui ^^ Ligada há %s, em %s, IMQ %s. Desde o último aviso: %d salas, %d mensagens tratadas, %d falhas",
+		"intent_greeting_1":     "oiii~ ^^",
+		"intent_greeting_2":     "eai! bem-vindo :3",
+		"intent_greeting_3":     "olá! <3",
+		"intent_greeting_4":     "oi oi! tudo bem?",
+		"intent_smalltalk_1":    "hehe",
+		"intent_smalltalk_2":    "né?? kkkk",
+		"intent_smalltalk_3":    "kkk verdade",
+		"intent_smalltalk_4":    "^^",
+		"intent_abuse_1":        "grosso... vou fingir que não ouvi -_-",
+		"intent_abuse_2":        "nossa, seja legal por favor",
+		"intent_abuse_3":        "isso não foi nada gentil >:(",
+		"usage_me":              "Diga !me para ver seu perfil, !me set <nickname|pronouns|language|noping|noai> <valor> para mudá-lo ou !me clear <campo> para limpar um campo. noping e noai aceitam on ou off",
+		"me_profile":            "Apelido: %s, pronomes: %s, idioma: %s, sem menções: %s, sem IA: %s",
+		"me_updated":            "Certo, atualizei seu campo %s",
+		"me_on":                 "ligado",
+		"me_off":                "desligado",
+		"usage_config":          "Uso: !config [get [chave]], !config set <chave> <valor> ou !config reset <chave>",
+		"config_unknown":        "Não existe a configuração %s",
+		"config_invalid":        "Valor inválido para %s, esperado %s",
+		"config_values":         "Configurações: %s",
+		"config_set":            "Pronto, %s agora é %s",
+		"usage_badges":          "Uso: !badges [ID do usuário]",
+	},
+	Spanish: {
+		"help":                  "Comandos: !uptime, !sit <asiento>, !seats, !dance, !wave, !hug <usuario>, !triggers, !temp [0-2], !pause, !sleep, !wake, !ask <pregunta>, !summarize [min], !usage [usuario], !ignore <usuario>, !unignore <usuario>, !music on|off|stations, !lang pt|en|es, !lurk, !unlurk, !roll [2d6], !8ball <pregunta>, !trivia [respuesta|scores], !rank [usuario], !leaderboard, !wishlist [usuario], !gift <producto> [usuario], !buy <producto>, !confirm <n>, !spending, !whatiswearing <usuario>, !tryon <producto>, !badges [usuario], !post <texto>, !snapshot [título], !greeter [rooms], !translate <idioma> <texto>, !history export <usuario>, !forget me, !me [set|clear <field>], !stats [today|week], !roominfo, !follow <usuario>, !unfollow <usuario>, !status, !audit [n], !notifications, !config [get|set|reset], !quit",
+		"uptime":                "En línea hace: %s",
+		"daily_roulette":        "Ruleta diaria: %s (%d %s)",
+		"usage_sit":             "Uso: !sit <asiento>",
+		"unknown_seat":          "Asiento desconocido: %s",
+		"seats":                 "Asientos ocupados: %d; predefinidos: %s",
+		"usage_hug":             "Uso: !hug <usuario>",
+		"hugs":                  "abraza a %s uwu",
+		"triggers":              "Triggers: %s",
+		"cant_action":           "No puedo hacer %s con esta ropa >w<",
+		"temperature":           "Temperatura: %s",
+		"temperature_default":   "predeterminada",
+		"usage_temp":            "Uso: !temp <0-2>",
+		"temperature_set":       "Temperatura ajustada a %v",
+		"usage_ignore":          "Uso: !ignore <usuario>",
+		"never_ignore":          "Nunca podría ignorarte, senpai >w<",
+		"ignoring":              "Ignorando a %s",
+		"usage_unignore":        "Uso: !unignore <usuario>",
+		"not_ignored":           "%s no está ignorado",
+		"unignored":             "Ya no ignoro a %s",
+		"usage_ask":             "Uso: !ask <pregunta>",
+		"ask_empty":             "Todavía no recuerdo nada de esta sala >w<",
+		"usage_music":           "Uso: !music on [estación] | off | stations",
+		"unknown_station":       "Estación desconocida: %s",
+		"music_on":              "Música encendida ^_^",
+		"music_off":             "Música apagada",
+		"no_stations":           "No hay estaciones configuradas, !music on pone la música de la sala",
+		"stations":              "Estaciones: %s",
+		"usage_user":            "Uso de %s hoy: %d pedidos, %d tokens de prompt + %d de respuesta",
+		"usage_today":           "Uso hoy: %d pedidos, %d tokens de prompt + %d de respuesta (límite: %s)",
+		"budget_unlimited":      "sin límite",
+		"budget_spent":          "Se me acabaron las palabras por hoy, hasta mañana >w<",
+		"usage_lang":            "Uso: !lang pt|en|es",
+		"lang_set":              "Idioma cambiado a español",
+		"usage_roll":            "Uso: !roll [2d6]",
+		"roll":                  "%s tiró %s: %s = %d",
+		"usage_8ball":           "Uso: !8ball <pregunta>",
+		"eightball_1":           "Es seguro ^_^",
+		"eightball_2":           "¡Sin duda!",
+		"eightball_3":           "Muy probablemente uwu",
+		"eightball_4":           "Pregunta más tarde, tengo sueño >w<",
+		"eightball_5":           "Mejor no te lo digo ahora...",
+		"eightball_6":           "No cuentes con ello",
+		"eightball_7":           "Mis fuentes dicen que no >_<",
+		"eightball_8":           "Muy dudoso",
+		"trivia_question":       "Trivia: %s (responde con !trivia <respuesta>)",
+		"trivia_correct":        "¡%s acertó! La respuesta era %s ^_^",
+		"trivia_timeout":        "¡Se acabó el tiempo! La respuesta era %s",
+		"trivia_none":           "No hay pregunta ahora, pide una con !trivia",
+		"trivia_scores":         "Ranking de trivia: %s",
+		"trivia_no_scores":      "Nadie ha puntuado en trivia todavía",
+		"rank":                  "%s: nivel %d, %d XP, #%d (%d mensajes, %s en la sala)",
+		"rank_none":             "%s todavía no tiene XP",
+		"leaderboard":           "Ranking: %s",
+		"leaderboard_empty":     "Nadie tiene XP todavía",
+		"wishlist":              "Lo primero de la wishlist de %s:",
+		"wishlist_item":         "%d. %s - %s",
+		"wishlist_empty":        "La wishlist de %s está vacía o es privada",
+		"usage_wishlist":        "Uso: !wishlist [usuario] | add <producto> | remove <producto>",
+		"wishlist_added":        "Añadido a mi wishlist: %s ^_^",
+		"wishlist_removed":      "Quité el producto %s de mi wishlist",
+		"status":                "Versión %s | en línea hace %s | IMQ %s hace %s, último mensaje hace %s | sala %s, %d usuarios | Gemini p50 %s, p95 %s | heap %d MB, %d goroutines | humor %s",
+		"usage_audit":           "Uso: !audit [cantidad]",
+		"audit_empty":           "Todavía no se ejecutó ningún comando",
+		"audit_entry":           "%s %s: %s (%s)",
+		"notifications_none":    "No hay notificaciones sin leer",
+		"notifications_summary": "%d notificaciones sin leer: %d solicitudes de amistad, %d regalos, %d mensajes",
+		"notification_entry":    "- %s",
+		"usage_whatiswearing":   "Uso: !whatiswearing <usuario>",
+		"not_in_room":           "%s no está en la sala",
+		"look_empty":            "No puedo ver lo que lleva %s",
+		"look":                  "%s lleva %d artículos:",
+		"look_item":             "%s: %s",
+		"lurk_on":               "Ahora solo miraré en silencio, llámame si me necesitas ^_^",
+		"lurk_off":              "¡Volví a charlar con todos! uwu",
+		"usage_summarize":       "Uso: !summarize [minutos, hasta %d] [whisper]",
+		"summary_empty":         "Nadie dijo nada en los últimos %d minutos >w<",
+		"refusal":               "Ay, prefiero no hablar de eso >///< ¿hablamos de otra cosa?",
+		"greeting":              "¡Bienvenido(a), %s! ^_^",
+		"usage_gift":            "Uso: !gift <producto> [usuario]",
+		"usage_buy":             "Uso: !buy <producto>",
+		"usage_confirm":         "Uso: !confirm <n>",
+		"confirm_unknown":       "Ningún gasto #%d espera confirmación",
+		"spend_disabled":        "Gastar créditos está desactivado",
+		"spend_budget":          "Ya gasté todos los créditos que puedo hoy >w<",
+		"spend_recipient_limit": "%s ya recibió todos los regalos que puedo dar hoy",
+		"spend_failed":          "No pude gastar los créditos, lo siento",
+		"spend_pending":         "Son %d créditos, senpai tiene que confirmarlo (#%d)",
+		"gift_sent":             "Regalé %s a %s ^_^",
+		"purchase_done":         "Compré %s ^_^",
+		"spending_today":        "Créditos gastados hoy: %d/%d",
+		"sleeping":              "Me voy a dormir, zzz... (。-ω-)",
+		"awake":                 "¡Desperté! ^_^",
+		"usage_tryon":           "Uso: !tryon <producto> | off",
+		"tryon":                 "Probándome %s por %d segundos, ¿qué les parece? ^_^ %s",
+		"tryon_failed":          "No pude probarme eso >.<",
+		"badges_progress":       "Tengo %d insignias, nivel de insignias %d",
+		"badges":                "%s tiene %d insignias: %s",
+		"badges_none":           "%s no tiene insignias o son privadas",
+		"badge_earned":          "¡Acabo de ganar la insignia %s! ^_^",
+		"usage_post":            "Uso: !post <texto>",
+		"feed_done":             "Publiqué en mi feed ^_^",
+		"feed_posted":           "%s publicó en su feed: %s",
+		"greeter_not":           "No soy greeter oficial (todavía)",
+		"greeter_score":         "Mi puntuación de greeter es %d",
+		"greeter_score_change":  "Mi puntuación de greeter es %d (%+d desde %s)",
+		"greeter_rooms_none":    "Ninguna sala está pidiendo greeters ahora",
+		"greeter_room":          "%d. %s (%d/%d)",
+		"usage_translate":       "Uso: !translate <idioma> <texto>",
+		"translation":           "[%s: %s]",
+		"usage_history":         "Uso: !history export <usuario>",
+		"export_done":           "Exportado a %s",
+		"export_failed":         "La exportación falló, revisa los logs",
+		"usage_forget":          "Di !forget me y borro todo lo que guardé sobre ti",
+		"forgotten":             "Listo, olvidé todo lo que tenía guardado sobre ti",
+		"forget_failed":         "No pude borrar tus datos, inténtalo de nuevo más tarde",
+		"arg_unknown_flag":      "Opción desconocida --%s.",
+		"arg_missing":           "Falta <%s>.",
+		"arg_not_number":        "<%s> debe ser un número, no %q.",
+		"arg_too_many":          "Demasiados argumentos.",
+		"arg_unknown_user":      "No hay nadie llamado %s en la sala.",
+		"arg_ambiguous_user":    "Hay más de una persona llamada %s aquí, usa su ID.",
+		"usage_stats":           "Uso: !stats [today|week]",
+		"stats_none":            "Todavía no hay actividad registrada en esta sala en ese período",
+		"stats_today":           "Hoy: %d mensajes de %d personas, hasta %d en la sala. Horas más activas (UTC): %s",
+		"stats_week":            "Esta semana: %d mensajes de %d personas, hasta %d en la sala. Horas más activas (UTC): %s",
+		"look_image":            "Foto del look: %s",
+		"apology_1":             "Ups, me quedé en blanco un segundo >_< ¿lo repites?",
+		"apology_2":             "Perdón, me mareé un poco @_@ ¿lo intentas en un ratito?",
+		"apology_3":             "Ahh, algo salió mal de mi lado, ¡perdón! ;w;",
+		"apology_4":             "Hmm, perdí el hilo >.< dame un momento",
+		"apology_5":             "Eep, mi cerebro se trabó T_T ¿me preguntas más tarde?",
+		"roominfo":              "%s: %d de %d lugares ocupados. Llegaron últimos: %s",
+		"roominfo_uncapped":     "%s: %d en la sala. Llegaron últimos: %s",
+		"room_full_alert":       "%s está llena, los %d lugares están ocupados",
+		"room_empty_alert":      "Todos se fueron de %s, ahora estoy sola",
+		"usage_follow":          "Uso: !follow <usuario> o !unfollow <usuario>",
+		"followed":              "Ahora sigo a %s, te aviso cuando aparezca",
+		"unfollowed":            "Dejé de seguir a %s",
+		"buddy_online":          "%s acaba de conectarse",
+		"buddy_arrived":         "%s acaba de entrar a la sala",
+		"catalog_new":           "Nuevo en la tienda: %s de %s por %d créditos %s",
+		"catalog_sale":          "En oferta: %s de %s, %d%% de descuento %s",
+		"heartbeat":             "Sigo aquí ^^ Encendida hace %s, en %s, IMQ %s. Desde el último aviso: %d salas, %d mensajes atendidos, %d fallos",
+		"intent_greeting_1":     "holiii~ ^^",
+		"intent_greeting_2":     "¡hey! bienvenido :3",
+		"intent_greeting_3":     "¡hola! <3",
+		"intent_greeting_4":     "¡hola hola! ¿qué tal?",
+		"intent_smalltalk_1":    "jeje",
+		"intent_smalltalk_2":    "¿¿verdad?? xD",
+		"intent_smalltalk_3":    "jaja igual",
+		"intent_smalltalk_4":    "^^",
+		"intent_abuse_1":        "grosero... haré como que no lo oí -_-",
+		"intent_abuse_2":        "vaya, sé amable por favor",
+		"intent_abuse_3":        "eso no fue nada amable >:(",
+		"usage_me":              "Di !me para ver tu perfil, !me set <nickname|pronouns|language|noping|noai> <valor> para cambiarlo o !me clear <campo> para borrar un campo. noping y noai aceptan on u off",
+		"me_profile":            "Apodo: %s, pronombres: %s, idioma: %s, sin menciones: %s, sin IA: %s",
+		"me_updated":            "Listo, actualicé tu campo %s",
+		"me_on":                 "activado",
+		"me_off":                "desactivado",
+		"usage_config":          "Uso: !config [get [clave]], !config set <clave> <valor> o !config reset <clave>",
+		"config_unknown":        "No existe la configuración %s",
+		"config_invalid":        "Valor inválido para %s, se esperaba %s",
+		"config_values":         "Configuración: %s",
+		"config_set":            "Listo, %s ahora es %s",
+		"usage_badges":          "Uso: !badges [ID de usuario]",
+	},
+}
+
+// Supported reports whether lang has a catalog
+func Supported(lang string) bool {
+	_, ok := catalog[lang]
+	return ok
+}
+
+// Name returns the name of the language for the persona prompt
+func Name(lang string) string {
+	return names[lang]
+}
+
+// T returns the string of key in lang formatted with args. Missing keys fall
+// back to English, and to the key itself as a last resort.
+func T(lang, key string, args ...any) string {
+	format, ok := catalog[lang][key]
+	if !ok {
+		format, ok = catalog[fallback][key]
+	}
+	if !ok {
+		return key
+	}
+
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
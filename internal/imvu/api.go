@@ -1,24 +1,31 @@
 package imvu
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync/atomic"
 )
 
 // API represents the API API client
 type API struct {
 	client *HTTPClient
-	ws     *WebSocketClient
+	// ws is replaced when the session restarts, while other goroutines send
+	ws     atomic.Pointer[WebSocketClient]
 	opID   *OperationID
+	router router
+	// version is the APIVersion detected at login
+	version atomic.Int32
 }
 
 // New creates a new IMVU API client
-func NewAPI(opID *OperationID) (*API, error) {
-	client, err := NewClient()
+func NewAPI(opID *OperationID, options ...ClientOption) (*API, error) {
+	client, err := NewClient(options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
 	}
@@ -29,15 +36,39 @@ func NewAPI(opID *OperationID) (*API, error) {
 	}, nil
 }
 
+// Authenticate logs in. When IMVU asks for a captcha and a solver is set, the
+// login is retried once with the solver's answer.
 func (i *API) Authenticate(username, password string) error {
+	err := i.authenticate(username, password, "")
+
+	var apiErr *APIError
+	if !errors.Is(err, ErrCaptchaRequired) || i.client.captchaSolver == nil || !errors.As(err, &apiErr) {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), captchaSolveTimeout)
+	defer cancel()
+
+	log.Printf("Login asked for a captcha, solving it")
+	token, solveErr := i.client.captchaSolver.Solve(ctx, captchaChallenge(apiErr))
+	if solveErr != nil {
+		return fmt.Errorf("failed to solve captcha: %w (%w)", solveErr, err)
+	}
+	return i.authenticate(username, password, token)
+}
+
+func (i *API) authenticate(username, password, captchaToken string) error {
 	loginPayload := map[string]any{
 		"username":               username,
 		"password":               password,
 		"gdpr_cookie_acceptance": false,
 	}
+	if captchaToken != "" {
+		loginPayload["captcha_response"] = captchaToken
+	}
 
 	headers := map[string]string{
-		"Origin": "https://pt.secure.imvu.com",
+		"Origin": i.client.endpoints.SecureOrigin,
 	}
 
 	resp, err := i.client.Post("/login", loginPayload, headers)
@@ -47,8 +78,7 @@ func (i *API) Authenticate(username, password string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("login failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return fmt.Errorf("login failed: %w", readAPIError(resp))
 	}
 
 	var loginResponse map[string]any
@@ -59,58 +89,417 @@ func (i *API) Authenticate(username, password string) error {
 	return nil
 }
 
+// Logout ends the session of the login cookie
+func (i *API) Logout() error {
+	if _, err := DoResponse(i.client, http.MethodDelete, "/login/me", nil); err != nil {
+		return fmt.Errorf("logout request failed: %w", err)
+	}
+	return nil
+}
+
 func (i *API) Me() (*MeData, error) {
-	resp, err := i.client.Get("/login/me", nil)
+	res, err := DoResponse(i.client, http.MethodGet, "/login/me", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current user: %w", err)
 	}
+	me, err := ExtractEntity[MeData](res, res.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current user: %w", err)
+	}
+	if i.Version() == APINext {
+		// The user is only linked
+		entity, _ := res.Entity(res.ID)
+		me.User.ID = entity.Relations["user"]
+	}
+	return me, nil
+}
+
+func (i *API) GetUser(userID string) (*User, error) {
+	user, err := get[User](i.client, fmt.Sprintf("/user/user-%s", userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+func (i *API) JoinRoom(ownerID, chatroomID string) (*JoinResult, error) {
+	resp, err := i.client.Post(fmt.Sprintf("/chat/chat-%s-%s/participants", ownerID, chatroomID), map[string]string{}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enter chat: %w", err)
+	}
+
 	defer resp.Body.Close()
+	var chatResp EnterChatResponse
+	if err := ParseResponse(resp, &chatResp); err != nil {
+		return nil, fmt.Errorf("failed to parse chat response: %w", err)
+	}
+	if err := chatResp.ParseEnterChatResponse(); err != nil {
+		return nil, fmt.Errorf("failed to parse chat data: %w", err)
+	}
 
-	var res MeResponse
-	if err := ParseResponse(resp, &res); err != nil {
-		return nil, fmt.Errorf("failed to parse user response: %w", err)
+	participantsRef := chatResp.ID
+	if idx := strings.LastIndex(participantsRef, "/"); idx >= 0 {
+		participantsRef = participantsRef[:idx]
 	}
 
-	if err := res.ParseMe(); err != nil {
-		return nil, fmt.Errorf("failed to parse user data: %w", err)
+	return &JoinResult{
+		ParticipantID:   chatResp.ID,
+		ParticipantsRef: participantsRef,
+		SeatNumber:      chatResp.Participant.SeatNumber,
+		SeatFurniID:     chatResp.Participant.SeatFurniID,
+		AssetURL:        chatResp.Participant.AssetURL,
+		LookURL:         chatResp.Participant.LookURL,
+		User:            chatResp.User,
+	}, nil
+}
+
+func (i *API) GetParticipants(ownerID, chatroomID string) ([]Participant, error) {
+	path := fmt.Sprintf("/chat/chat-%s-%s/participants", ownerID, chatroomID)
+	return NewPaginator(i, "participants", path, func(res *BaseResponse, item string) (Participant, error) {
+		data, err := ExtractEntity[ChatParticipantData](res, item)
+		if err != nil {
+			return Participant{}, err
+		}
+		return Participant{UserID: participantUserID(item), ChatParticipantData: *data}, nil
+	}).Collect(0)
+}
+
+func (i *API) GetUserRooms(userID string) ([]RoomInfo, error) {
+	return NewPaginator(i, "rooms", fmt.Sprintf("/user/user-%s/rooms", userID), extractRoom).Collect(0)
+}
+
+// SearchRooms returns a paginator over the public rooms matching the keywords
+func (i *API) SearchRooms(keywords string) *Paginator[RoomInfo] {
+	path := "/room?" + url.Values{"keywords": {keywords}}.Encode()
+	return NewPaginator(i, "rooms", path, extractRoom)
+}
+
+// GetGreeterRooms returns a paginator over the rooms that asked for greeters
+func (i *API) GetGreeterRooms() *Paginator[RoomInfo] {
+	return NewPaginator(i, "greeter rooms", "/greeter/rooms", extractRoom)
+}
+
+// Greet records a greeter greeting of the user by greeterID
+func (i *API) Greet(greeterID, userID string) error {
+	_, err := DoResponse(i.client, http.MethodPost, fmt.Sprintf("/greeter/greeter-%s/greets", greeterID), map[string]string{
+		"id": i.entityID(fmt.Sprintf("/user/user-%s", userID)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to greet: %w", err)
 	}
+	return nil
+}
 
-	return res.Me, nil
+func extractRoom(res *BaseResponse, item string) (RoomInfo, error) {
+	data, err := ExtractEntity[RoomData](res, item)
+	if err != nil {
+		return RoomInfo{}, err
+	}
+	ownerID, chatroomID := roomIDsFromEntity(item)
+	return RoomInfo{OwnerID: ownerID, ChatroomID: chatroomID, RoomData: *data}, nil
 }
 
-func (i *API) GetUser(userID string) (*User, error) {
-	resp, err := i.client.Get(fmt.Sprintf("/user/user-%s", userID), nil)
+// GetFriends returns a paginator over the user's friends
+func (i *API) GetFriends(userID string) *Paginator[Friend] {
+	path := fmt.Sprintf("/user/user-%s/friends", userID)
+	return NewPaginator(i, "friends", path, func(res *BaseResponse, item string) (Friend, error) {
+		user, err := ExtractEntity[User](res, item)
+		if err != nil {
+			return Friend{}, err
+		}
+		return Friend{UserID: participantUserID(item), User: *user}, nil
+	})
+}
+
+// GetBadges returns a paginator over the badges on the user's profile
+func (i *API) GetBadges(userID string) *Paginator[Badge] {
+	path := fmt.Sprintf("/user/user-%s/badges", userID)
+	return NewPaginator(i, "badges", path, func(res *BaseResponse, item string) (Badge, error) {
+		badge, err := ExtractEntity[Badge](res, item)
+		if err != nil {
+			return Badge{}, err
+		}
+		fields := strings.Split(item, "/")
+		badge.ID = strings.TrimPrefix(fields[len(fields)-1], "badge-")
+		return *badge, nil
+	})
+}
+
+// GetInventory returns a paginator over the products the user owns
+func (i *API) GetInventory(userID string) *Paginator[ProductItem] {
+	path := fmt.Sprintf("/user/user-%s/inventory", userID)
+	return NewPaginator(i, "inventory", path, func(res *BaseResponse, item string) (ProductItem, error) {
+		inventoryItem := ProductItem{ProductID: productIDFromEntity(item)}
+		if product, err := ExtractEntity[Product](res, item); err == nil {
+			inventoryItem.Product = *product
+		}
+		return inventoryItem, nil
+	})
+}
+
+// GetLookProducts returns the IDs of the products of an avatar look. Look URLs
+// usually list them in their products parameter; otherwise the look entity is
+// fetched.
+func (i *API) GetLookProducts(lookURL string) ([]string, error) {
+	u, err := url.Parse(lookURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, fmt.Errorf("invalid look URL: %w", err)
+	}
+	if products := u.Query().Get("products"); products != "" {
+		return strings.FieldsFunc(products, func(r rune) bool {
+			return r == ',' || r == ' '
+		}), nil
+	}
+
+	path := strings.TrimPrefix(lookURL, i.client.endpoints.REST)
+	if path == lookURL {
+		path = u.RequestURI()
+	}
+	look, err := get[Look](i.client, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get look: %w", err)
 	}
 
-	var res UserResponse
-	if err := ParseResponse(resp, &res); err != nil {
-		return nil, err
+	productIDs := make([]string, 0, len(look.Products))
+	for _, id := range look.Products {
+		productIDs = append(productIDs, string(id))
 	}
+	return productIDs, nil
+}
+
+// GetNotifications returns up to limit of the user's latest notifications
+func (i *API) GetNotifications(userID string, limit int) ([]Notification, error) {
+	path := fmt.Sprintf("/user/user-%s/notifications", userID)
+	return NewPaginator(i, "notifications", path, func(res *BaseResponse, item string) (Notification, error) {
+		notification, err := ExtractEntity[Notification](res, item)
+		if err != nil {
+			return Notification{}, err
+		}
+		notification.ID = item
+		return *notification, nil
+	}).Collect(limit)
+}
 
-	if err := res.ParseUser(); err != nil {
-		return nil, fmt.Errorf("failed to parse user data: %w", err)
+// GetRoomInfo returns the metadata of a room. The occupancy is counted from
+// the chat's participant list and the moderators come from the room's
+// moderator collection; both are best effort.
+func (i *API) GetRoomInfo(ownerID, chatroomID string) (*RoomInfo, error) {
+	data, err := get[RoomData](i.client, fmt.Sprintf("/room/room-%s-%s", ownerID, chatroomID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get room: %w", err)
 	}
+	info := &RoomInfo{OwnerID: ownerID, ChatroomID: chatroomID, RoomData: *data}
 
-	return res.User, nil
+	if participants, err := i.GetParticipants(ownerID, chatroomID); err == nil {
+		info.Occupancy = len(participants)
+	} else {
+		log.Printf("Warning: using the reported occupancy of room %s-%s: %v", ownerID, chatroomID, err)
+	}
+
+	moderators, err := i.getRoomModerators(ownerID, chatroomID)
+	if err != nil {
+		log.Printf("Warning: no moderator list for room %s-%s: %v", ownerID, chatroomID, err)
+	}
+	info.Moderators = moderators
+
+	return info, nil
 }
 
-func (i *API) JoinRoom(ownerID, chatroomID string) error {
-	resp, err := i.client.Post(fmt.Sprintf("/chat/chat-%s-%s/participants", ownerID, chatroomID), map[string]string{}, nil)
+func (i *API) getRoomModerators(ownerID, chatroomID string) ([]string, error) {
+	path := fmt.Sprintf("/room/room-%s-%s/moderators", ownerID, chatroomID)
+	return NewPaginator(i, "moderators", path, func(_ *BaseResponse, item string) (string, error) {
+		return participantUserID(item), nil
+	}).Collect(0)
+}
+
+func (i *API) GetWallet(userID string) (*Wallet, error) {
+	wallet, err := get[Wallet](i.client, fmt.Sprintf("/wallet/wallet-%s", userID))
 	if err != nil {
-		return fmt.Errorf("failed to enter chat: %w", err)
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
 	}
+	return wallet, nil
+}
 
-	defer resp.Body.Close()
-	var chatResp EnterChatResponse
-	if err := ParseResponse(resp, &chatResp); err != nil {
-		return fmt.Errorf("failed to parse chat response: %w", err)
+func (i *API) GetRoulette(userID string) (*Roulette, error) {
+	roulette, err := get[Roulette](i.client, fmt.Sprintf("/roulette/roulette-%s", userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roulette: %w", err)
 	}
-	if err := chatResp.ParseEnterChatResponse(); err != nil {
-		return fmt.Errorf("failed to parse chat data: %w", err)
+	return roulette, nil
+}
+
+func (i *API) SpinRoulette(userID string) (*RoulettePrize, error) {
+	prize, err := Do[RoulettePrize](i.client, http.MethodPost, fmt.Sprintf("/roulette/roulette-%s/spin", userID), map[string]any{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to spin roulette: %w", err)
 	}
+	return prize, nil
+}
+
+func (i *API) GetProduct(productID string) (*Product, error) {
+	path := fmt.Sprintf("/product/product-%s", productID)
+	if i.Version() == APINext {
+		next, err := get[nextProduct](i.client, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get product: %w", err)
+		}
+		product := next.product()
+		return &product, nil
+	}
+
+	product, err := get[Product](i.client, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	return product, nil
+}
+
+// GetWishlist returns the products on the user's public wishlist, in the
+// order of the wishlist
+func (i *API) GetWishlist(userID string) ([]ProductItem, error) {
+	path := fmt.Sprintf("/user/user-%s/wishlist", userID)
+	return NewPaginator(i, "wishlist", path, func(res *BaseResponse, entityID string) (ProductItem, error) {
+		item := ProductItem{ProductID: productIDFromEntity(entityID)}
+		if product, err := ExtractEntity[Product](res, entityID); err == nil {
+			item.Product = *product
+		}
+		return item, nil
+	}).Collect(0)
+}
 
+// AddToWishlist adds the product to the user's wishlist
+func (i *API) AddToWishlist(userID, productID string) error {
+	_, err := DoResponse(i.client, http.MethodPost, fmt.Sprintf("/user/user-%s/wishlist", userID), map[string]string{
+		"id": i.entityID(fmt.Sprintf("/product/product-%s", productID)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add to wishlist: %w", err)
+	}
+	return nil
+}
+
+// RemoveFromWishlist removes the product from the user's wishlist
+func (i *API) RemoveFromWishlist(userID, productID string) error {
+	_, err := DoResponse(i.client, http.MethodDelete, fmt.Sprintf("/user/user-%s/wishlist/product-%s", userID, productID), nil)
+	if err != nil {
+		return fmt.Errorf("failed to remove from wishlist: %w", err)
+	}
+	return nil
+}
+
+// GetFeed returns a paginator over the user's feed posts, newest first
+func (i *API) GetFeed(userID string) *Paginator[FeedPost] {
+	path := fmt.Sprintf("/user/user-%s/feed", userID)
+	return NewPaginator(i, "feed", path, func(res *BaseResponse, item string) (FeedPost, error) {
+		post, err := ExtractEntity[FeedPost](res, item)
+		if err != nil {
+			return FeedPost{}, err
+		}
+		fields := strings.Split(item, "/")
+		post.ID = strings.TrimPrefix(fields[len(fields)-1], "feed_element-")
+		return *post, nil
+	})
+}
+
+// CreatePost publishes a post on the user's feed. The image is optional.
+func (i *API) CreatePost(userID, message, imageURL string) error {
+	body := map[string]string{"message": message}
+	if imageURL != "" {
+		body["image_url"] = imageURL
+	}
+	_, err := DoResponse(i.client, http.MethodPost, fmt.Sprintf("/user/user-%s/feed", userID), body)
+	if err != nil {
+		return fmt.Errorf("failed to create post: %w", err)
+	}
+	return nil
+}
+
+// LikePost likes the feed post as the user
+func (i *API) LikePost(userID, postID string) error {
+	_, err := DoResponse(i.client, http.MethodPost, fmt.Sprintf("/feed_element/feed_element-%s/likes", postID), map[string]string{
+		"id": i.entityID(fmt.Sprintf("/user/user-%s", userID)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to like post: %w", err)
+	}
+	return nil
+}
+
+// CommentPost comments on the feed post
+func (i *API) CommentPost(postID, message string) error {
+	_, err := DoResponse(i.client, http.MethodPost, fmt.Sprintf("/feed_element/feed_element-%s/comments", postID), map[string]string{
+		"message": message,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to comment on post: %w", err)
+	}
+	return nil
+}
+
+// GetFollowing returns a paginator over the users the user follows
+func (i *API) GetFollowing(userID string) *Paginator[Friend] {
+	path := fmt.Sprintf("/user/user-%s/following", userID)
+	return NewPaginator(i, "following", path, func(res *BaseResponse, item string) (Friend, error) {
+		user, err := ExtractEntity[User](res, item)
+		if err != nil {
+			return Friend{}, err
+		}
+		return Friend{UserID: participantUserID(item), User: *user}, nil
+	})
+}
+
+// FollowUser makes the user follow targetID
+func (i *API) FollowUser(userID, targetID string) error {
+	_, err := DoResponse(i.client, http.MethodPost, fmt.Sprintf("/user/user-%s/following", userID), map[string]string{
+		"id": i.entityID(fmt.Sprintf("/user/user-%s", targetID)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to follow user: %w", err)
+	}
+	return nil
+}
+
+// UnfollowUser makes the user stop following targetID
+func (i *API) UnfollowUser(userID, targetID string) error {
+	path := fmt.Sprintf("/user/user-%s/following/user-%s", userID, targetID)
+	if _, err := DoResponse(i.client, http.MethodDelete, path, nil); err != nil {
+		return fmt.Errorf("failed to unfollow user: %w", err)
+	}
+	return nil
+}
+
+// SendDirectMessage sends a private message from the user to recipientID,
+// outside of any room
+func (i *API) SendDirectMessage(userID, recipientID, message string) error {
+	_, err := DoResponse(i.client, http.MethodPost, fmt.Sprintf("/user/user-%s/conversations", userID), map[string]string{
+		"id":      i.entityID(fmt.Sprintf("/user/user-%s", recipientID)),
+		"message": message,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send direct message: %w", err)
+	}
+	return nil
+}
+
+// AcceptFriendRequest accepts the friend request the user received from
+// requesterID
+func (i *API) AcceptFriendRequest(userID, requesterID string) error {
+	_, err := DoResponse(i.client, http.MethodPost, fmt.Sprintf("/user/user-%s/friends", userID), map[string]string{
+		"id": i.entityID(fmt.Sprintf("/user/user-%s", requesterID)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to accept friend request: %w", err)
+	}
+	return nil
+}
+
+// DeclineFriendRequest declines the friend request the user received from
+// requesterID
+func (i *API) DeclineFriendRequest(userID, requesterID string) error {
+	path := fmt.Sprintf("/user/user-%s/inbound_friend_requests/user-%s", userID, requesterID)
+	if _, err := DoResponse(i.client, http.MethodDelete, path, nil); err != nil {
+		return fmt.Errorf("failed to decline friend request: %w", err)
+	}
 	return nil
 }
 
@@ -125,25 +514,18 @@ func (i *API) ChangeAvalability(userID string) error {
 
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to change availability with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return fmt.Errorf("failed to change availability: %w", readAPIError(resp))
 	}
 
 	return nil
 }
 
 func (i *API) GetChat(roomID, chatID string) (*BaseResponse, error) {
-	resp, err := i.client.Get(fmt.Sprintf("/chat/chat-%s-%s", roomID, chatID), nil)
+	chat, err := DoResponse(i.client, http.MethodGet, fmt.Sprintf("/chat/chat-%s-%s", roomID, chatID), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get chat: %w", err)
 	}
-
-	var chatResp BaseResponse
-	if err := ParseResponse(resp, &chatResp); err != nil {
-		return nil, fmt.Errorf("failed to parse chat response: %w", err)
-	}
-
-	return &chatResp, nil
+	return chat, nil
 }
 
 func (i *API) GetRoomChatQueue(roomID, roomChatID string) (string, error) {
@@ -152,7 +534,7 @@ func (i *API) GetRoomChatQueue(roomID, roomChatID string) (string, error) {
 		return "", fmt.Errorf("failed to get chat: %w", err)
 	}
 
-	entityID := fmt.Sprintf("https://api.imvu.com/chat/chat-%s-%s", roomID, roomChatID)
+	entityID := i.entityID(fmt.Sprintf("/chat/chat-%s-%s", roomID, roomChatID))
 
 	type ChatData struct {
 		ImqQueue string `json:"imq_queue"`
@@ -174,19 +556,20 @@ func (i *API) LeaveRoom(roomID, chatID, userID string) error {
 
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusNoContent {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to leave chat with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return fmt.Errorf("failed to leave chat: %w", readAPIError(resp))
 	}
 
 	return nil
 }
 
-func (i *API) ConnectMsgStream(userID string, ch chan ChatMessagePayload) error {
+// ConnectMsgStream connects to IMQ. Deliveries are routed to the handlers
+// registered with Subscribe and state changes are delivered on States.
+func (i *API) ConnectMsgStream(userID string) error {
 	headers := http.Header{}
 	headers.Set("User-Agent", i.client.userAgent)
-	headers.Set("Origin", "https://www.imvu.com")
+	headers.Set("Origin", i.client.endpoints.Origin)
 
-	cookies, err := i.client.GetCookies("https://wss-imq.imvu.com")
+	cookies, err := i.client.GetCookies(i.client.endpoints.cookieURL())
 	if err != nil {
 		return fmt.Errorf("failed to get cookies: %w", err)
 	}
@@ -207,9 +590,8 @@ func (i *API) ConnectMsgStream(userID string, ch chan ChatMessagePayload) error
 		log.Println("Warning: osCsid cookie not found, using empty value")
 	}
 
-	wsURL := "wss://wss-imq.imvu.com/streaming/imvu_pre"
 	config := Config{
-		URL:       wsURL,
+		URL:       i.client.endpoints.IMQ,
 		Headers:   headers,
 		UserID:    userID,
 		SessionID: osCsid,
@@ -218,99 +600,107 @@ func (i *API) ConnectMsgStream(userID string, ch chan ChatMessagePayload) error
 			"app":           "imvu_next",
 			"platform_type": "big",
 		},
-		OnMessage: func(message map[string]any) {
-			record, ok := message["record"].(string)
-			if !ok {
-				return
-			}
-
-			if record == "msg_g2c_send_message" {
-				// Re-marshal the message to get it into a byte slice
-				payloadBytes, err := json.Marshal(message)
-				if err != nil {
-					log.Printf("Failed to re-marshal send message payload: %v", err)
-					return
-				}
-
-				var payload WebSocketSendMessageMessage
-				if err := json.Unmarshal(payloadBytes, &payload); err != nil {
-					log.Printf("Failed to parse send message payload: %v", err)
-					return
-				}
-
-				// Now we need to convert payload.Message to ChatMessagePayload
-				chatMessageBytes, err := json.Marshal(payload.Message)
-				if err != nil {
-					log.Printf("Failed to marshal inner chat message: %v", err)
-					return
-				}
-
-				var chatMessage ChatMessagePayload
-				if err := json.Unmarshal(chatMessageBytes, &chatMessage); err != nil {
-					log.Printf("Failed to unmarshal inner chat message: %v", err)
-					return
-				}
-
-				ch <- chatMessage
-			}
-		},
+		Dialer:         i.client.dialer,
+		MaxMessageSize: i.client.imqMaxMessage,
+		OnMessage:      i.router.dispatch,
 	}
 
-	i.ws = NewWebSocketClient(config)
-	i.ws.Connect()
+	ws := NewWebSocketClient(config)
+	i.ws.Store(ws)
+	ws.Connect()
 
 	return nil
 }
 
+// States returns the state changes of the IMQ connection, or nil before
+// ConnectMsgStream
+func (i *API) States() <-chan StateChange {
+	ws := i.ws.Load()
+	if ws == nil {
+		return nil
+	}
+	return ws.States()
+}
+
 func (i *API) CloseWebSocket() {
-	if i.ws != nil {
-		i.ws.Close()
+	if ws := i.ws.Load(); ws != nil {
+		ws.Close()
 	}
 }
 
-func (i *API) SendWebSocketMessage(record string, payload map[string]any) {
-	if i.ws != nil {
-		i.ws.Send(record, payload)
+func (i *API) SendWebSocketMessage(record string, payload map[string]any) error {
+	ws := i.ws.Load()
+	if ws == nil {
+		return errNotConnected
 	}
+	return ws.Send(record, payload)
 }
 
 func (i *API) SubscribeToQueue(queue string, opID int) {
-	if i.ws == nil {
-		log.Println("WebSocket not connected")
-		return
-	}
-	subscription := map[string]any{
-		"record": "subscription",
-		"name":   queue,
-		"op_id":  opID,
+	if err := i.sendSubscribe([]any{subscriptionEntry(queue, opID)}); err != nil {
+		log.Printf("Failed to subscribe to %s: %v", queue, err)
 	}
+}
+
+// sendSubscribe sends a single subscribe frame for all the entries
+func (i *API) sendSubscribe(entries []any) error {
 	payload := map[string]any{
-		"queues_with_results": []any{subscription},
+		"queues_with_results": entries,
 	}
-	i.SendWebSocketMessage("msg_c2g_subscribe", payload)
+	return i.SendWebSocketMessage("msg_c2g_subscribe", payload)
 }
 
-func (i *API) SendChatMessage(queue, mount string, payload ChatMessagePayload) {
-	if i.ws == nil {
-		log.Println("WebSocket not connected")
-		return
+func subscriptionEntry(queue string, opID int) map[string]any {
+	return map[string]any{
+		"record": "subscription",
+		"name":   queue,
+		"op_id":  opID,
 	}
+}
 
+// SendChatMessage sends a message on a chat queue with the op_id, which the
+// echo of the message carries back
+func (i *API) SendChatMessage(queue, mount string, opID int, payload ChatMessagePayload) error {
 	message := map[string]any{
 		"queue":   queue,
 		"mount":   mount,
 		"message": payload,
-		"op_id":   i.opID.GetNew(),
+		"op_id":   opID,
 	}
 
-	i.SendWebSocketMessage("msg_c2g_send_message", message)
+	return i.SendWebSocketMessage("msg_c2g_send_message", message)
 }
 
 func (i *API) IsWebSocketConnected() bool {
-	if i.ws == nil {
+	ws := i.ws.Load()
+	if ws == nil {
 		return false
 	}
-	return i.ws.GetState() == StateAuthenticated
+	return ws.GetState() == StateAuthenticated
+}
+
+// entityID returns the ID of the entity at path, such as /user/user-123,
+// which is its URL on the REST API
+func (i *API) entityID(path string) string {
+	return i.client.endpoints.REST + path
+}
+
+// roomIDsFromEntity extracts the owner and chatroom IDs from a room entity ID
+// such as https://api.imvu.com/room/room-123-45
+func roomIDsFromEntity(entityID string) (string, string) {
+	fields := strings.Split(entityID, "/")
+	ids := strings.Split(strings.TrimPrefix(fields[len(fields)-1], "room-"), "-")
+	if len(ids) < 2 {
+		return "", ""
+	}
+	return ids[0], ids[1]
+}
+
+// productIDFromEntity extracts the product ID from an entity ID such as
+// https://api.imvu.com/product/product-123
+func productIDFromEntity(entityID string) string {
+	fields := strings.Split(entityID, "/")
+	return strings.TrimPrefix(fields[len(fields)-1], "product-")
 }
 
 func (i *API) GetCookies(urlStr string) ([]*http.Cookie, error) {
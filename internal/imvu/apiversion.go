@@ -0,0 +1,93 @@
+package imvu
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// APIVersion is the shape of the REST API responses. Accounts moved to IMVU
+// Next get some entities in a newer shape than classic accounts; the parsing
+// of those entities branches on the version detected at login, so callers
+// always get the same types.
+type APIVersion int32
+
+const (
+	// APIUnknown is the version before DetectVersion runs; responses are
+	// parsed as classic
+	APIUnknown APIVersion = iota
+	APIClassic
+	APINext
+)
+
+func (v APIVersion) String() string {
+	switch v {
+	case APIClassic:
+		return "classic"
+	case APINext:
+		return "next"
+	}
+	return "unknown"
+}
+
+// Version returns the API version detected at login
+func (i *API) Version() APIVersion {
+	return APIVersion(i.version.Load())
+}
+
+// APIVersion returns the version of the REST API detected at login
+func (i *IMVU) APIVersion() APIVersion {
+	return i.api.Version()
+}
+
+// DetectVersion tells the API versions apart by the login entity: classic
+// embeds the user in its data, Next only links it as the "user" relation.
+// The result is kept for the parsing of later responses.
+func (i *API) DetectVersion() (APIVersion, error) {
+	res, err := DoResponse(i.client, "GET", "/login/me", nil)
+	if err != nil {
+		return APIUnknown, fmt.Errorf("failed to get current user: %w", err)
+	}
+	entity, ok := res.Entity(res.ID)
+	if !ok {
+		return APIUnknown, fmt.Errorf("entity not found: %s", res.ID)
+	}
+
+	var me MeData
+	if err := json.Unmarshal(entity.Data, &me); err != nil {
+		return APIUnknown, fmt.Errorf("failed to parse entity data: %w", err)
+	}
+
+	version := APIClassic
+	if me.User.ID == "" && entity.Relations["user"] != "" {
+		version = APINext
+	}
+	i.version.Store(int32(version))
+	return version, nil
+}
+
+// nextProduct is a product entity in the Next shape
+type nextProduct struct {
+	Name        string   `json:"name"`
+	CreatorName string   `json:"creator_name"`
+	CreatorID   int64    `json:"creator_id"`
+	Rating      string   `json:"rating"`
+	URL         string   `json:"url"`
+	Image       string   `json:"image"`
+	Price       int64    `json:"price"`
+	Categories  []string `json:"categories"`
+	Triggers    []string `json:"triggers"`
+}
+
+func (p nextProduct) product() Product {
+	return Product{
+		ProductName:  p.Name,
+		CreatorName:  p.CreatorName,
+		CreatorID:    p.CreatorID,
+		Rating:       p.Rating,
+		ProductPage:  p.URL,
+		ProductImage: p.Image,
+		Price:        p.Price,
+		Categories:   p.Categories,
+		Triggers:     p.Triggers,
+	}
+}
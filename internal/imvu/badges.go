@@ -0,0 +1,109 @@
+package imvu
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"giiny/internal/events"
+)
+
+const (
+	// badgePollInterval is how often the bot's badges are checked when no
+	// notification arrives
+	badgePollInterval = 30 * time.Minute
+	// badgeProgressTTL is how long the bot's badge progress is cached. New
+	// badges drop it earlier.
+	badgeProgressTTL = 10 * time.Minute
+)
+
+// BadgeProgress is how far the bot got collecting badges
+type BadgeProgress struct {
+	Level int
+	Count int
+}
+
+// Badges returns up to limit badges of the user, and how many they have in
+// total as far as IMVU tells; a limit of 0 returns all of them
+func (i *IMVU) Badges(userID string, limit int) ([]Badge, int, error) {
+	pages := i.api.GetBadges(userID)
+	badges, err := pages.Collect(limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	return badges, max(pages.Total, len(badges)), nil
+}
+
+// BadgeProgress returns the badge level and badge count of the bot. It takes
+// fetching every badge, so it is cached for a while.
+func (i *IMVU) BadgeProgress() (*BadgeProgress, error) {
+	i.badgeMu.Lock()
+	cached, expires := i.badgeProgress, i.badgeExpires
+	i.badgeMu.Unlock()
+	if cached != nil && time.Now().Before(expires) {
+		return cached, nil
+	}
+
+	// The level changes as badges are earned, the cached profile may be old
+	i.users.Invalidate(i.UserID())
+	user, err := i.Profile(i.UserID())
+	if err != nil {
+		return nil, err
+	}
+	_, count, err := i.Badges(i.UserID(), 0)
+	if err != nil {
+		return nil, err
+	}
+	progress := &BadgeProgress{Level: user.BadgeLevel, Count: count}
+
+	i.badgeMu.Lock()
+	i.badgeProgress, i.badgeExpires = progress, time.Now().Add(badgeProgressTTL)
+	i.badgeMu.Unlock()
+	return progress, nil
+}
+
+// WatchBadges publishes an events.BadgeEarned for every new badge of the bot
+// until the context is cancelled. Badges are checked periodically and on
+// every notification, since awards are announced in the notification center.
+// Badges the bot has when it starts are not published.
+func (i *IMVU) WatchBadges(ctx context.Context) {
+	notifications := events.Subscribe[events.NotificationReceived](i.Events, 4)
+	defer notifications.Close()
+
+	seen := newSeenKeys(seenKept)
+	poll(ctx, badgePollInterval, notifications.C, func() {
+		badges, _, err := i.Badges(i.UserID(), 0)
+		if err != nil {
+			log.Printf("Failed to check badges: %v", err)
+			return
+		}
+		i.publishBadges(badges, seen)
+	})
+}
+
+// publishBadges publishes the badges not seen before
+func (i *IMVU) publishBadges(badges []Badge, seen *seenKeys) {
+	ids := make([]string, len(badges))
+	for n, b := range badges {
+		ids[n] = b.ID
+	}
+	fresh := seen.update(ids)
+	if len(fresh) > 0 {
+		i.badgeMu.Lock()
+		i.badgeProgress = nil
+		i.badgeMu.Unlock()
+	}
+
+	for _, b := range badges {
+		if !fresh[b.ID] {
+			continue
+		}
+
+		events.Publish(i.Events, events.BadgeEarned{
+			ID:          b.ID,
+			Name:        b.Name,
+			Description: b.Description,
+			At:          time.Now(),
+		})
+	}
+}
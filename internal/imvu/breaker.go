@@ -0,0 +1,131 @@
+package imvu
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting IMVU while the circuit breaker
+// of the endpoint group is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+const (
+	// breakerThreshold is the number of consecutive failures that opens the
+	// circuit of an endpoint group
+	breakerThreshold = 5
+	breakerCooldown  = 30 * time.Second
+)
+
+// BreakerState is the state of a circuit breaker
+type BreakerState int
+
+const (
+	// BreakerClosed lets every request through
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects requests until the cool-down is over
+	BreakerOpen
+	// BreakerHalfOpen lets a single trial request through
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+type circuit struct {
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// breakers keeps a circuit per endpoint group (the rate limit categories)
+type breakers struct {
+	mu       sync.Mutex
+	circuits map[string]*circuit
+	onChange func(group string, state BreakerState)
+}
+
+func newBreakers() *breakers {
+	return &breakers{circuits: map[string]*circuit{}}
+}
+
+// allow reports whether a request of the group may be sent
+func (b *breakers) allow(group string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(group)
+	switch c.state {
+	case BreakerOpen:
+		if time.Since(c.openedAt) < breakerCooldown {
+			return ErrCircuitOpen
+		}
+		b.setState(group, c, BreakerHalfOpen)
+		c.trial = true
+		return nil
+	case BreakerHalfOpen:
+		if c.trial {
+			return ErrCircuitOpen
+		}
+		c.trial = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record updates the circuit with the outcome of a request. Only server errors
+// and transport failures count as failures.
+func (b *breakers) record(group string, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(group)
+	c.trial = false
+
+	if !failed {
+		c.failures = 0
+		if c.state != BreakerClosed {
+			b.setState(group, c, BreakerClosed)
+		}
+		return
+	}
+
+	c.failures++
+	if c.state == BreakerHalfOpen || c.failures >= breakerThreshold {
+		c.openedAt = time.Now()
+		if c.state != BreakerOpen {
+			b.setState(group, c, BreakerOpen)
+		}
+	}
+}
+
+func (b *breakers) circuit(group string) *circuit {
+	c, ok := b.circuits[group]
+	if !ok {
+		c = &circuit{}
+		b.circuits[group] = c
+	}
+	return c
+}
+
+func (b *breakers) setState(group string, c *circuit, state BreakerState) {
+	c.state = state
+	log.Printf("Circuit breaker of %s endpoints is now %s", group, state)
+	if b.onChange != nil {
+		// Called without blocking the request path
+		go b.onChange(group, state)
+	}
+}
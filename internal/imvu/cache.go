@@ -0,0 +1,242 @@
+package imvu
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"giiny/internal/metrics"
+)
+
+// DefaultCacheEntries is how many responses the HTTP cache keeps by default
+const DefaultCacheEntries = 1000
+
+// httpCache keeps the responses of GET requests so they can be revalidated
+// with If-None-Match or If-Modified-Since instead of downloaded again, and
+// served without any request while Cache-Control max-age says they are
+// fresh. With a directory, entries are also written to disk so they survive
+// restarts.
+type httpCache struct {
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	max     int
+	dir     string
+}
+
+// cacheEntry is a cached response. Entries are replaced rather than changed,
+// apart from used, so they can be read without the cache's lock.
+type cacheEntry struct {
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Expires      time.Time   `json:"expires"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+	used         time.Time
+}
+
+// WithCache caches GET responses in memory, up to maxEntries of them. A
+// non-empty dir also keeps them on disk.
+func WithCache(maxEntries int, dir string) ClientOption {
+	return func(c *HTTPClient) {
+		if maxEntries <= 0 {
+			maxEntries = DefaultCacheEntries
+		}
+		if dir != "" {
+			if err := os.MkdirAll(dir, 0o700); err != nil {
+				log.Printf("Failed to create HTTP cache directory, caching in memory only: %v", err)
+				dir = ""
+			}
+		}
+		c.cache = &httpCache{entries: map[string]*cacheEntry{}, max: maxEntries, dir: dir}
+	}
+}
+
+// get returns the entry of path, or nil
+func (hc *httpCache) get(path string) *cacheEntry {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	entry, ok := hc.entries[path]
+	if !ok {
+		entry = hc.load(path)
+		if entry == nil {
+			return nil
+		}
+		hc.add(path, entry)
+	}
+	entry.used = time.Now()
+	return entry
+}
+
+// update stores resp if it can be cached, or answers a 304 with the cached
+// entry. It returns the response to hand to the caller.
+func (hc *httpCache) update(path string, cached *cacheEntry, resp *http.Response) (*http.Response, error) {
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		metrics.HTTPCacheRevalidations.Add(1)
+
+		hc.mu.Lock()
+		revalidated := *cached
+		revalidated.Expires = expires(resp.Header)
+		hc.add(path, &revalidated)
+		hc.save(path, &revalidated)
+		hc.mu.Unlock()
+		return revalidated.response(resp.Request), nil
+	}
+
+	if resp.StatusCode != http.StatusOK || !cacheable(resp.Header) {
+		return resp, nil
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	// Cookies belong to the session that got the response, not to whoever
+	// is served it from the cache
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+	entry := &cacheEntry{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Expires:      expires(resp.Header),
+		Header:       header,
+		Body:         data,
+		used:         time.Now(),
+	}
+
+	hc.mu.Lock()
+	hc.add(path, entry)
+	hc.save(path, entry)
+	hc.mu.Unlock()
+	return resp, nil
+}
+
+// add stores entry, evicting the least recently used entry when full
+func (hc *httpCache) add(path string, entry *cacheEntry) {
+	if _, ok := hc.entries[path]; !ok && len(hc.entries) >= hc.max {
+		var oldest string
+		for p, e := range hc.entries {
+			if oldest == "" || e.used.Before(hc.entries[oldest].used) {
+				oldest = p
+			}
+		}
+		delete(hc.entries, oldest)
+	}
+	hc.entries[path] = entry
+}
+
+// file returns the path of the entry of path on disk
+func (hc *httpCache) file(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(hc.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// load reads the entry of path from disk, or returns nil
+func (hc *httpCache) load(path string) *cacheEntry {
+	if hc.dir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(hc.file(path))
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		log.Printf("Warning: ignoring corrupt HTTP cache entry for %s: %v", path, err)
+		return nil
+	}
+	return &entry
+}
+
+// save writes the entry of path to disk, if the cache has a directory
+func (hc *httpCache) save(path string, entry *cacheEntry) {
+	if hc.dir == "" {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Failed to encode HTTP cache entry: %v", err)
+		return
+	}
+	if err := os.WriteFile(hc.file(path), data, 0o600); err != nil {
+		log.Printf("Failed to write HTTP cache entry: %v", err)
+	}
+}
+
+// fresh reports whether the entry can be used without asking the server
+func (e *cacheEntry) fresh() bool {
+	return time.Now().Before(e.Expires)
+}
+
+// validate adds the headers of a conditional request for the entry
+func (e *cacheEntry) validate(req *http.Request) {
+	if e.ETag != "" {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+}
+
+// response builds a response for req out of the entry
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// cacheable reports whether a response with the header can be cached: it has
+// a validator or a max-age, and Cache-Control neither forbids storing it nor
+// makes it private to the user it was sent to
+func cacheable(header http.Header) bool {
+	for directive := range strings.SplitSeq(header.Get("Cache-Control"), ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store", "private":
+			return false
+		}
+	}
+	return header.Get("ETag") != "" || header.Get("Last-Modified") != "" || !expires(header).IsZero()
+}
+
+// expires returns until when a response with the header is fresh, from the
+// max-age of Cache-Control. It is the zero time if it must be revalidated.
+func expires(header http.Header) time.Time {
+	var maxAge int
+	for directive := range strings.SplitSeq(header.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(directive)
+		if directive == "no-cache" {
+			return time.Time{}
+		}
+		if value, ok := strings.CutPrefix(directive, "max-age="); ok {
+			maxAge, _ = strconv.Atoi(value)
+		}
+	}
+	if maxAge <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(maxAge) * time.Second)
+}
@@ -0,0 +1,132 @@
+package imvu
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// cacheTestClient returns a client caching on disk in a temporary directory,
+// talking to a server that answers every request with handler, and counts
+// the requests that reached the server
+func cacheTestClient(t *testing.T, handler http.HandlerFunc) (*HTTPClient, *atomic.Int32, string) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	c, err := NewClient(
+		WithEndpoints(Endpoints{REST: srv.URL, IMQ: srv.URL, Origin: srv.URL, SecureOrigin: srv.URL}),
+		WithCache(10, dir),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, &hits, dir
+}
+
+func getBody(t *testing.T, c *HTTPClient, path string) (string, http.Header) {
+	t.Helper()
+	resp, err := c.Get(path, nil)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body), resp.Header
+}
+
+func TestCacheSkipsPrivateResponses(t *testing.T) {
+	for _, control := range []string{"private, max-age=60", "max-age=60, no-store", "Private"} {
+		t.Run(control, func(t *testing.T) {
+			c, hits, dir := cacheTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", control)
+				w.Header().Set("ETag", `"v1"`)
+				io.WriteString(w, "secret of "+r.URL.Path)
+			})
+
+			for range 2 {
+				if body, _ := getBody(t, c, "/user/user-1"); body != "secret of /user/user-1" {
+					t.Fatalf("body = %q", body)
+				}
+			}
+			if n := hits.Load(); n != 2 {
+				t.Errorf("server got %d requests, want 2", n)
+			}
+			if c.cache.get("/user/user-1") != nil {
+				t.Error("response was cached")
+			}
+			if files, _ := os.ReadDir(dir); len(files) != 0 {
+				t.Errorf("response was written to disk: %d files", len(files))
+			}
+		})
+	}
+}
+
+func TestCacheDropsCookies(t *testing.T) {
+	c, hits, dir := cacheTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Set-Cookie", "osCsid=session-of-someone; Path=/")
+		io.WriteString(w, "product")
+	})
+
+	_, header := getBody(t, c, "/product/product-1")
+	if header.Get("Set-Cookie") == "" {
+		t.Fatal("the response from the server lost its cookie")
+	}
+	body, header := getBody(t, c, "/product/product-1")
+	if hits.Load() != 1 {
+		t.Errorf("fresh response was not served from the cache: %d requests", hits.Load())
+	}
+	if body != "product" {
+		t.Errorf("cached body = %q, want %q", body, "product")
+	}
+	if cookie := header.Get("Set-Cookie"); cookie != "" {
+		t.Errorf("cached response sets cookie %q", cookie)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("%d entries on disk, want 1", len(files))
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "session-of-someone") {
+		t.Error("cookie written to the cache on disk")
+	}
+}
+
+func TestCacheRevalidates(t *testing.T) {
+	c, hits, _ := cacheTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, "room")
+	})
+
+	for n := range 3 {
+		if body, _ := getBody(t, c, "/room/room-1-2"); body != "room" {
+			t.Errorf("request %d: body = %q, want %q", n+1, body, "room")
+		}
+	}
+	// Without a max-age every request goes to the server, only conditionally
+	if n := hits.Load(); n != 3 {
+		t.Errorf("server got %d requests, want 3", n)
+	}
+}
@@ -0,0 +1,156 @@
+package imvu
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// captchaSolveTimeout bounds how long a solver may take, which includes a
+// human solving it by hand
+const captchaSolveTimeout = 5 * time.Minute
+
+// defaultCaptchaURL is where a login captcha can be solved in a browser
+const defaultCaptchaURL = "https://secure.imvu.com/welcome/login/"
+
+// CaptchaChallenge is the captcha asked for by a login attempt
+type CaptchaChallenge struct {
+	// Kind is the captcha provider, e.g. "recaptcha", when IMVU names it
+	Kind    string `json:"kind"`
+	SiteKey string `json:"site_key"`
+	// URL is the page the captcha is shown on
+	URL string `json:"url"`
+}
+
+// CaptchaSolver solves login captchas. The returned token is sent back with
+// the retried login.
+type CaptchaSolver interface {
+	Solve(ctx context.Context, challenge CaptchaChallenge) (string, error)
+}
+
+// WithCaptchaSolver sets the solver used when login asks for a captcha.
+// Without one, login fails with ErrCaptchaRequired.
+func WithCaptchaSolver(solver CaptchaSolver) ClientOption {
+	return func(c *HTTPClient) {
+		c.captchaSolver = solver
+	}
+}
+
+// captchaChallenge reads the challenge from the body of a captcha error.
+// IMVU has sent the details both at the top level and under "captcha".
+func captchaChallenge(apiErr *APIError) CaptchaChallenge {
+	type details struct {
+		Type    string `json:"type"`
+		SiteKey string `json:"site_key"`
+		URL     string `json:"url"`
+	}
+	var payload struct {
+		details
+		Captcha details `json:"captcha"`
+	}
+	json.Unmarshal(apiErr.Body, &payload)
+
+	challenge := CaptchaChallenge{
+		Kind:    payload.Type,
+		SiteKey: payload.SiteKey,
+		URL:     payload.URL,
+	}
+	if payload.Captcha != (details{}) {
+		challenge = CaptchaChallenge{
+			Kind:    payload.Captcha.Type,
+			SiteKey: payload.Captcha.SiteKey,
+			URL:     payload.Captcha.URL,
+		}
+	}
+	if challenge.URL == "" {
+		challenge.URL = defaultCaptchaURL
+	}
+	return challenge
+}
+
+// PromptSolver asks a person to solve the captcha in a browser and paste the
+// token
+type PromptSolver struct {
+	In  io.Reader
+	Out io.Writer
+}
+
+func (s PromptSolver) Solve(ctx context.Context, challenge CaptchaChallenge) (string, error) {
+	fmt.Fprintf(s.Out, "IMVU asks for a captcha to log in. Solve it at %s", challenge.URL)
+	if challenge.SiteKey != "" {
+		fmt.Fprintf(s.Out, " (%s site key %s)", challenge.Kind, challenge.SiteKey)
+	}
+	fmt.Fprint(s.Out, "\nand paste the captcha response token: ")
+
+	line := make(chan string, 1)
+	go func() {
+		text, _ := bufio.NewReader(s.In).ReadString('\n')
+		line <- strings.TrimSpace(text)
+	}()
+
+	select {
+	case token := <-line:
+		if token == "" {
+			return "", errors.New("no captcha token entered")
+		}
+		return token, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// ServiceSolver sends the challenge as JSON to an external solver service,
+// which answers with {"token": "..."}
+type ServiceSolver struct {
+	URL    string
+	APIKey string
+	Client *http.Client
+}
+
+func (s ServiceSolver) Solve(ctx context.Context, challenge CaptchaChallenge) (string, error) {
+	body, err := json.Marshal(challenge)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create solver request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("solver request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("solver failed with status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to parse solver response: %w", err)
+	}
+	if result.Token == "" {
+		return "", errors.New("solver returned no token")
+	}
+	return result.Token, nil
+}
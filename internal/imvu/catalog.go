@@ -0,0 +1,107 @@
+package imvu
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"giiny/internal/events"
+)
+
+// catalogQueue is the IMQ queue where the Next store announces catalog
+// changes
+const catalogQueue = "inv:/store_catalog/store_catalog-next"
+
+// maxCatalogProducts bounds how many products of one catalog update are
+// looked up
+const maxCatalogProducts = 20
+
+// catalogUpdate is a delivery of the store catalog queue
+type catalogUpdate struct {
+	// Event is "product_added" or "sale_started"
+	Event string `json:"event"`
+	// Products are product IDs or product entity IDs
+	Products []StringOrInt `json:"products"`
+	// Discount is the sale's discount in percent
+	Discount int `json:"discount_percent"`
+}
+
+// parseCatalogUpdate decodes a catalog delivery. The payload is JSON, or like
+// chat messages base64 encoded JSON.
+func parseCatalogUpdate(payload json.RawMessage) (catalogUpdate, bool) {
+	var update catalogUpdate
+	var encoded string
+	if json.Unmarshal(payload, &encoded) == nil {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return update, false
+		}
+		payload = decoded
+	}
+	if err := json.Unmarshal(payload, &update); err != nil {
+		return update, false
+	}
+	return update, len(update.Products) > 0
+}
+
+// catalogKind maps the event of a catalog update to an events.Catalog kind
+func catalogKind(event string) string {
+	switch strings.ToLower(event) {
+	case "product_added", "new_product":
+		return events.CatalogNew
+	case "sale_started", "sale":
+		return events.CatalogSale
+	}
+	return ""
+}
+
+// SetCatalogWanted sets what tells whether catalog updates are wanted. The
+// products of an update are only looked up while wanted reports true; without
+// it they are always looked up.
+func (i *IMVU) SetCatalogWanted(wanted func() bool) {
+	i.catalogWanted.Store(&wanted)
+}
+
+// handleCatalogMessage publishes an events.CatalogItem for every new or
+// discounted product of a catalog update. Other updates are ignored.
+func (i *IMVU) handleCatalogMessage(msg Message) {
+	if wanted := i.catalogWanted.Load(); wanted != nil && *wanted != nil && !(*wanted)() {
+		return
+	}
+	update, ok := parseCatalogUpdate(msg.Payload)
+	if !ok {
+		return
+	}
+	kind := catalogKind(update.Event)
+	if kind == "" {
+		return
+	}
+
+	go func() {
+		for _, id := range update.Products[:min(len(update.Products), maxCatalogProducts)] {
+			productID := productIDFromEntity(string(id))
+			product, err := i.api.GetProduct(productID)
+			if err != nil {
+				log.Printf("Failed to get catalog product %s: %v", productID, err)
+				continue
+			}
+			url := product.ProductPage
+			if url == "" {
+				url = ProductURL(productID)
+			}
+			events.Publish(i.Events, events.CatalogItem{
+				Kind:        kind,
+				ProductID:   productID,
+				Name:        product.ProductName,
+				CreatorName: product.CreatorName,
+				Price:       product.Price,
+				Discount:    update.Discount,
+				Categories:  product.Categories,
+				URL:         url,
+				At:          time.Now(),
+			})
+		}
+	}()
+}
@@ -2,6 +2,9 @@ package imvu
 
 import (
 	"strings"
+	"time"
+
+	"giiny/internal/events"
 )
 
 type IMVUCommand string
@@ -43,14 +46,31 @@ const (
 	CmdSeat                IMVUCommand = "seat"
 )
 
+// Exec sends an avatar command to the room on the bot's own behalf
 func (i *IMVU) Exec(command IMVUCommand, args ...string) error {
+	return i.ExecAs("", command, args...)
+}
+
+// ExecAs sends an avatar command to the room requested by actor. Every command
+// is published as an events.CommandExecuted attributed to its actor, for the
+// audit trail; an empty actor is the bot acting on its own.
+func (i *IMVU) ExecAs(actor string, command IMVUCommand, args ...string) error {
 	cmd := string(command)
 	if len(args) > 0 {
 		args := strings.Join(args, " ")
 		cmd += " " + args
 	}
 
-	i.SendChatMessage("*" + cmd)
+	// Commands aren't split, as only the first part would keep the *
+	err := i.sendChatPart("0", "*"+cmd)
+
+	events.Publish(i.Events, events.CommandExecuted{
+		Actor:   actor,
+		Command: string(command),
+		Args:    args,
+		Err:     err,
+		At:      time.Now(),
+	})
 
-	return nil
+	return err
 }
@@ -0,0 +1,56 @@
+package imvu
+
+import (
+	"sync"
+	"time"
+)
+
+// dedupeWindow is how long a delivered message is remembered. IMQ replays
+// recent queue traffic when a queue is subscribed again after a reconnect.
+const dedupeWindow = 2 * time.Minute
+
+// dedupe remembers recently seen message keys. The keys are also queued in
+// the order they were seen, so the expired ones are dropped from the front
+// without scanning them all.
+type dedupe struct {
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[string]time.Time
+	order  []seenKey
+}
+
+type seenKey struct {
+	key string
+	at  time.Time
+}
+
+func newDedupe(window time.Duration) *dedupe {
+	return &dedupe{
+		window: window,
+		seen:   map[string]time.Time{},
+	}
+}
+
+// Seen records the key and reports whether it was already recorded within the
+// window
+func (d *dedupe) Seen(key string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	expired := 0
+	for _, s := range d.order {
+		if now.Sub(s.at) <= d.window {
+			break
+		}
+		delete(d.seen, s.key)
+		expired++
+	}
+	d.order = d.order[expired:]
+
+	if _, ok := d.seen[key]; ok {
+		return true
+	}
+	d.seen[key] = now
+	d.order = append(d.order, seenKey{key: key, at: now})
+	return false
+}
@@ -0,0 +1,33 @@
+package imvu
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDedupe(t *testing.T) {
+	d := newDedupe(time.Minute)
+	start := time.Now()
+
+	if d.Seen("a", start) {
+		t.Fatal("first delivery of a reported as seen")
+	}
+	if !d.Seen("a", start.Add(30*time.Second)) {
+		t.Error("replay of a within the window not reported as seen")
+	}
+	if d.Seen("b", start.Add(40*time.Second)) {
+		t.Error("first delivery of b reported as seen")
+	}
+
+	// a expires, b is still within its window
+	later := start.Add(90 * time.Second)
+	if d.Seen("a", later) {
+		t.Error("a reported as seen after the window")
+	}
+	if !d.Seen("b", later) {
+		t.Error("b expired before its window")
+	}
+	if len(d.seen) != len(d.order) {
+		t.Errorf("%d keys but %d queued", len(d.seen), len(d.order))
+	}
+}
@@ -0,0 +1,143 @@
+package imvu
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"giiny/internal/events"
+	"giiny/internal/metrics"
+)
+
+// defaultEchoTimeout is how long a sent chat message may take to come back on
+// the chat queue before it is considered lost
+const defaultEchoTimeout = 10 * time.Second
+
+// deliveries tracks the public chat messages sent by the bot until the server
+// echoes them back on the chat queue, matched by op_id. A message without an
+// echo is sent once more, and if that isn't echoed either
+// events.DeliveryFailed is published. Avatar commands are never sent again,
+// since a late echo would run them twice. Whispers aren't tracked, as they
+// may not be echoed back to the sender.
+type deliveries struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	pending map[int]*delivery
+}
+
+// delivery is a sent message waiting for its echo
+type delivery struct {
+	room    *Room
+	message string
+	resent  bool
+	timer   *time.Timer
+}
+
+func newDeliveries() *deliveries {
+	return &deliveries{
+		timeout: defaultEchoTimeout,
+		pending: map[int]*delivery{},
+	}
+}
+
+// SetEchoTimeout sets how long a sent chat message may take to be echoed back
+// before it is sent again. Zero disables the tracking.
+func (i *IMVU) SetEchoTimeout(timeout time.Duration) {
+	i.deliveries.mu.Lock()
+	defer i.deliveries.mu.Unlock()
+	i.deliveries.timeout = timeout
+}
+
+// deliver sends a public chat message to the room and tracks it until its
+// echo comes back. It doesn't wait for the echo.
+func (i *IMVU) deliver(room *Room, message string, resent bool) error {
+	payload := ChatMessagePayload{
+		ChatID:  StringOrInt(room.ChatroomID),
+		Message: message,
+		To:      StringOrInt("0"),
+		UserID:  StringOrInt(i.UserID()),
+	}
+	opID := i.opID.GetNew()
+
+	// The delivery is pending before the message goes out, so an echo coming
+	// back right away finds it
+	d := i.deliveries
+	var pending *delivery
+	d.mu.Lock()
+	if d.timeout > 0 {
+		pending = &delivery{room: room, message: message, resent: resent}
+		pending.timer = time.AfterFunc(d.timeout, func() { i.echoMissing(opID, pending) })
+		d.pending[opID] = pending
+	}
+	d.mu.Unlock()
+
+	if err := i.api.SendChatMessage(room.ChatQueue, "messages", opID, payload); err != nil {
+		if pending != nil {
+			d.mu.Lock()
+			pending.timer.Stop()
+			delete(d.pending, opID)
+			d.mu.Unlock()
+		}
+		return err
+	}
+	return nil
+}
+
+// confirmEcho marks the delivery with the op_id as delivered. Echoes without
+// an op_id are matched to the oldest delivery of the same message on the
+// queue.
+func (i *IMVU) confirmEcho(queue string, opID int, message string) {
+	d := i.deliveries
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if opID == 0 {
+		for id, p := range d.pending {
+			if p.room.ChatQueue == queue && p.message == message && (opID == 0 || id < opID) {
+				opID = id
+			}
+		}
+	}
+	p, ok := d.pending[opID]
+	if !ok || p.room.ChatQueue != queue {
+		return
+	}
+	p.timer.Stop()
+	delete(d.pending, opID)
+}
+
+// echoMissing sends a message without an echo again, or publishes
+// DeliveryFailed if it was already sent again or is an avatar command
+func (i *IMVU) echoMissing(opID int, missing *delivery) {
+	d := i.deliveries
+	d.mu.Lock()
+	if d.pending[opID] != missing {
+		// Echoed in the meantime
+		d.mu.Unlock()
+		return
+	}
+	delete(d.pending, opID)
+	d.mu.Unlock()
+
+	// Messages for a room that was left since are not worth sending again
+	room := i.currentRoom.Load()
+	if room == nil || room.OwnerID != missing.room.OwnerID || room.ChatroomID != missing.room.ChatroomID {
+		return
+	}
+
+	if !missing.resent && !isCommandText(missing.message) {
+		metrics.ChatResends.Add(1)
+		log.Printf("No echo of sent message on %s, sending it again: %s", room.ChatQueue, missing.message)
+		if err := i.deliver(room, missing.message, true); err == nil {
+			return
+		}
+	}
+
+	metrics.ChatDeliveryFailures.Add(1)
+	log.Printf("Message on %s was not delivered: %s", room.ChatQueue, missing.message)
+	events.Publish(i.Events, events.DeliveryFailed{
+		Queue:   room.ChatQueue,
+		Message: missing.message,
+		At:      time.Now(),
+	})
+}
@@ -0,0 +1,101 @@
+package imvu
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Endpoints are the addresses of the IMVU services the client talks to. The
+// defaults are production; they can point at another environment or at a mock
+// server such as imvutest.
+type Endpoints struct {
+	// REST is the base URL of the REST API
+	REST string `json:"rest,omitempty"`
+	// IMQ is the URL of the IMQ WebSocket
+	IMQ string `json:"imq,omitempty"`
+	// Origin is the Origin of the IMQ handshake
+	Origin string `json:"origin,omitempty"`
+	// SecureOrigin is the Origin of login and the Referer of REST requests
+	SecureOrigin string `json:"secure_origin,omitempty"`
+}
+
+// DefaultEndpoints returns the endpoints of IMVU's production services
+func DefaultEndpoints() Endpoints {
+	return Endpoints{
+		REST:         "https://api.imvu.com",
+		IMQ:          "wss://wss-imq.imvu.com/streaming/imvu_pre",
+		Origin:       "https://www.imvu.com",
+		SecureOrigin: "https://pt.secure.imvu.com",
+	}
+}
+
+// withDefaults fills the empty endpoints with the production ones
+func (e Endpoints) withDefaults() Endpoints {
+	d := DefaultEndpoints()
+	if e.REST != "" {
+		d.REST = strings.TrimSuffix(e.REST, "/")
+	}
+	if e.IMQ != "" {
+		d.IMQ = e.IMQ
+	}
+	if e.Origin != "" {
+		d.Origin = strings.TrimSuffix(e.Origin, "/")
+	}
+	if e.SecureOrigin != "" {
+		d.SecureOrigin = strings.TrimSuffix(e.SecureOrigin, "/")
+	}
+	return d
+}
+
+// Validate checks that the set endpoints are absolute URLs of the right
+// scheme
+func (e Endpoints) Validate() error {
+	checks := []struct {
+		name, value string
+		schemes     []string
+	}{
+		{"rest", e.REST, []string{"http", "https"}},
+		{"imq", e.IMQ, []string{"ws", "wss"}},
+		{"origin", e.Origin, []string{"http", "https"}},
+		{"secure_origin", e.SecureOrigin, []string{"http", "https"}},
+	}
+	for _, c := range checks {
+		if c.value == "" {
+			continue
+		}
+		u, err := url.Parse(c.value)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("%s: %q is not an absolute URL", c.name, c.value)
+		}
+		if !slices.Contains(c.schemes, u.Scheme) {
+			return fmt.Errorf("%s: scheme must be %s", c.name, strings.Join(c.schemes, " or "))
+		}
+	}
+	return nil
+}
+
+// cookieURL returns the HTTP URL of the IMQ host, whose cookies are sent
+// with the WebSocket handshake
+func (e Endpoints) cookieURL() string {
+	u, err := url.Parse(e.IMQ)
+	if err != nil {
+		return e.IMQ
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	default:
+		u.Scheme = "https"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// WithEndpoints points the client at other services. Empty endpoints keep
+// the production ones.
+func WithEndpoints(endpoints Endpoints) ClientOption {
+	return func(c *HTTPClient) {
+		c.endpoints = endpoints.withDefaults()
+	}
+}
@@ -0,0 +1,98 @@
+package imvu
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// ErrNoRelation is returned when an entity has no relation of the given name
+var ErrNoRelation = errors.New("relation not found")
+
+// Entity returns the denormalized entity. A partial ID such as
+// "/user/user-123" matches the entity whose full URL ends with it.
+func (r *BaseResponse) Entity(entityID string) (EntityData, bool) {
+	if entity, ok := r.Denormalized[entityID]; ok {
+		return entity, true
+	}
+	if strings.HasPrefix(entityID, "https://") {
+		return EntityData{}, false
+	}
+
+	for key, entity := range r.Denormalized {
+		if strings.HasSuffix(key, entityID) {
+			return entity, true
+		}
+	}
+	return EntityData{}, false
+}
+
+// Relation returns the ID of the entity the relation of entityID points to
+func Relation(response *BaseResponse, entityID, relation string) (string, error) {
+	entity, ok := response.Entity(entityID)
+	if !ok {
+		return "", fmt.Errorf("entity not found: %s", entityID)
+	}
+
+	ref, ok := entity.Relations[relation]
+	if !ok || ref == "" {
+		return "", fmt.Errorf("%w: %s of %s", ErrNoRelation, relation, entityID)
+	}
+	return ref, nil
+}
+
+// UpdatesQueue returns the IMQ queue on which changes of the entity of the
+// given kind are published, from its updates links
+func UpdatesQueue(response *BaseResponse, entityID, kind string) (string, error) {
+	entity, ok := response.Entity(entityID)
+	if !ok {
+		return "", fmt.Errorf("entity not found: %s", entityID)
+	}
+
+	queue, ok := entity.Updates[kind]
+	if !ok || queue == "" {
+		return "", fmt.Errorf("%w: updates %s of %s", ErrNoRelation, kind, entityID)
+	}
+	return queue, nil
+}
+
+// GetRelated extracts the entity the relation of entityID points to. It
+// returns an error wrapping ErrNoRelation when the relation is missing.
+func GetRelated[T any](response *BaseResponse, entityID, relation string) (*T, error) {
+	ref, err := Relation(response, entityID, relation)
+	if err != nil {
+		return nil, err
+	}
+	return ExtractEntity[T](response, ref)
+}
+
+// ExtractList extracts the items of the collection entity. Items that are not
+// in the response are logged and skipped.
+func ExtractList[T any](response *BaseResponse, collectionID string) ([]T, error) {
+	collection, err := ExtractEntity[Collection](response, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]T, 0, len(collection.Items))
+	for _, ref := range collection.Items {
+		item, err := ExtractEntity[T](response, ref)
+		if err != nil {
+			log.Printf("Warning: skipping %s: %v", ref, err)
+			continue
+		}
+		items = append(items, *item)
+	}
+	return items, nil
+}
+
+// GetRelatedList extracts the items of the collection the relation of
+// entityID points to
+func GetRelatedList[T any](response *BaseResponse, entityID, relation string) ([]T, error) {
+	ref, err := Relation(response, entityID, relation)
+	if err != nil {
+		return nil, err
+	}
+	return ExtractList[T](response, ref)
+}
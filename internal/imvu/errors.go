@@ -0,0 +1,125 @@
+package imvu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Codes of APIError. Error identifiers that aren't recognized are kept as sent
+// by IMVU.
+const (
+	CodeInvalidCredentials = "invalid-credentials"
+	CodeCaptchaRequired    = "captcha-required"
+	CodeRateLimited        = "rate-limited"
+	CodeUnauthorized       = "unauthorized"
+	CodeNotFound           = "not-found"
+	CodeUnknown            = "unknown"
+)
+
+var (
+	// ErrInvalidCredentials matches an APIError for a wrong username or
+	// password
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrCaptchaRequired matches an APIError for a login that needs a captcha
+	ErrCaptchaRequired = errors.New("captcha required")
+)
+
+// APIError is a failed response of the IMVU API
+type APIError struct {
+	StatusCode int
+	// Code is one of the Code constants or the identifier sent by IMVU
+	Code    string
+	Message string
+	// Retryable is set when the same request may succeed later
+	Retryable bool
+	// Body is the raw response, for the details of specific errors
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("IMVU API error %s (status %d)", e.Code, e.StatusCode)
+	}
+	return fmt.Sprintf("IMVU API error %s (status %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// Is makes errors.Is match the sentinel errors of specific codes
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrInvalidCredentials:
+		return e.Code == CodeInvalidCredentials
+	case ErrCaptchaRequired:
+		return e.Code == CodeCaptchaRequired
+	}
+	return false
+}
+
+// errorPayload is the body of a failed response. The login endpoint uses
+// error identifiers like imvu:login:..., other endpoints plain messages.
+type errorPayload struct {
+	Status       string `json:"status"`
+	Error        string `json:"error"`
+	ErrorCode    string `json:"error_code"`
+	Message      string `json:"message"`
+	ErrorMessage string `json:"error_message"`
+}
+
+// newAPIError parses the error payload of a response body
+func newAPIError(statusCode int, body []byte) *APIError {
+	var payload errorPayload
+	json.Unmarshal(body, &payload)
+
+	identifier := payload.Error
+	if identifier == "" {
+		identifier = payload.ErrorCode
+	}
+	message := payload.Message
+	if message == "" {
+		message = payload.ErrorMessage
+	}
+	if message == "" && identifier == "" && !json.Valid(body) {
+		message = strings.TrimSpace(string(body))
+	}
+
+	code := classify(statusCode, identifier, message)
+	return &APIError{
+		StatusCode: statusCode,
+		Code:       code,
+		Message:    message,
+		Retryable:  statusCode == http.StatusTooManyRequests || statusCode >= 500 || code == CodeRateLimited,
+		Body:       body,
+	}
+}
+
+// readAPIError reads the body of a failed response into an APIError
+func readAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	return newAPIError(resp.StatusCode, body)
+}
+
+// classify maps an error to one of the Code constants, keeping unknown
+// identifiers
+func classify(statusCode int, identifier, message string) string {
+	text := strings.ToLower(identifier + " " + message)
+	switch {
+	case strings.Contains(text, "captcha"):
+		return CodeCaptchaRequired
+	case strings.Contains(text, "credential"), strings.Contains(text, "password"),
+		strings.Contains(text, "invalid_login"), strings.Contains(text, "invalid-login"):
+		return CodeInvalidCredentials
+	case statusCode == http.StatusTooManyRequests, strings.Contains(text, "rate limit"), strings.Contains(text, "rate_limit"):
+		return CodeRateLimited
+	case identifier != "":
+		return identifier
+	case statusCode == http.StatusUnauthorized:
+		return CodeUnauthorized
+	case statusCode == http.StatusNotFound, statusCode == http.StatusGone:
+		return CodeNotFound
+	default:
+		return CodeUnknown
+	}
+}
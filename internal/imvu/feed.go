@@ -0,0 +1,104 @@
+package imvu
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"giiny/internal/events"
+)
+
+// feedPostsChecked is how many of the latest posts of a watched user are
+// compared with the ones already seen
+const feedPostsChecked = 10
+
+// Feed returns up to limit of the latest posts of the user
+func (i *IMVU) Feed(userID string, limit int) ([]FeedPost, error) {
+	return i.api.GetFeed(userID).Collect(limit)
+}
+
+// PostStatus posts a text status on the bot's feed
+func (i *IMVU) PostStatus(message string) error {
+	if i.DryRun() {
+		log.Printf("[dry-run] Would post on the feed: %s", message)
+		return nil
+	}
+	return i.api.CreatePost(i.UserID(), message, "")
+}
+
+// PostSnapshot posts the bot's current avatar picture on its feed, with the
+// message as caption
+func (i *IMVU) PostSnapshot(message string) error {
+	i.users.Invalidate(i.UserID())
+	user, err := i.Profile(i.UserID())
+	if err != nil {
+		return err
+	}
+	if i.DryRun() {
+		log.Printf("[dry-run] Would post a snapshot on the feed: %s (%s)", message, user.AvatarImage)
+		return nil
+	}
+	return i.api.CreatePost(i.UserID(), message, user.AvatarImage)
+}
+
+// LikePost likes a feed post as the bot
+func (i *IMVU) LikePost(postID string) error {
+	if i.DryRun() {
+		log.Printf("[dry-run] Would like post %s", postID)
+		return nil
+	}
+	return i.api.LikePost(i.UserID(), postID)
+}
+
+// CommentPost comments on a feed post as the bot
+func (i *IMVU) CommentPost(postID, message string) error {
+	if i.DryRun() {
+		log.Printf("[dry-run] Would comment on post %s: %s", postID, message)
+		return nil
+	}
+	return i.api.CommentPost(postID, message)
+}
+
+// WatchFeeds publishes an events.FeedPosted for every new post of the users
+// until the context is cancelled, checking their feeds every interval. Posts
+// that exist when it starts are not published.
+func (i *IMVU) WatchFeeds(ctx context.Context, userIDs []string, interval time.Duration) {
+	seen := make(map[string]*seenKeys, len(userIDs))
+	for _, userID := range userIDs {
+		seen[userID] = newSeenKeys(seenKept)
+	}
+
+	poll[struct{}](ctx, interval, nil, func() {
+		for _, userID := range userIDs {
+			posts, err := i.Feed(userID, feedPostsChecked)
+			if err != nil {
+				log.Printf("Failed to check the feed of user %s: %v", userID, err)
+				continue
+			}
+			i.publishPosts(userID, posts, seen[userID])
+		}
+	})
+}
+
+// publishPosts publishes the posts not seen before
+func (i *IMVU) publishPosts(userID string, posts []FeedPost, seen *seenKeys) {
+	ids := make([]string, len(posts))
+	for n, p := range posts {
+		ids[n] = p.ID
+	}
+	fresh := seen.update(ids)
+
+	for _, p := range posts {
+		if !fresh[p.ID] {
+			continue
+		}
+
+		events.Publish(i.Events, events.FeedPosted{
+			UserID:   userID,
+			PostID:   p.ID,
+			Message:  p.Message,
+			ImageURL: p.ImageURL,
+			At:       time.Now(),
+		})
+	}
+}
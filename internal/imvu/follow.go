@@ -0,0 +1,114 @@
+package imvu
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"giiny/internal/events"
+)
+
+// maxFollowing bounds how many followed users are watched
+const maxFollowing = 200
+
+// Following returns up to limit of the users the bot follows
+func (i *IMVU) Following(limit int) ([]Friend, error) {
+	return i.api.GetFollowing(i.UserID()).Collect(limit)
+}
+
+// Follow makes the bot follow the user, adding them to the watched buddies
+func (i *IMVU) Follow(userID string) error {
+	if i.DryRun() {
+		log.Printf("[dry-run] Would follow user %s", userID)
+		return nil
+	}
+	if err := i.api.FollowUser(i.UserID(), userID); err != nil {
+		return err
+	}
+	i.setFollowed(userID, true)
+	return nil
+}
+
+// Unfollow makes the bot stop following the user
+func (i *IMVU) Unfollow(userID string) error {
+	if i.DryRun() {
+		log.Printf("[dry-run] Would unfollow user %s", userID)
+		return nil
+	}
+	if err := i.api.UnfollowUser(i.UserID(), userID); err != nil {
+		return err
+	}
+	i.setFollowed(userID, false)
+	return nil
+}
+
+// Follows reports whether the bot follows the user, as of the last check of
+// WatchFollowing or the last Follow or Unfollow
+func (i *IMVU) Follows(userID string) bool {
+	i.followingMu.Lock()
+	defer i.followingMu.Unlock()
+	return i.following[userID]
+}
+
+func (i *IMVU) setFollowed(userID string, followed bool) {
+	i.followingMu.Lock()
+	defer i.followingMu.Unlock()
+	if i.following == nil {
+		i.following = map[string]bool{}
+	}
+	if followed {
+		i.following[userID] = true
+	} else {
+		delete(i.following, userID)
+	}
+}
+
+// SendDirectMessage sends a private message to the user, who doesn't need to
+// be in the room
+func (i *IMVU) SendDirectMessage(userID, message string) error {
+	if i.DryRun() {
+		log.Printf("[dry-run] Would send a direct message to user %s: %s", userID, message)
+		return nil
+	}
+	return i.api.SendDirectMessage(i.UserID(), userID, message)
+}
+
+// WatchFollowing publishes an events.BuddyOnline whenever a user the bot
+// follows comes online, checking the list every interval until the context
+// is cancelled. Users already online when it starts are not published.
+func (i *IMVU) WatchFollowing(ctx context.Context, interval time.Duration) {
+	// Only who is online now is remembered, so a user coming back online is
+	// published again
+	online := newSeenKeys(0)
+	poll[struct{}](ctx, interval, nil, func() {
+		following, err := i.Following(maxFollowing)
+		if err != nil {
+			log.Printf("Failed to check the users the bot follows: %v", err)
+			return
+		}
+		i.publishOnline(following, online)
+	})
+}
+
+// publishOnline publishes the users who came online since the last check
+func (i *IMVU) publishOnline(following []Friend, online *seenKeys) {
+	followed := make(map[string]bool, len(following))
+	var userIDs []string
+	for _, f := range following {
+		followed[f.UserID] = true
+		if f.Online {
+			userIDs = append(userIDs, f.UserID)
+		}
+	}
+
+	fresh := online.update(userIDs)
+	for _, userID := range userIDs {
+		if fresh[userID] {
+			events.Publish(i.Events, events.BuddyOnline{UserID: userID, At: time.Now()})
+		}
+	}
+
+	i.followingMu.Lock()
+	i.following = followed
+	i.followingMu.Unlock()
+}
@@ -0,0 +1,40 @@
+package imvu
+
+import (
+	"errors"
+	"log"
+)
+
+// ErrNotGreeter is returned by greeter actions when the bot account is not an
+// official greeter
+var ErrNotGreeter = errors.New("not a greeter")
+
+// GreeterStatus returns whether the bot is an official greeter and its
+// current greeter score
+func (i *IMVU) GreeterStatus() (bool, int, error) {
+	// The score changes with every greeting, the cached profile may be old
+	i.users.Invalidate(i.UserID())
+	user, err := i.Profile(i.UserID())
+	if err != nil {
+		return false, 0, err
+	}
+	return user.IsGreeter, user.GreeterScore, nil
+}
+
+// GreeterRooms returns up to limit rooms that asked for greeters
+func (i *IMVU) GreeterRooms(limit int) ([]RoomInfo, error) {
+	return i.api.GetGreeterRooms().Collect(limit)
+}
+
+// Greet records an official greeting of the user, which counts towards the
+// bot's greeter score
+func (i *IMVU) Greet(userID string) error {
+	if user := i.User(); user != nil && !user.IsGreeter {
+		return ErrNotGreeter
+	}
+	if i.DryRun() {
+		log.Printf("[dry-run] Would greet user %s as a greeter", userID)
+		return nil
+	}
+	return i.api.Greet(i.UserID(), userID)
+}
@@ -2,24 +2,49 @@ package imvu
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"giiny/internal/metrics"
+
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/publicsuffix"
+	"golang.org/x/time/rate"
 )
 
-const baseURL = "https://api.imvu.com"
+var tracer = otel.Tracer("giiny/imvu")
 
 type HTTPClient struct {
 	httpClient *http.Client
-	baseURL    string
+	endpoints  Endpoints
 	userAgent  string
 	headers    map[string]string
+	// dialer opens the IMQ WebSocket connection
+	dialer *websocket.Dialer
+	// imqMaxMessage is the largest IMQ message read, in bytes
+	imqMaxMessage int64
+	limiters      map[string]*rate.Limiter
+	breakers      *breakers
+	// captchaSolver answers the captcha challenges of login
+	captchaSolver CaptchaSolver
+	// cache keeps GET responses, nil unless WithCache is given
+	cache *httpCache
+	// lastSuccess is when a request last got a response below 500, in Unix
+	// nanoseconds
+	lastSuccess atomic.Int64
 }
 
 func (c *HTTPClient) AddHeader(key, value string) {
@@ -41,7 +66,9 @@ func NewClient(options ...ClientOption) (*HTTPClient, error) {
 			Jar:     jar,
 			Timeout: 30 * time.Second,
 		},
-		baseURL:   baseURL,
+		endpoints: DefaultEndpoints(),
+		limiters:  newLimiters(),
+		breakers:  newBreakers(),
 		userAgent: "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/136.0.0.0 Safari/537.36",
 		headers: map[string]string{
 			"Accept":             "application/json; charset=utf-8",
@@ -57,6 +84,8 @@ func NewClient(options ...ClientOption) (*HTTPClient, error) {
 		},
 	}
 
+	client.lastSuccess.Store(time.Now().UnixNano())
+
 	for _, option := range options {
 		option(client)
 	}
@@ -64,9 +93,17 @@ func NewClient(options ...ClientOption) (*HTTPClient, error) {
 	return client, nil
 }
 
+// LastSuccess returns when a request last got a response that wasn't a server
+// error. It starts at the creation of the client.
+func (c *HTTPClient) LastSuccess() time.Time {
+	return time.Unix(0, c.lastSuccess.Load())
+}
+
+// WithBaseURL points the REST API at another server, keeping the other
+// endpoints
 func WithBaseURL(baseURL string) ClientOption {
 	return func(c *HTTPClient) {
-		c.baseURL = baseURL
+		c.endpoints.REST = strings.TrimSuffix(baseURL, "/")
 	}
 }
 
@@ -88,8 +125,62 @@ func WithTimeout(timeout time.Duration) ClientOption {
 	}
 }
 
+// WithDialer sets the dialer used for the IMQ WebSocket connection, e.g. to
+// pin certificates, route through a proxy or enable compression
+func WithDialer(dialer *websocket.Dialer) ClientOption {
+	return func(c *HTTPClient) {
+		c.dialer = dialer
+	}
+}
+
+// WithTransport sets the RoundTripper of REST requests, e.g. for a custom
+// resolver, tuned connection pooling or a test double. The default is
+// http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *HTTPClient) {
+		c.httpClient.Transport = transport
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of REST requests, e.g. to present
+// a client certificate. It applies to the default transport or one set with
+// WithTransport before it if that is an *http.Transport; other RoundTrippers
+// are left alone.
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *HTTPClient) {
+		var transport *http.Transport
+		switch t := c.httpClient.Transport.(type) {
+		case nil:
+			transport = http.DefaultTransport.(*http.Transport).Clone()
+		case *http.Transport:
+			transport = t.Clone()
+		default:
+			return
+		}
+		transport.TLSClientConfig = config
+		c.httpClient.Transport = transport
+	}
+}
+
+// WithIMQMaxMessageSize sets the largest IMQ message read, in bytes. Larger
+// messages are skipped. Zero doesn't limit the size.
+func WithIMQMaxMessageSize(size int64) ClientOption {
+	return func(c *HTTPClient) {
+		c.imqMaxMessage = size
+	}
+}
+
 func (c *HTTPClient) Request(method, path string, body any, headers map[string]string) (*http.Response, error) {
-	fullURL := c.baseURL + path
+	fullURL := c.endpoints.REST + path
+
+	ctx, span := tracer.Start(context.Background(), method+" "+path,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", method),
+			attribute.String("url.full", fullURL),
+		),
+	)
+	defer span.End()
 
 	var bodyReader io.Reader
 	if body != nil {
@@ -100,11 +191,36 @@ func (c *HTTPClient) Request(method, path string, body any, headers map[string]s
 		bodyReader = bytes.NewReader(jsonBody)
 	}
 
-	req, err := http.NewRequest(method, fullURL, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	// Fresh cached responses are served without touching the network or the
+	// rate limits
+	var cached *cacheEntry
+	if method == http.MethodGet && c.cache != nil {
+		cached = c.cache.get(path)
+		if cached != nil && cached.fresh() {
+			metrics.HTTPCacheHits.Add(1)
+			span.SetAttributes(attribute.Bool("http.cache_hit", true))
+			return cached.response(req), nil
+		}
+	}
+
+	if err := c.wait(ctx, path); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "rate limited")
+		return nil, err
+	}
+
+	group := limitCategory(path)
+	if err := c.breakers.allow(group); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "circuit open")
+		return nil, fmt.Errorf("%s endpoints: %w", group, err)
+	}
+
 	req.Header.Set("User-Agent", c.userAgent)
 
 	for key, value := range c.headers {
@@ -116,14 +232,33 @@ func (c *HTTPClient) Request(method, path string, body any, headers map[string]s
 	}
 
 	if req.Header.Get("Referer") == "" {
-		req.Header.Set("Referer", "https://pt.secure.imvu.com/")
+		req.Header.Set("Referer", c.endpoints.SecureOrigin+"/")
+	}
+
+	if cached != nil {
+		cached.validate(req)
 	}
 
 	resp, err := c.httpClient.Do(req)
+	c.breakers.record(group, err != nil || resp.StatusCode >= 500)
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "request failed")
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 
+	if resp.StatusCode < 500 {
+		c.lastSuccess.Store(time.Now().UnixNano())
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+
+	if method == http.MethodGet && c.cache != nil {
+		return c.cache.update(path, cached, resp)
+	}
 	return resp, nil
 }
 
@@ -0,0 +1,188 @@
+package imvu
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"giiny/internal/events"
+)
+
+// handleChatMessage publishes the messages of a room chat queue as events
+func (i *IMVU) handleChatMessage(msg Message) {
+	i.touchRoom()
+	if msg.Record != "msg_g2c_send_message" {
+		return
+	}
+
+	var chatMessage ChatMessagePayload
+	if err := json.Unmarshal(msg.Payload, &chatMessage); err != nil {
+		log.Printf("Failed to unmarshal inner chat message: %v", err)
+		return
+	}
+
+	now := time.Now()
+
+	// The sender's op_id increases with every message it sends, so together
+	// with the content it identifies a delivery that IMQ replayed. Without
+	// one, a repeated message can't be told from a replay and is kept.
+	key := fmt.Sprintf("%s|%s|%d|%s", msg.Queue, chatMessage.UserID.String(), msg.OpID, chatMessage.Message)
+	if msg.OpID != 0 && i.delivered.Seen(key, now) {
+		log.Printf("Dropping duplicate message from %s on %s", chatMessage.UserID.String(), msg.Queue)
+		return
+	}
+
+	self := chatMessage.UserID.String() == i.UserID()
+	if self {
+		i.confirmEcho(msg.Queue, msg.OpID, chatMessage.Message)
+	}
+
+	parsed := ParseMessage(chatMessage.Message)
+	// The echoes of the bot's own avatar commands are not chat; Exec already
+	// published them as CommandExecuted. Kept out, they can't end up in the
+	// transcript, the room stats or Gemini's context.
+	if self && parsed.Kind == events.MessageCommand {
+		return
+	}
+	events.Publish(i.Events, events.ChatMessage{
+		Queue:      msg.Queue,
+		ChatID:     chatMessage.ChatID.String(),
+		UserID:     chatMessage.UserID.String(),
+		Sender:     i.Sender(chatMessage.UserID.String()),
+		SenderName: chatMessage.DisplayName,
+		To:         chatMessage.WhisperTarget(),
+		Message:    chatMessage.Message,
+		Kind:       parsed.Kind,
+		Text:       parsed.Text,
+		Emotes:     parsed.Emotes,
+		Flags:      chatMessage.Flags,
+		SentAt:     chatMessage.SentAt(),
+		ReceivedAt: now,
+	})
+
+	if to := chatMessage.WhisperTarget(); to != "" {
+		events.Publish(i.Events, events.Whisper{
+			From:       chatMessage.UserID.String(),
+			To:         to,
+			Message:    chatMessage.Message,
+			ReceivedAt: now,
+		})
+	}
+}
+
+// handleWalletMessage refreshes the wallet on any wallet queue traffic
+func (i *IMVU) handleWalletMessage(Message) {
+	go i.refreshWallet()
+}
+
+// handleRoomMessage checks the bot's participation in the room, since
+// participant changes, including the bot being kicked, are announced on the
+// room queue
+func (i *IMVU) handleRoomMessage(Message) {
+	i.touchRoom()
+	i.checkRoom()
+}
+
+// watchStates handles the state changes of the IMQ connection until it is
+// closed
+func (i *IMVU) watchStates(states <-chan StateChange) {
+	for change := range states {
+		i.handleStateChange(change)
+		if change.New == StateClosed {
+			return
+		}
+	}
+}
+
+// handleStateChange publishes Reconnected whenever IMQ authenticates again
+// after the first connection, and Disconnected when an established connection
+// is lost
+func (i *IMVU) handleStateChange(change StateChange) {
+	if change.New == StateWaiting && i.imqConnected.Load() {
+		events.Publish(i.Events, events.Disconnected{At: change.At, NextAttempt: change.NextConnectTime})
+		return
+	}
+	if change.New != StateAuthenticated {
+		return
+	}
+
+	if !i.imqConnected.Swap(true) {
+		return
+	}
+
+	events.Publish(i.Events, events.Reconnected{At: change.At})
+	i.checkRoom()
+}
+
+// refreshWallet fetches the wallet and publishes CreditsChanged if the balance
+// is different from the last known one
+func (i *IMVU) refreshWallet() {
+	wallet, err := i.api.GetWallet(i.UserID())
+	if err != nil {
+		log.Printf("Failed to refresh wallet: %v", err)
+		return
+	}
+
+	i.walletMu.Lock()
+	previous := i.wallet
+	i.wallet = wallet
+	i.walletMu.Unlock()
+
+	if previous == nil || *previous == *wallet {
+		return
+	}
+
+	events.Publish(i.Events, events.CreditsChanged{
+		Credits:      wallet.Credits,
+		PromoCredits: wallet.PromoCredits,
+		Delta:        (wallet.Credits + wallet.PromoCredits) - (previous.Credits + previous.PromoCredits),
+	})
+}
+
+// refreshRoster fetches the participant list of the current room and publishes
+// UserJoined and UserLeft for the differences. The first call for a room only
+// records the roster. It reports whether the bot itself is still a participant.
+func (i *IMVU) refreshRoster(roomID, roomChatID string, publish bool) (bool, error) {
+	participants, err := i.api.GetParticipants(roomID, roomChatID)
+	if err != nil {
+		return false, err
+	}
+
+	current := make(map[string]int, len(participants))
+	for _, p := range participants {
+		current[p.UserID] = p.SeatNumber
+	}
+	_, present := current[i.UserID()]
+
+	i.rosterMu.Lock()
+	previous := i.roster
+	i.roster = current
+	i.rosterMu.Unlock()
+
+	if !publish {
+		return present, nil
+	}
+
+	for userID, seat := range current {
+		if _, ok := previous[userID]; !ok {
+			events.Publish(i.Events, events.UserJoined{
+				OwnerID:    roomID,
+				ChatroomID: roomChatID,
+				UserID:     userID,
+				SeatNumber: seat,
+			})
+		}
+	}
+	for userID := range previous {
+		if _, ok := current[userID]; !ok {
+			events.Publish(i.Events, events.UserLeft{
+				OwnerID:    roomID,
+				ChatroomID: roomChatID,
+				UserID:     userID,
+			})
+		}
+	}
+
+	return present, nil
+}
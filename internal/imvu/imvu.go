@@ -2,65 +2,201 @@ package imvu
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"giiny/internal/events"
+	"giiny/internal/metrics"
+)
+
+const (
+	// maxOperationID is the largest op_id handed out; op_ids stay within 32
+	// bits so they fit whatever integer the server parses them into
+	maxOperationID = math.MaxInt32
+	// opIDsPerSecond is the rate of op_ids since opIDEpoch the seed leaves
+	// room for. A restarted process continues after the op_ids of the
+	// previous one unless that one went faster on average.
+	opIDsPerSecond = 10
 )
 
+// opIDEpoch is the time the op_id seed counts from
+var opIDEpoch = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// OperationID hands out the op_ids of IMQ messages. It is seeded from the
+// clock, so op_ids keep increasing across restarts instead of starting over
+// at 0 and looking like replays to the server.
 type OperationID struct {
 	ID int
 	sync.Mutex
 }
 
+// NewOperationID returns an OperationID seeded from the current time
+func NewOperationID() *OperationID {
+	seed := int(time.Since(opIDEpoch).Seconds()) * opIDsPerSecond
+	return &OperationID{ID: max(seed, 0) % maxOperationID}
+}
+
+// GetNew returns the next op_id
 func (o *OperationID) GetNew() int {
+	return o.GetBatch(1)
+}
+
+// GetBatch reserves n contiguous op_ids and returns the first one. A range
+// that would go past maxOperationID starts over from 1 instead, so a batch is
+// never split by the wrap around.
+func (o *OperationID) GetBatch(n int) int {
 	o.Lock()
 	defer o.Unlock()
 
-	// Increment the ID and return it
-	result := o.ID
-	o.ID++
-	return result
+	if o.ID > maxOperationID-n {
+		log.Printf("IMQ op_ids reached %d, starting over from 1", o.ID)
+		o.ID = 1
+	}
+	first := o.ID
+	o.ID += n
+	return first
 }
 
 type Room struct {
 	OwnerID    string
 	ChatroomID string
 	ChatQueue  string
+	// Observing is set when the room is followed with Observe, without the
+	// avatar joining it
+	Observing bool
 }
 
 type IMVU struct {
-	Authenticated      bool
-	UserID             string
-	User               *User
-	sauce              string
-	api                *API
-	opID               *OperationID
-	currentRoom        *Room
-	roomCancelFunc     context.CancelFunc
-	ChatMessageChannel chan ChatMessagePayload
-}
-
-func New() (*IMVU, error) {
+	Events *events.Bus
+	// userID, user and authenticated describe the session of the last Login,
+	// read with UserID, User and Authenticated
+	userID        atomic.Value
+	user          atomic.Pointer[User]
+	authenticated atomic.Bool
+	// sessionMu serializes logging in with joining, leaving and observing
+	// rooms, so the supervisor rebuilding the session doesn't interleave with
+	// the bot moving between rooms, and guards roomCancelFunc
+	sessionMu sync.Mutex
+	sauce     string
+	api       *API
+	opID      *OperationID
+	// currentRoom is replaced, never modified, so readers can keep the Room
+	// they loaded
+	currentRoom    atomic.Pointer[Room]
+	roomCancelFunc context.CancelFunc
+	roomCheck      chan struct{}
+	notifyCheck    chan struct{}
+	delivered      *dedupe
+	deliveries     *deliveries
+	imqConnected   atomic.Bool
+	dryRun         atomic.Bool
+	catalogWanted  atomic.Pointer[func() bool]
+	username       string
+	password       string
+	maxMessage     atomic.Int64
+	walletMu       sync.Mutex
+	badgeMu        sync.Mutex
+	badgeProgress  *BadgeProgress
+	badgeExpires   time.Time
+	wallet         *Wallet
+	rosterMu       sync.Mutex
+	roster         map[string]int
+	outfitMu       sync.Mutex
+	outfit         []string
+	triggers       map[string]string
+	joinActions    atomic.Pointer[JoinActions]
+	tryMu          sync.Mutex
+	tryingOn       string
+	tryTimer       *time.Timer
+	users          *UserCache
+	bots           atomic.Pointer[map[string]bool]
+	// following are the IDs of the users the bot follows, as of the last
+	// check of WatchFollowing
+	followingMu sync.Mutex
+	following   map[string]bool
+	// loggedOut is set by Logout until the next Login
+	loggedOut    atomic.Bool
+	staleChat    atomic.Int64
+	roomActivity atomic.Int64
+}
+
+const (
+	// imqConnectTimeout is how long Login waits for IMQ to authenticate
+	imqConnectTimeout = 15 * time.Second
+	// subscribeTimeout is how long Login waits for the server to confirm the
+	// subscriptions to the user's queues
+	subscribeTimeout = 5 * time.Second
+)
+
+func New(options ...ClientOption) (*IMVU, error) {
 	imvu := &IMVU{
-		opID: &OperationID{},
+		opID:        NewOperationID(),
+		Events:      events.NewBus(),
+		roomCheck:   make(chan struct{}, 1),
+		notifyCheck: make(chan struct{}, 1),
+		delivered:   newDedupe(dedupeWindow),
+		deliveries:  newDeliveries(),
 	}
+	imvu.maxMessage.Store(defaultMaxMessageBytes)
+	imvu.staleChat.Store(int64(defaultStaleChat))
 
-	api, err := NewAPI(imvu.opID)
+	api, err := NewAPI(imvu.opID, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create IMVU API client: %w", err)
 	}
 
 	imvu.api = api
+	imvu.users = NewUserCache(DefaultUserTTL, api.GetUser)
+	api.client.breakers.onChange = func(group string, state BreakerState) {
+		events.Publish(imvu.Events, events.CircuitChanged{
+			Group: group,
+			State: state.String(),
+			At:    time.Now(),
+		})
+	}
 	return imvu, nil
 }
 
+// UserID returns the ID of the logged in user
+func (i *IMVU) UserID() string {
+	id, _ := i.userID.Load().(string)
+	return id
+}
+
+// User returns the logged in user as of the last Login, or nil before it
+func (i *IMVU) User() *User {
+	return i.user.Load()
+}
+
+// Authenticated reports whether the client is logged in
+func (i *IMVU) Authenticated() bool {
+	return i.authenticated.Load()
+}
+
 func (i *IMVU) Login(username, password string) error {
+	i.sessionMu.Lock()
+	defer i.sessionMu.Unlock()
+	return i.login(username, password)
+}
+
+func (i *IMVU) login(username, password string) error {
 	err := i.api.Authenticate(username, password)
 	if err != nil {
 		return fmt.Errorf("authentication failed: %w", err)
 	}
+	i.loggedOut.Store(false)
+
+	version, err := i.api.DetectVersion()
+	if err != nil {
+		return fmt.Errorf("failed to detect the API version: %w", err)
+	}
+	log.Printf("Using the %s IMVU API", version)
 
 	me, err := i.api.Me()
 	if err != nil {
@@ -68,19 +204,19 @@ func (i *IMVU) Login(username, password string) error {
 	}
 
 	urlFields := strings.Split(me.User.ID, "/")
-	i.UserID = urlFields[len(urlFields)-1]
+	userID := urlFields[len(urlFields)-1]
+	i.userID.Store(userID)
 
-	user, err := i.api.GetUser(i.UserID)
+	user, err := i.api.GetUser(userID)
 	if err != nil {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
-	i.ChatMessageChannel = make(chan ChatMessagePayload)
-
-	err = i.api.ConnectMsgStream(i.UserID, i.ChatMessageChannel)
+	err = i.api.ConnectMsgStream(userID)
 	if err != nil {
 		return fmt.Errorf("failed to connect to messages stream: %w", err)
 	}
+	go i.watchStates(i.api.States())
 
 	queues := []string{
 		"inv:/user/user-%s",
@@ -88,7 +224,7 @@ func (i *IMVU) Login(username, password string) error {
 		"/user/%s",
 		"inv:/wallet/wallet-%s",
 		"inv:/roulette/roulette-%s",
-		"inv:/store_catalog/store_catalog-next",
+		catalogQueue,
 		//"inv:/user/user-362179840",
 		//"inv:/eligible_quest_event/eligible_quest_event-%s-309",
 		//"inv:/eligible_quest_event/eligible_quest_event-%s-300",
@@ -113,54 +249,96 @@ func (i *IMVU) Login(username, password string) error {
 		"inv:/avatar/avatar-%s",
 	}
 
-	time.Sleep(time.Second * 1)
+	if err := i.waitConnected(imqConnectTimeout); err != nil {
+		return err
+	}
+
+	subs := make([]Subscription, 0, len(queues))
 	for _, qName := range queues {
 		if strings.Contains(qName, "%s") {
-			qName = fmt.Sprintf(qName, i.UserID)
+			qName = fmt.Sprintf(qName, userID)
 		}
-		i.api.SubscribeToQueue(qName, i.opID.GetNew())
-		time.Sleep(time.Millisecond * 200)
+
+		sub := Subscription{Queue: qName}
+		switch {
+		case strings.HasPrefix(qName, "inv:/wallet/"):
+			sub.Handler = i.handleWalletMessage
+		case strings.HasPrefix(qName, "private:/user/"):
+			sub.Handler = i.handlePrivateMessage
+		case qName == catalogQueue:
+			sub.Handler = i.handleCatalogMessage
+		case strings.HasPrefix(qName, "inv:/user/"), strings.HasPrefix(qName, "inv:/profile/"):
+			sub.Handler = i.handleProfileMessage
+		}
+		subs = append(subs, sub)
+	}
+	if err := i.api.SubscribeAll(subs, subscribeTimeout); err != nil {
+		log.Printf("Some IMQ subscriptions failed: %v", err)
 	}
 
 	i.api.client.AddHeader("X-Imvu-Application", "next_desktop/1")
 	i.api.client.AddHeader("X-Imvu-Sauce", me.Sauce)
 	i.sauce = me.Sauce
-	i.Authenticated = true
-	i.User = user
+	i.username, i.password = username, password
+	i.authenticated.Store(true)
+	i.user.Store(user)
 
+	go i.refreshWallet()
+
+	return nil
+}
+
+// waitConnected waits until IMQ is authenticated
+func (i *IMVU) waitConnected(timeout time.Duration) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	deadline := time.After(timeout)
+
+	for !i.api.IsWebSocketConnected() {
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			return fmt.Errorf("IMQ not authenticated after %s", timeout)
+		}
+	}
 	return nil
 }
 
-func (i *IMVU) JoinRoom(roomID, roomChatID string) error {
+// JoinRoom enters the room's chat and subscribes to its queues. The result
+// tells where the avatar landed.
+//
+// Full rooms and Access Pass rooms the bot can't enter are refused with
+// ErrRoomFull and ErrRoomAP, unless the bot is rejoining its current room.
+func (i *IMVU) JoinRoom(roomID, roomChatID string) (*JoinResult, error) {
+	i.sessionMu.Lock()
+	defer i.sessionMu.Unlock()
+	return i.joinRoom(roomID, roomChatID)
+}
+
+func (i *IMVU) joinRoom(roomID, roomChatID string) (*JoinResult, error) {
+	current := i.currentRoom.Load()
+	rejoin := current != nil && current.OwnerID == roomID && current.ChatroomID == roomChatID
+	if !rejoin {
+		if err := i.checkJoinable(roomID, roomChatID); err != nil {
+			return nil, fmt.Errorf("refusing to join room %s-%s: %w", roomID, roomChatID, err)
+		}
+	}
+
 	if i.roomCancelFunc != nil {
 		i.roomCancelFunc()
 	}
 
-	err := i.api.JoinRoom(roomID, roomChatID)
+	result, err := i.api.JoinRoom(roomID, roomChatID)
 	if err != nil {
-		return fmt.Errorf("failed to join room: %w", err)
+		return nil, fmt.Errorf("failed to join room: %w", err)
 	}
 
 	var ctx context.Context
 	ctx, i.roomCancelFunc = context.WithCancel(context.Background())
 
-	go func() {
-		ticker := time.NewTicker(1 * time.Minute)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				log.Printf("Rejoining room %s-%s after 1 minute", roomID, roomChatID)
-				err := i.api.JoinRoom(roomID, roomChatID)
-				if err != nil {
-					log.Printf("Failed to rejoin room %s-%s: %v", roomID, roomChatID, err)
-				}
-			case <-ctx.Done():
-				log.Printf("Stopping rejoining room %s-%s", roomID, roomChatID)
-				return
-			}
-		}
-	}()
+	go i.keepInRoom(ctx, roomID, roomChatID)
+	i.touchRoom()
+	go i.watchChatActivity(ctx, roomID, roomChatID)
 
 	go func() {
 		ticker := time.NewTicker(2 * time.Minute)
@@ -168,83 +346,253 @@ func (i *IMVU) JoinRoom(roomID, roomChatID string) error {
 		for {
 			select {
 			case <-ticker.C:
-				log.Printf("Changing availability for user %s", i.UserID)
-				err := i.api.ChangeAvalability(i.UserID)
+				log.Printf("Changing availability for user %s", i.UserID())
+				err := i.api.ChangeAvalability(i.UserID())
 				if err != nil {
-					log.Printf("Failed to change availability for user %s: %v", i.UserID, err)
+					log.Printf("Failed to change availability for user %s: %v", i.UserID(), err)
 				}
 			case <-ctx.Done():
-				log.Printf("Stopping availability changes for user %s", i.UserID)
+				log.Printf("Stopping availability changes for user %s", i.UserID())
 				return
 			}
 		}
 	}()
 
-	sceneQueue := fmt.Sprintf("inv:/scene/scene-%s-%s", roomID, roomChatID)
-	i.api.SubscribeToQueue(sceneQueue, i.opID.GetNew())
-
-	roomQueue := fmt.Sprintf("inv:/room/room-%s-%s", roomID, roomChatID)
-	i.api.SubscribeToQueue(roomQueue, i.opID.GetNew())
+	// The queues of the room the bot is leaving would keep delivering
+	if previous := current; previous != nil && !rejoin {
+		i.api.Unsubscribe(sceneQueue(previous.OwnerID, previous.ChatroomID), roomQueue(previous.OwnerID, previous.ChatroomID))
+	}
+	i.api.Subscribe(sceneQueue(roomID, roomChatID), i.handleSceneMessage)
+	i.api.Subscribe(roomQueue(roomID, roomChatID), i.handleRoomMessage)
 
 	chatQueue, err := i.api.GetRoomChatQueue(roomID, roomChatID)
 	if err != nil {
-		return fmt.Errorf("failed to get room chat ID: %w", err)
+		return nil, fmt.Errorf("failed to get room chat ID: %w", err)
+	}
+	i.api.Subscribe(chatQueue, i.handleChatMessage)
+	result.ChatQueue = chatQueue
+	if previous := i.currentRoom.Load(); previous != nil && previous.ChatQueue != chatQueue {
+		i.dropChatQueue(previous.ChatQueue, chatQueue)
 	}
-	i.api.SubscribeToQueue(chatQueue, i.opID.GetNew())
 
-	i.currentRoom = &Room{
+	i.currentRoom.Store(&Room{
 		OwnerID:    roomID,
 		ChatroomID: roomChatID,
 		ChatQueue:  chatQueue,
+	})
+	if _, err := i.refreshRoster(roomID, roomChatID, false); err != nil {
+		log.Printf("Failed to refresh roster of room %s-%s: %v", roomID, roomChatID, err)
 	}
 
-	time.Sleep(1 * time.Second)
-
-	// TODO: Test how CmdPutOnOutfit and CmdUse work. Maybe create a function to handle the player outfits?
-	outfitItemIDS := []string{
-		"69320200", "70312022", "12444122", "13831030", "16070306", "19442649", "23974249", "55139083", "55595518", "63520397", "63520471", "70082645", "70082730", "55595754", "61753525", "62845575", "59508957", "63520653", "63520746",
+	if !rejoin {
+		metrics.RoomsJoined.Add(1)
 	}
 
-	i.Exec(CmdImvuIsPureUser)
-	i.Exec(CmdPutOnOutfit, outfitItemIDS...)
-	i.Exec(CmdUse, outfitItemIDS...)
+	time.Sleep(1 * time.Second)
+	i.runJoinActions(rejoin)
 
-	return nil
+	return result, nil
 }
 
 func (i *IMVU) LeaveRoom(roomID, chatID string) error {
+	i.sessionMu.Lock()
+	defer i.sessionMu.Unlock()
+
 	if i.roomCancelFunc != nil {
 		i.roomCancelFunc()
 		i.roomCancelFunc = nil
 	}
 
-	err := i.api.LeaveRoom(roomID, chatID, i.UserID)
-	if err != nil {
-		return fmt.Errorf("failed to leave room: %w", err)
+	room := i.currentRoom.Load()
+	if room == nil || !room.Observing {
+		if err := i.api.LeaveRoom(roomID, chatID, i.UserID()); err != nil {
+			return fmt.Errorf("failed to leave room: %w", err)
+		}
 	}
 
-	i.currentRoom = nil
+	if room != nil {
+		queues := []string{roomQueue(room.OwnerID, room.ChatroomID), room.ChatQueue}
+		if !room.Observing {
+			queues = append(queues, sceneQueue(room.OwnerID, room.ChatroomID))
+		}
+		i.api.Unsubscribe(queues...)
+	}
+	i.currentRoom.Store(nil)
 	return nil
 }
 
+// ErrCommandText is returned when a chat message, or a part of it once
+// split, would run as an avatar command. Only Exec sends commands, so text
+// from Gemini, auto replies or plugins can't gift or buy behind the
+// spending limits.
+var ErrCommandText = errors.New("chat message would run as an avatar command")
+
+// SendChatMessage sends a message to the current room. Messages that would
+// run as avatar commands are refused with ErrCommandText.
 func (i *IMVU) SendChatMessage(message string) error {
-	if i.currentRoom == nil {
+	return i.sendText("0", message)
+}
+
+// SendWhisper sends a message in the current room that only the given user
+// sees. Messages that would run as avatar commands are refused with
+// ErrCommandText.
+func (i *IMVU) SendWhisper(userID, message string) error {
+	return i.sendText(userID, message)
+}
+
+// sendText sends a message that must not contain avatar commands
+func (i *IMVU) sendText(to, message string) error {
+	for _, part := range splitMessage(message, int(i.maxMessage.Load())) {
+		if isCommandText(part) {
+			return ErrCommandText
+		}
+	}
+	return i.sendChatMessage(to, message)
+}
+
+// isCommandText reports whether IMVU would take the message for an avatar
+// command or a system notice rather than chat
+func isCommandText(message string) bool {
+	kind := ParseMessage(message).Kind
+	return kind == events.MessageCommand || kind == events.MessageSystem
+}
+
+// SetDryRun enables or disables dry-run mode. In dry-run mode chat messages,
+// whispers and avatar commands are logged instead of being sent to the room.
+func (i *IMVU) SetDryRun(enabled bool) {
+	i.dryRun.Store(enabled)
+}
+
+// DryRun reports whether dry-run mode is enabled
+func (i *IMVU) DryRun() bool {
+	return i.dryRun.Load()
+}
+
+// SetMaxMessageBytes sets the length at which chat messages are split into
+// several paced messages. Zero disables splitting.
+func (i *IMVU) SetMaxMessageBytes(limit int) {
+	i.maxMessage.Store(int64(limit))
+}
+
+func (i *IMVU) sendChatMessage(to, message string) error {
+	if i.currentRoom.Load() == nil {
 		return fmt.Errorf("not in a room, cannot send message")
 	}
 
-	room := i.currentRoom
+	for n, part := range splitMessage(message, int(i.maxMessage.Load())) {
+		if n > 0 {
+			time.Sleep(messagePace)
+		}
+		if err := i.sendChatPart(to, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendChatPart sends a message that fits in a single chat message
+func (i *IMVU) sendChatPart(to, message string) error {
+	room := i.currentRoom.Load()
+	if room == nil {
+		return fmt.Errorf("not in a room, cannot send message")
+	}
+	if room.Observing {
+		return ErrObserving
+	}
+
+	if i.dryRun.Load() {
+		if to == "0" {
+			log.Printf("[dry-run] Would send: %s", message)
+		} else {
+			log.Printf("[dry-run] Would whisper to %s: %s", to, message)
+		}
+		return nil
+	}
+
+	if to == "0" {
+		return i.deliver(room, message, false)
+	}
 
 	payload := ChatMessagePayload{
 		ChatID:  StringOrInt(room.ChatroomID),
 		Message: message,
-		To:      StringOrInt("0"),
-		UserID:  StringOrInt(i.UserID),
+		To:      StringOrInt(to),
+		UserID:  StringOrInt(i.UserID()),
+	}
+
+	return i.api.SendChatMessage(room.ChatQueue, "messages", i.opID.GetNew(), payload)
+}
+
+// Rooms returns the rooms owned by the given user
+func (i *IMVU) Rooms(userID string) ([]RoomInfo, error) {
+	return i.api.GetUserRooms(userID)
+}
+
+// ChatQueue returns the IMQ queue of the current room's chat, or an empty
+// string when not in a room
+func (i *IMVU) ChatQueue() string {
+	room := i.currentRoom.Load()
+	if room == nil {
+		return ""
+	}
+	return room.ChatQueue
+}
+
+// Participants returns the IDs of the users in the current room as of the
+// last refresh of the participant list
+func (i *IMVU) Participants() []string {
+	i.rosterMu.Lock()
+	defer i.rosterMu.Unlock()
+
+	userIDs := make([]string, 0, len(i.roster))
+	for userID := range i.roster {
+		userIDs = append(userIDs, userID)
 	}
+	return userIDs
+}
+
+// Connected reports whether the IMQ WebSocket is currently connected
+func (i *IMVU) Connected() bool {
+	return i.api.IsWebSocketConnected()
+}
+
+// ConnectionState returns the state of the IMQ WebSocket, when it entered it
+// and when the last message was received
+func (i *IMVU) ConnectionState() (state State, since, lastMessage time.Time) {
+	ws := i.api.ws.Load()
+	if ws == nil {
+		return StateClosed, time.Time{}, time.Time{}
+	}
+	state, since = ws.StateSince()
+	return state, since, ws.LastMessageTime()
+}
 
-	i.api.SendChatMessage(
-		room.ChatQueue,
-		"messages",
-		payload,
-	)
+// Logout ends the session and disconnects from IMQ. Supervise leaves the
+// session alone until the next Login.
+func (i *IMVU) Logout() error {
+	i.sessionMu.Lock()
+	defer i.sessionMu.Unlock()
+
+	i.loggedOut.Store(true)
+	i.close()
+	i.authenticated.Store(false)
+	if err := i.api.Logout(); err != nil {
+		return fmt.Errorf("failed to log out: %w", err)
+	}
 	return nil
 }
+
+// Close disconnects from IMQ and stops the room background tasks
+func (i *IMVU) Close() {
+	i.sessionMu.Lock()
+	defer i.sessionMu.Unlock()
+	i.close()
+}
+
+func (i *IMVU) close() {
+	if i.roomCancelFunc != nil {
+		i.roomCancelFunc()
+		i.roomCancelFunc = nil
+	}
+	i.api.CloseWebSocket()
+}
@@ -0,0 +1,55 @@
+package imvu
+
+import "log"
+
+// JoinActions is what the avatar does after joining a room, in this order:
+// tell the room it is a pure user, put on Outfit, sit on Seat, send Greeting
+// and play the Emotes triggers. The greeting is not sent again when rejoining
+// the same room. Nothing is done by default.
+type JoinActions struct {
+	PureUser bool
+	Outfit   []string
+	Seat     *Seat
+	Greeting string
+	Emotes   []string
+}
+
+// SetJoinActions sets what the avatar does after joining a room
+func (i *IMVU) SetJoinActions(actions JoinActions) {
+	i.joinActions.Store(&actions)
+}
+
+// runJoinActions runs the join actions. Failures are logged, they don't undo
+// the join.
+func (i *IMVU) runJoinActions(rejoin bool) {
+	actions := i.joinActions.Load()
+	if actions == nil {
+		return
+	}
+
+	if actions.PureUser {
+		if err := i.Exec(CmdImvuIsPureUser); err != nil {
+			log.Printf("Failed to declare a pure user: %v", err)
+		}
+	}
+	if len(actions.Outfit) > 0 {
+		if err := i.ChangeOutfit("", actions.Outfit...); err != nil {
+			log.Printf("Failed to put on the join outfit: %v", err)
+		}
+	}
+	if seat := actions.Seat; seat != nil {
+		if err := i.SitOn("", seat.UserID, seat.FurniID, seat.SeatNumber); err != nil {
+			log.Printf("Failed to sit on the join seat: %v", err)
+		}
+	}
+	if actions.Greeting != "" && !rejoin {
+		if err := i.SendChatMessage(actions.Greeting); err != nil {
+			log.Printf("Failed to send the join greeting: %v", err)
+		}
+	}
+	for _, emote := range actions.Emotes {
+		if err := i.TriggerAction("", emote); err != nil {
+			log.Printf("Failed to play join emote %s: %v", emote, err)
+		}
+	}
+}
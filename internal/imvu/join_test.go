@@ -0,0 +1,92 @@
+package imvu_test
+
+import (
+	"testing"
+	"time"
+
+	"giiny/internal/events"
+	"giiny/internal/imvu"
+	"giiny/internal/imvutest"
+)
+
+func TestJoinRoomAndEcho(t *testing.T) {
+	srv := imvutest.NewServer()
+	defer srv.Close()
+	srv.AddUser("100", "giiny", "secret")
+	srv.AddUser("200", "owner", "secret")
+	room := srv.AddRoom("200", "1")
+
+	// Logging in takes more requests than the auth limit lets through at once
+	options := append(srv.ClientOptions(), imvu.WithRateLimits(map[string]imvu.RateLimit{
+		imvu.LimitAuth: {PerSecond: 100, Burst: 10},
+	}))
+	client, err := imvu.New(options...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer client.Close()
+	client.SetEchoTimeout(200 * time.Millisecond)
+
+	if err := client.Login("giiny", "secret"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if _, err := client.JoinRoom(room.OwnerID, room.ChatroomID); err != nil {
+		t.Fatalf("JoinRoom: %v", err)
+	}
+	if !srv.IsParticipant(room.OwnerID, room.ChatroomID, "100") {
+		t.Fatal("not a participant of the room after joining")
+	}
+	waitFor(t, "chat queue subscription", func() bool { return srv.Subscribed("100", room.Queue) })
+
+	chat := events.Subscribe[events.ChatMessage](client.Events, 8)
+	defer chat.Close()
+	failed := events.Subscribe[events.DeliveryFailed](client.Events, 1)
+	defer failed.Close()
+
+	srv.SendChat(room, "200", "hello giiny")
+	select {
+	case msg := <-chat.C:
+		if msg.UserID != "200" || msg.Message != "hello giiny" {
+			t.Errorf("got message %q from %s, want %q from 200", msg.Message, msg.UserID, "hello giiny")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("message from the room never arrived")
+	}
+
+	if err := client.SendChatMessage("hi owner"); err != nil {
+		t.Fatalf("SendChatMessage: %v", err)
+	}
+	select {
+	case msg := <-chat.C:
+		if msg.UserID != "100" || msg.Message != "hi owner" {
+			t.Errorf("got message %q from %s, want the echo of %q", msg.Message, msg.UserID, "hi owner")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("sent message was never echoed")
+	}
+
+	// An echoed message is neither sent again nor reported as lost
+	select {
+	case f := <-failed.C:
+		t.Errorf("delivery of %q failed", f.Message)
+	case <-time.After(500 * time.Millisecond):
+	}
+	sent := srv.SentMessages()
+	if len(sent) != 1 {
+		t.Fatalf("server got %d messages, want 1", len(sent))
+	}
+	if sent[0].Queue != room.Queue || sent[0].Payload.Message != "hi owner" {
+		t.Errorf("server got %q on %s, want %q on %s", sent[0].Payload.Message, sent[0].Queue, "hi owner", room.Queue)
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
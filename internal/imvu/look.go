@@ -0,0 +1,76 @@
+package imvu
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrNotParticipant is returned when looking at a user who is not in the room
+var ErrNotParticipant = errors.New("user is not in the room")
+
+// maxLookItems bounds how many products of a look are looked up in the
+// catalog
+const maxLookItems = 30
+
+// Look returns up to limit products worn by a participant of the current room,
+// and how many they wear in total. A limit of 0 returns up to maxLookItems.
+// Items whose catalog entry can't be fetched only have their product ID.
+func (i *IMVU) Look(userID string, limit int) ([]ProductItem, int, error) {
+	p, err := i.participant(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if p.LookURL == "" {
+		return nil, 0, nil
+	}
+
+	productIDs, err := i.api.GetLookProducts(p.LookURL)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if limit <= 0 || limit > maxLookItems {
+		limit = maxLookItems
+	}
+	items := make([]ProductItem, 0, min(len(productIDs), limit))
+	for _, productID := range productIDs[:min(len(productIDs), limit)] {
+		item := ProductItem{ProductID: productID}
+		if product, err := i.api.GetProduct(productID); err == nil {
+			item.Product = *product
+		} else {
+			log.Printf("Failed to get product %s: %v", productID, err)
+		}
+		items = append(items, item)
+	}
+	return items, len(productIDs), nil
+}
+
+// LookImage returns the URL of a picture of the avatar of a participant of the
+// current room in their current outfit, or an empty string if IMVU has none
+func (i *IMVU) LookImage(userID string) (string, error) {
+	p, err := i.participant(userID)
+	if err != nil {
+		return "", err
+	}
+	return p.Image(), nil
+}
+
+// participant returns the chat data of a participant of the current room
+func (i *IMVU) participant(userID string) (*Participant, error) {
+	room := i.currentRoom.Load()
+	if room == nil {
+		return nil, fmt.Errorf("not in a room, cannot look at users")
+	}
+
+	participants, err := i.api.GetParticipants(room.OwnerID, room.ChatroomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get participants: %w", err)
+	}
+	for _, p := range participants {
+		if p.UserID == userID {
+			return &p, nil
+		}
+	}
+	return nil, ErrNotParticipant
+}
@@ -0,0 +1,60 @@
+package imvu
+
+import (
+	"regexp"
+	"strings"
+
+	"giiny/internal/events"
+)
+
+// emotePattern matches emote tokens such as *tomato* or *wave_2*
+var emotePattern = regexp.MustCompile(`\*[\p{L}\p{N}_:-]+\*`)
+
+// ParsedMessage is a chat message with its IMVU markup taken apart
+type ParsedMessage struct {
+	// Kind is one of events.MessageText, MessageAction, MessageCommand or
+	// MessageSystem
+	Kind string
+	// Text is the message without the markup: the words of a text message,
+	// what the user does in an action, the command line of a command or the
+	// notice of a system message
+	Text string
+	// Emotes are the names of the emotes in the message, without asterisks
+	Emotes []string
+}
+
+// ParseMessage classifies a raw chat message. "/me waves" is an action,
+// "*msg ..." a system notice and any other message starting with "*" an
+// avatar command, unless it starts with an emote like "*tomato*". Emotes are
+// extracted from text and action messages.
+func ParseMessage(raw string) ParsedMessage {
+	text := strings.TrimSpace(raw)
+
+	if rest, ok := strings.CutPrefix(text, "/me "); ok {
+		parsed := parseEmotes(rest)
+		parsed.Kind = events.MessageAction
+		return parsed
+	}
+
+	if strings.HasPrefix(text, "*") && !emotePattern.MatchString(strings.SplitN(text, " ", 2)[0]) {
+		name, args, _ := strings.Cut(text[1:], " ")
+		if IMVUCommand(name) == CmdMsg {
+			return ParsedMessage{Kind: events.MessageSystem, Text: strings.TrimSpace(args)}
+		}
+		return ParsedMessage{Kind: events.MessageCommand, Text: text[1:]}
+	}
+
+	parsed := parseEmotes(text)
+	parsed.Kind = events.MessageText
+	return parsed
+}
+
+// parseEmotes removes the emote tokens from text and returns them apart
+func parseEmotes(text string) ParsedMessage {
+	var parsed ParsedMessage
+	for _, token := range emotePattern.FindAllString(text, -1) {
+		parsed.Emotes = append(parsed.Emotes, strings.Trim(token, "*"))
+	}
+	parsed.Text = strings.Join(strings.Fields(emotePattern.ReplaceAllString(text, " ")), " ")
+	return parsed
+}
@@ -0,0 +1,33 @@
+package imvu
+
+import (
+	"slices"
+	"testing"
+
+	"giiny/internal/events"
+)
+
+func TestParseMessage(t *testing.T) {
+	tests := []struct {
+		raw    string
+		kind   string
+		text   string
+		emotes []string
+	}{
+		{"hello there", events.MessageText, "hello there", nil},
+		{"  hi *wave* you *tomato*  ", events.MessageText, "hi you", []string{"wave", "tomato"}},
+		{"*tomato* lol", events.MessageText, "lol", []string{"tomato"}},
+		{"/me waves *wave_2*", events.MessageAction, "waves", []string{"wave_2"}},
+		{"*putOnOutfit 123 456", events.MessageCommand, "putOnOutfit 123 456", nil},
+		{"*imvu:trigger dance", events.MessageCommand, "imvu:trigger dance", nil},
+		{"*msg  SeatAssignment 2 1 3 4 ", events.MessageSystem, "SeatAssignment 2 1 3 4", nil},
+		{"*", events.MessageCommand, "", nil},
+		{"", events.MessageText, "", nil},
+	}
+	for _, tt := range tests {
+		got := ParseMessage(tt.raw)
+		if got.Kind != tt.kind || got.Text != tt.text || !slices.Equal(got.Emotes, tt.emotes) {
+			t.Errorf("ParseMessage(%q) = %+v, want %s %q %q", tt.raw, got, tt.kind, tt.text, tt.emotes)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package imvu
+
+// ActivateMusic turns the room's music player on. A non-empty station selects
+// the stream to play in rooms that offer a choice of stations.
+func (i *IMVU) ActivateMusic(actor, station string) error {
+	if station == "" {
+		return i.ExecAs(actor, CmdImvuActivateMusic)
+	}
+	return i.ExecAs(actor, CmdImvuActivateMusic, station)
+}
+
+// DeactivateMusic turns the room's music player off
+func (i *IMVU) DeactivateMusic(actor string) error {
+	return i.ExecAs(actor, CmdImvuDeactivateMusic)
+}
@@ -0,0 +1,78 @@
+package imvu
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"giiny/internal/events"
+)
+
+const (
+	// notificationPollInterval is how often the notification center is
+	// polled when IMQ doesn't announce anything
+	notificationPollInterval = 5 * time.Minute
+	// notificationLimit is how many of the latest notifications are fetched
+	notificationLimit = 50
+)
+
+// Notifications returns the bot's latest notifications, newest first
+func (i *IMVU) Notifications() ([]Notification, error) {
+	return i.api.GetNotifications(i.UserID(), notificationLimit)
+}
+
+// WatchNotifications publishes an events.NotificationReceived for every new
+// notification until the context is cancelled. The notification center is
+// checked periodically and whenever something arrives on the bot's private
+// queue. Notifications that exist when it starts are not published.
+func (i *IMVU) WatchNotifications(ctx context.Context) {
+	ticker := time.NewTicker(notificationPollInterval)
+	defer ticker.Stop()
+
+	var seen map[string]bool
+	for {
+		notifications, err := i.Notifications()
+		if err != nil {
+			log.Printf("Failed to check notifications: %v", err)
+		} else {
+			seen = i.publishNotifications(notifications, seen)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-i.notifyCheck:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// publishNotifications publishes the notifications missing from seen and
+// returns the IDs to remember. A nil seen only records the IDs.
+func (i *IMVU) publishNotifications(notifications []Notification, seen map[string]bool) map[string]bool {
+	current := make(map[string]bool, len(notifications))
+	for _, n := range notifications {
+		current[n.ID] = true
+		if seen == nil || seen[n.ID] || n.IsRead {
+			continue
+		}
+
+		events.Publish(i.Events, events.NotificationReceived{
+			ID:       n.ID,
+			Kind:     n.Kind,
+			Message:  n.Message,
+			SenderID: string(n.SenderID),
+			At:       time.Now(),
+		})
+	}
+	return current
+}
+
+// handlePrivateMessage checks the notification center on any traffic on the
+// bot's private queue, where new notifications are announced
+func (i *IMVU) handlePrivateMessage(Message) {
+	select {
+	case i.notifyCheck <- struct{}{}:
+	default:
+	}
+}
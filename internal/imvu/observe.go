@@ -0,0 +1,46 @@
+package imvu
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrObserving is returned when sending to a room that is only observed
+var ErrObserving = errors.New("observing the room without joining it")
+
+// Observe follows the chat and roster of a room without joining it: the
+// avatar doesn't appear in the room and nothing can be sent to it. It is
+// meant for logging rooms the bot shouldn't visibly take part in. Stop with
+// LeaveRoom.
+func (i *IMVU) Observe(roomID, roomChatID string) error {
+	i.sessionMu.Lock()
+	defer i.sessionMu.Unlock()
+	return i.observe(roomID, roomChatID)
+}
+
+func (i *IMVU) observe(roomID, roomChatID string) error {
+	if i.roomCancelFunc != nil {
+		i.roomCancelFunc()
+		i.roomCancelFunc = nil
+	}
+
+	i.api.Subscribe(roomQueue(roomID, roomChatID), i.handleRoomMessage)
+
+	chatQueue, err := i.api.GetRoomChatQueue(roomID, roomChatID)
+	if err != nil {
+		return fmt.Errorf("failed to get room chat ID: %w", err)
+	}
+	i.api.Subscribe(chatQueue, i.handleChatMessage)
+
+	i.currentRoom.Store(&Room{
+		OwnerID:    roomID,
+		ChatroomID: roomChatID,
+		ChatQueue:  chatQueue,
+		Observing:  true,
+	})
+	if _, err := i.refreshRoster(roomID, roomChatID, false); err != nil {
+		log.Printf("Failed to refresh roster of room %s-%s: %v", roomID, roomChatID, err)
+	}
+	return nil
+}
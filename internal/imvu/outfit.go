@@ -0,0 +1,172 @@
+package imvu
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// PutOnOutfit dresses the avatar with the given products, replacing the
+// current outfit, and remembers them as the worn products. actor is who asked
+// for it, for the audit trail.
+func (i *IMVU) PutOnOutfit(actor string, productIDs ...string) error {
+	// TODO: Test how CmdPutOnOutfit and CmdUse work.
+	if err := i.ExecAs(actor, CmdPutOnOutfit, productIDs...); err != nil {
+		return err
+	}
+	if err := i.ExecAs(actor, CmdUse, productIDs...); err != nil {
+		return err
+	}
+
+	i.outfitMu.Lock()
+	i.outfit = append([]string(nil), productIDs...)
+	i.triggers = nil
+	i.outfitMu.Unlock()
+
+	return nil
+}
+
+// ChangeOutfit dresses the avatar with the given products, taking off and
+// putting on only the products that differ from the worn ones so the avatar
+// doesn't flicker. When the worn products are unknown the whole outfit is put
+// on with PutOnOutfit.
+func (i *IMVU) ChangeOutfit(actor string, productIDs ...string) error {
+	worn := i.WornProducts()
+	if len(worn) == 0 {
+		return i.PutOnOutfit(actor, productIDs...)
+	}
+
+	takeOff, putOn := diffOutfit(worn, productIDs)
+	if len(takeOff) == 0 && len(putOn) == 0 {
+		return nil
+	}
+	if err := i.TakeOff(actor, takeOff...); err != nil {
+		return err
+	}
+	return i.PutOn(actor, putOn...)
+}
+
+// PutOn adds the products to the worn ones with a single command
+func (i *IMVU) PutOn(actor string, productIDs ...string) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+	if err := i.ExecAs(actor, CmdPutOn, productIDs...); err != nil {
+		return err
+	}
+
+	i.outfitMu.Lock()
+	_, added := diffOutfit(i.outfit, productIDs)
+	i.outfit = append(i.outfit, added...)
+	i.triggers = nil
+	i.outfitMu.Unlock()
+	return nil
+}
+
+// TakeOff removes the products from the worn ones with a single command
+func (i *IMVU) TakeOff(actor string, productIDs ...string) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+	if err := i.ExecAs(actor, CmdTakeOff, productIDs...); err != nil {
+		return err
+	}
+
+	i.outfitMu.Lock()
+	i.outfit = slices.DeleteFunc(i.outfit, func(id string) bool {
+		return slices.Contains(productIDs, id)
+	})
+	i.triggers = nil
+	i.outfitMu.Unlock()
+	return nil
+}
+
+// diffOutfit returns the worn products missing from target and the target
+// products not worn, in their order
+func diffOutfit(worn, target []string) (takeOff, putOn []string) {
+	for _, id := range worn {
+		if !slices.Contains(target, id) {
+			takeOff = append(takeOff, id)
+		}
+	}
+	for _, id := range target {
+		if !slices.Contains(worn, id) && !slices.Contains(putOn, id) {
+			putOn = append(putOn, id)
+		}
+	}
+	return takeOff, putOn
+}
+
+// WornProducts returns the IDs of the products the avatar is wearing
+func (i *IMVU) WornProducts() []string {
+	i.outfitMu.Lock()
+	defer i.outfitMu.Unlock()
+	return append([]string(nil), i.outfit...)
+}
+
+// LoadTriggers fetches the triggers of every worn product. It is called
+// lazily by TriggerAction, but can be used to refresh them.
+func (i *IMVU) LoadTriggers() error {
+	triggers := map[string]string{}
+	for _, productID := range i.WornProducts() {
+		product, err := i.api.GetProduct(productID)
+		if err != nil {
+			log.Printf("Failed to load triggers of product %s: %v", productID, err)
+			continue
+		}
+		for _, trigger := range product.Triggers {
+			triggers[strings.ToLower(trigger)] = productID
+		}
+	}
+
+	i.outfitMu.Lock()
+	i.triggers = triggers
+	i.outfitMu.Unlock()
+
+	log.Printf("Loaded %d triggers from worn products", len(triggers))
+	return nil
+}
+
+// AvailableTriggers returns the trigger words offered by the worn products
+func (i *IMVU) AvailableTriggers() []string {
+	i.outfitMu.Lock()
+	defer i.outfitMu.Unlock()
+
+	names := make([]string, 0, len(i.triggers))
+	for name := range i.triggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TriggerAction plays an avatar action. The trigger must be offered by one of
+// the worn products.
+func (i *IMVU) TriggerAction(actor, name string) error {
+	i.outfitMu.Lock()
+	loaded := i.triggers != nil
+	i.outfitMu.Unlock()
+
+	if !loaded {
+		if err := i.LoadTriggers(); err != nil {
+			return err
+		}
+	}
+
+	name = strings.ToLower(name)
+	i.outfitMu.Lock()
+	_, ok := i.triggers[name]
+	i.outfitMu.Unlock()
+	if !ok {
+		return fmt.Errorf("no worn product has the trigger %q", name)
+	}
+
+	return i.ExecAs(actor, CmdImvuTrigger, name)
+}
+
+// StopAction stops an action started with TriggerAction
+func (i *IMVU) StopAction(actor, name string) error {
+	return i.ExecAs(actor, CmdImvuUntrigger, strings.ToLower(name))
+}
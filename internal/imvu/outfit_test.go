@@ -0,0 +1,28 @@
+package imvu
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiffOutfit(t *testing.T) {
+	tests := []struct {
+		name           string
+		worn, want     []string
+		takeOff, putOn []string
+	}{
+		{"same", []string{"1", "2"}, []string{"2", "1"}, nil, nil},
+		{"change", []string{"1", "2", "3"}, []string{"2", "4"}, []string{"1", "3"}, []string{"4"}},
+		{"nothing worn", nil, []string{"1"}, nil, []string{"1"}},
+		{"undress", []string{"1"}, nil, []string{"1"}, nil},
+		{"duplicates", []string{"1"}, []string{"2", "2"}, []string{"1"}, []string{"2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			takeOff, putOn := diffOutfit(tt.worn, tt.want)
+			if !slices.Equal(takeOff, tt.takeOff) || !slices.Equal(putOn, tt.putOn) {
+				t.Errorf("diffOutfit(%q, %q) = %q, %q, want %q, %q", tt.worn, tt.want, takeOff, putOn, tt.takeOff, tt.putOn)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package imvu
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxPages bounds how many pages a Paginator follows, in case the server
+// keeps returning next links
+const maxPages = 100
+
+// errStop ends the iteration of a Paginator early without an error
+var errStop = errors.New("stop")
+
+// ItemExtractor turns an entity of a collection page into an item
+type ItemExtractor[T any] func(res *BaseResponse, entityID string) (T, error)
+
+// Paginator walks a paged IMVU collection. Each page lists the entity IDs of
+// its items and, in its HTTP meta or relations, a link to the next page.
+// Items that can't be extracted are logged and skipped.
+type Paginator[T any] struct {
+	client  *HTTPClient
+	name    string
+	next    string
+	extract ItemExtractor[T]
+	pages   int
+	// Total is the size of the whole collection as reported by the last
+	// page, or 0 if unknown
+	Total int
+}
+
+// NewPaginator returns a Paginator starting at the collection at path. The
+// name describes the items in errors and logs (e.g. "friends").
+func NewPaginator[T any](api *API, name, path string, extract ItemExtractor[T]) *Paginator[T] {
+	return &Paginator[T]{
+		client:  api.client,
+		name:    name,
+		next:    path,
+		extract: extract,
+	}
+}
+
+// HasNext reports whether there are pages left
+func (p *Paginator[T]) HasNext() bool {
+	return p.next != "" && p.pages < maxPages
+}
+
+// Next fetches the next page. It returns nil when there are no pages left.
+func (p *Paginator[T]) Next() ([]T, error) {
+	if !p.HasNext() {
+		return nil, nil
+	}
+
+	res, err := DoResponse(p.client, http.MethodGet, p.next, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", p.name, err)
+	}
+
+	collection, err := ExtractEntity[Collection](res, res.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract %s: %w", p.name, err)
+	}
+
+	p.pages++
+	p.Total = collection.TotalCount
+	p.next = p.nextPath(res)
+
+	items := make([]T, 0, len(collection.Items))
+	for _, entityID := range collection.Items {
+		item, err := p.extract(res, entityID)
+		if err != nil {
+			log.Printf("Warning: skipping %s item %s: %v", p.name, entityID, err)
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// EachPage calls fn with every page until the collection ends or fn returns
+// an error, which is returned
+func (p *Paginator[T]) EachPage(fn func(page []T) error) error {
+	for p.HasNext() {
+		page, err := p.Next()
+		if err != nil {
+			return err
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// All iterates over the items of every page. A failed page is yielded as an
+// error and ends the iteration.
+func (p *Paginator[T]) All() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for p.HasNext() {
+			page, err := p.Next()
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Collect returns the items of every page, stopping once it has limit items.
+// A limit of 0 collects the whole collection.
+func (p *Paginator[T]) Collect(limit int) ([]T, error) {
+	var items []T
+	err := p.EachPage(func(page []T) error {
+		items = append(items, page...)
+		if limit > 0 && len(items) >= limit {
+			items = items[:limit]
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return items, err
+	}
+	return items, nil
+}
+
+// nextPath returns the request path of the page after res, or an empty string
+// on the last page
+func (p *Paginator[T]) nextPath(res *BaseResponse) string {
+	var link string
+	if meta, ok := res.HTTP[res.ID].Meta.(map[string]any); ok {
+		link, _ = meta["next"].(string)
+	}
+	if link == "" {
+		link, _ = Relation(res, res.ID, "next")
+	}
+	if link == "" {
+		return ""
+	}
+	if path, ok := strings.CutPrefix(link, p.client.endpoints.REST); ok {
+		return path
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		log.Printf("Warning: ignoring invalid next page link %q: %v", link, err)
+		return ""
+	}
+	return u.RequestURI()
+}
@@ -0,0 +1,97 @@
+package imvu
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// roomCheckInterval is how often the participant list is polled when
+	// nothing on IMQ suggests that the bot was dropped
+	roomCheckInterval = 1 * time.Minute
+	minRejoinBackoff  = 5 * time.Second
+	maxRejoinBackoff  = 5 * time.Minute
+)
+
+// checkRoom asks the presence watcher of the current room to verify that the
+// bot is still a participant. It never blocks; pending checks are coalesced.
+func (i *IMVU) checkRoom() {
+	select {
+	case i.roomCheck <- struct{}{}:
+	default:
+	}
+}
+
+// keepInRoom watches the bot's participation in the room and posts it back to
+// the participant list only when it was removed or the chat expired. Failed
+// rejoins are retried with exponential backoff.
+func (i *IMVU) keepInRoom(ctx context.Context, roomID, roomChatID string) {
+	ticker := time.NewTicker(roomCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-i.roomCheck:
+		case <-ctx.Done():
+			log.Printf("Stopping presence checks for room %s-%s", roomID, roomChatID)
+			return
+		}
+
+		present, err := i.refreshRoster(roomID, roomChatID, true)
+		if err != nil && !chatExpired(err) {
+			log.Printf("Failed to refresh roster of room %s-%s: %v", roomID, roomChatID, err)
+			continue
+		}
+		if present {
+			continue
+		}
+
+		if err != nil {
+			log.Printf("Chat of room %s-%s expired, rejoining", roomID, roomChatID)
+		} else {
+			log.Printf("No longer a participant of room %s-%s, rejoining", roomID, roomChatID)
+		}
+		i.rejoinRoom(ctx, roomID, roomChatID)
+	}
+}
+
+// rejoinRoom posts the bot to the participant list until it succeeds or the
+// context is cancelled
+func (i *IMVU) rejoinRoom(ctx context.Context, roomID, roomChatID string) {
+	backoff := minRejoinBackoff
+	for {
+		_, err := i.api.JoinRoom(roomID, roomChatID)
+		if err == nil {
+			log.Printf("Rejoined room %s-%s", roomID, roomChatID)
+			// The expired chat was replaced by one with another queue
+			if err := i.resubscribeChat(roomID, roomChatID); err != nil {
+				log.Printf("Failed to resubscribe to the chat of room %s-%s: %v", roomID, roomChatID, err)
+			}
+			if _, err := i.refreshRoster(roomID, roomChatID, true); err != nil {
+				log.Printf("Failed to refresh roster of room %s-%s: %v", roomID, roomChatID, err)
+			}
+			return
+		}
+
+		log.Printf("Failed to rejoin room %s-%s, retrying in %s: %v", roomID, roomChatID, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff = min(backoff*2, maxRejoinBackoff)
+	}
+}
+
+// chatExpired reports whether the error means the room's chat no longer exists
+func chatExpired(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusGone
+}
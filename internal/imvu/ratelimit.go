@@ -0,0 +1,94 @@
+package imvu
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"giiny/internal/metrics"
+
+	"golang.org/x/time/rate"
+)
+
+// Rate limit categories. Every request counts against the global limit and
+// the limit of its category.
+const (
+	LimitGlobal = "global"
+	LimitAuth   = "auth"
+	LimitChat   = "chat"
+	LimitUser   = "user"
+	LimitOther  = "other"
+)
+
+// RateLimit is a token bucket refilled with PerSecond tokens every second and
+// holding up to Burst tokens
+type RateLimit struct {
+	PerSecond float64 `json:"per_second"`
+	Burst     int     `json:"burst"`
+}
+
+// DefaultRateLimits are conservative limits that stay well below what the
+// desktop client does when opening a room
+func DefaultRateLimits() map[string]RateLimit {
+	return map[string]RateLimit{
+		LimitGlobal: {PerSecond: 5, Burst: 10},
+		LimitAuth:   {PerSecond: 0.1, Burst: 2},
+		LimitChat:   {PerSecond: 1, Burst: 5},
+		LimitUser:   {PerSecond: 2, Burst: 5},
+		LimitOther:  {PerSecond: 2, Burst: 5},
+	}
+}
+
+// WithRateLimits overrides the rate limits of the given categories
+func WithRateLimits(limits map[string]RateLimit) ClientOption {
+	return func(c *HTTPClient) {
+		for category, limit := range limits {
+			c.limiters[category] = rate.NewLimiter(rate.Limit(limit.PerSecond), limit.Burst)
+		}
+	}
+}
+
+func newLimiters() map[string]*rate.Limiter {
+	limiters := map[string]*rate.Limiter{}
+	for category, limit := range DefaultRateLimits() {
+		limiters[category] = rate.NewLimiter(rate.Limit(limit.PerSecond), limit.Burst)
+	}
+	return limiters
+}
+
+// limitCategory returns the rate limit category of a request path
+func limitCategory(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/login"):
+		return LimitAuth
+	case strings.HasPrefix(path, "/chat/"):
+		return LimitChat
+	case strings.HasPrefix(path, "/user/"):
+		return LimitUser
+	default:
+		return LimitOther
+	}
+}
+
+// wait blocks until the request is allowed by both the global limiter and the
+// limiter of its category
+func (c *HTTPClient) wait(ctx context.Context, path string) error {
+	for _, category := range []string{LimitGlobal, limitCategory(path)} {
+		limiter, ok := c.limiters[category]
+		if !ok {
+			continue
+		}
+
+		start := time.Now()
+		if err := limiter.Wait(ctx); err != nil {
+			return fmt.Errorf("rate limit %s: %w", category, err)
+		}
+		if waited := time.Since(start); waited > time.Millisecond {
+			metrics.RateLimitWaits.Add(category, 1)
+			metrics.RateLimitWaitSeconds.AddFloat(category, waited.Seconds())
+		}
+		metrics.SetRateLimitTokens(category, limiter.Tokens())
+	}
+	return nil
+}
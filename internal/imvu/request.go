@@ -0,0 +1,64 @@
+package imvu
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// maxRetries is how many times a GET that failed with a retryable
+	// APIError is sent again
+	maxRetries = 2
+	// retryBackoff is the wait before the first retry, doubled for each one
+	// after it
+	retryBackoff = time.Second
+)
+
+// Do sends a request and returns the entity the response is about, parsed
+// into T. A non-nil body is sent as JSON.
+func Do[T any](c *HTTPClient, method, path string, body any) (*T, error) {
+	res, err := DoResponse(c, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	return ExtractEntity[T](res, res.ID)
+}
+
+// DoResponse sends a request and returns the whole response envelope, for
+// endpoints whose entities are read with the relation helpers. GETs failing
+// with a retryable APIError are retried with backoff; other methods aren't,
+// as they may have taken effect.
+func DoResponse(c *HTTPClient, method, path string, body any) (*BaseResponse, error) {
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		res, err := doResponse(c, method, path, body)
+		var apiErr *APIError
+		if err == nil || method != http.MethodGet || attempt == maxRetries ||
+			!errors.As(err, &apiErr) || !apiErr.Retryable {
+			return res, err
+		}
+		log.Printf("GET %s failed, retrying in %s: %v", path, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func doResponse(c *HTTPClient, method, path string, body any) (*BaseResponse, error) {
+	resp, err := c.Request(method, path, body, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res BaseResponse
+	if err := ParseResponse(resp, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// get is a shorthand for Do with GET
+func get[T any](c *HTTPClient, path string) (*T, error) {
+	return Do[T](c, http.MethodGet, path, nil)
+}
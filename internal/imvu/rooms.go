@@ -0,0 +1,53 @@
+package imvu
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+var (
+	// ErrRoomFull is returned when joining a room without a free place
+	ErrRoomFull = errors.New("room is full")
+	// ErrRoomAP is returned when joining an Access Pass room without AP
+	ErrRoomAP = errors.New("room requires Access Pass")
+)
+
+// RoomInfo returns the metadata of a room
+func (i *IMVU) RoomInfo(ownerID, chatroomID string) (*RoomInfo, error) {
+	return i.api.GetRoomInfo(ownerID, chatroomID)
+}
+
+// checkJoinable refuses to join rooms that are full or need an Access Pass the
+// bot doesn't have. Rooms whose metadata can't be fetched are allowed, the
+// join itself will tell.
+func (i *IMVU) checkJoinable(ownerID, chatroomID string) error {
+	info, err := i.api.GetRoomInfo(ownerID, chatroomID)
+	if err != nil {
+		log.Printf("Failed to get info of room %s-%s, joining anyway: %v", ownerID, chatroomID, err)
+		return nil
+	}
+
+	if user := i.User(); info.IsAP && (user == nil || !user.IsAP) {
+		return fmt.Errorf("%w: %q", ErrRoomAP, info.Name)
+	}
+	if info.Full() {
+		return fmt.Errorf("%w: %q has %d/%d users", ErrRoomFull, info.Name, info.Occupancy, info.Capacity)
+	}
+	return nil
+}
+
+// roomQueue is the IMQ queue announcing the room's participant changes
+func roomQueue(ownerID, chatroomID string) string {
+	return fmt.Sprintf("inv:/room/room-%s-%s", ownerID, chatroomID)
+}
+
+// sceneQueue is the IMQ queue of the avatars' movements in the room
+func sceneQueue(ownerID, chatroomID string) string {
+	return fmt.Sprintf("inv:/scene/scene-%s-%s", ownerID, chatroomID)
+}
+
+// SearchRooms returns up to limit public rooms matching the keywords
+func (i *IMVU) SearchRooms(keywords string, limit int) ([]RoomInfo, error) {
+	return i.api.SearchRooms(keywords).Collect(limit)
+}
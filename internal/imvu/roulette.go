@@ -0,0 +1,28 @@
+package imvu
+
+import (
+	"fmt"
+	"log"
+)
+
+// ClaimDailySpin spins the daily roulette if a spin is available. It returns a
+// nil prize when the spin was already used today.
+func (i *IMVU) ClaimDailySpin() (*RoulettePrize, error) {
+	roulette, err := i.api.GetRoulette(i.UserID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to check roulette: %w", err)
+	}
+
+	if !roulette.IsSpinAvailable {
+		log.Printf("Daily roulette already spun, next spin at %s", roulette.NextSpinTime)
+		return nil, nil
+	}
+
+	prize, err := i.api.SpinRoulette(i.UserID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim daily spin: %w", err)
+	}
+
+	log.Printf("Daily roulette prize: %s (%d %s)", prize.Description, prize.Amount, prize.PrizeType)
+	return prize, nil
+}
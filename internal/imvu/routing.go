@@ -0,0 +1,220 @@
+package imvu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errNotConfirmed is returned for subscriptions the server didn't answer in
+// time
+var errNotConfirmed = errors.New("subscription not confirmed")
+
+// Message is an IMQ delivery on a subscribed queue
+type Message struct {
+	Record string
+	Queue  string
+	Mount  string
+	// OpID is the operation ID the sender used
+	OpID int
+	// Payload is the raw message field. Chat messages are base64 encoded JSON
+	// that decodes into ChatMessagePayload.
+	Payload json.RawMessage
+}
+
+// result is the server's answer to an operation, such as a subscription
+type result struct {
+	status  int
+	message string
+}
+
+// router dispatches IMQ deliveries to the handler of their queue and
+// operation results to whoever waits for them
+type router struct {
+	mu       sync.RWMutex
+	handlers map[string]func(Message)
+	pending  map[int]chan result
+}
+
+func (r *router) handle(queue string, handler func(Message)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.handlers == nil {
+		r.handlers = map[string]func(Message){}
+	}
+	r.handlers[queue] = handler
+}
+
+func (r *router) remove(queue string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.handlers, queue)
+}
+
+// expect returns a channel that receives the result of the operation
+func (r *router) expect(opID int) <-chan result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pending == nil {
+		r.pending = map[int]chan result{}
+	}
+	ch := make(chan result, 1)
+	r.pending[opID] = ch
+	return ch
+}
+
+// forget stops waiting for the result of the operation
+func (r *router) forget(opID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, opID)
+}
+
+// resolve delivers an operation result to its waiter, if any
+func (r *router) resolve(raw map[string]any) {
+	opID, ok := raw["op_id"].(float64)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	ch := r.pending[int(opID)]
+	delete(r.pending, int(opID))
+	r.mu.Unlock()
+	if ch == nil {
+		return
+	}
+
+	var res result
+	if status, ok := raw["status"].(float64); ok {
+		res.status = int(status)
+	}
+	res.message, _ = raw["error_message"].(string)
+	ch <- res
+}
+
+// dispatch is the WebSocket OnMessage callback
+func (r *router) dispatch(raw map[string]any) {
+	if record, _ := raw["record"].(string); record == "msg_g2c_result" {
+		r.resolve(raw)
+		return
+	}
+
+	queue, ok := raw["queue"].(string)
+	if !ok {
+		return
+	}
+
+	r.mu.RLock()
+	handler := r.handlers[queue]
+	r.mu.RUnlock()
+	if handler == nil {
+		return
+	}
+
+	msg := Message{Queue: queue}
+	msg.Record, _ = raw["record"].(string)
+	msg.Mount, _ = raw["mount"].(string)
+	if opID, ok := raw["op_id"].(float64); ok {
+		msg.OpID = int(opID)
+	}
+	if payload, ok := raw["message"]; ok {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			log.Printf("Failed to re-marshal IMQ message on %s: %v", queue, err)
+			return
+		}
+		msg.Payload = data
+	}
+
+	handler(msg)
+}
+
+// Subscribe subscribes to an IMQ queue and routes its deliveries to handler.
+// A nil handler subscribes without handling the deliveries.
+func (i *API) Subscribe(queue string, handler func(Message)) {
+	if handler != nil {
+		i.router.handle(queue, handler)
+	}
+	i.SubscribeToQueue(queue, i.opID.GetNew())
+}
+
+// Subscription pairs an IMQ queue with the handler of its deliveries. A nil
+// Handler subscribes without handling them.
+type Subscription struct {
+	Queue   string
+	Handler func(Message)
+}
+
+// SubscribeAll subscribes to every queue in a single frame and waits up to
+// timeout for the server to confirm them. The error names the queues that
+// were refused or not confirmed in time.
+func (i *API) SubscribeAll(subs []Subscription, timeout time.Duration) error {
+	opIDs := make([]int, len(subs))
+	results := make([]<-chan result, len(subs))
+	entries := make([]any, len(subs))
+	first := i.opID.GetBatch(len(subs))
+	for n, sub := range subs {
+		if sub.Handler != nil {
+			i.router.handle(sub.Queue, sub.Handler)
+		}
+		opIDs[n] = first + n
+		results[n] = i.router.expect(opIDs[n])
+		entries[n] = subscriptionEntry(sub.Queue, opIDs[n])
+	}
+	defer func() {
+		for _, opID := range opIDs {
+			i.router.forget(opID)
+		}
+	}()
+
+	if err := i.sendSubscribe(entries); err != nil {
+		return fmt.Errorf("failed to send subscriptions: %w", err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var errs []error
+	expired := false
+	for n, sub := range subs {
+		var res result
+		if expired {
+			select {
+			case res = <-results[n]:
+			default:
+				errs = append(errs, fmt.Errorf("%s: %w", sub.Queue, errNotConfirmed))
+				continue
+			}
+		} else {
+			select {
+			case res = <-results[n]:
+			case <-timer.C:
+				expired = true
+				errs = append(errs, fmt.Errorf("%s: %w", sub.Queue, errNotConfirmed))
+				continue
+			}
+		}
+		if res.status != 0 {
+			errs = append(errs, fmt.Errorf("%s: refused with status %d: %s", sub.Queue, res.status, res.message))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Unsubscribe stops routing the deliveries of the queue and tells IMQ to stop
+// sending them
+func (i *API) Unsubscribe(queues ...string) {
+	for _, queue := range queues {
+		i.router.remove(queue)
+	}
+	if err := i.SendWebSocketMessage("msg_c2g_unsubscribe", map[string]any{"queues": queues}); err != nil {
+		log.Printf("Failed to unsubscribe from %s: %v", strings.Join(queues, ", "), err)
+	}
+}
@@ -0,0 +1,16 @@
+package imvu
+
+// Product returns the catalog product with the ID
+func (i *IMVU) Product(productID string) (*Product, error) {
+	return i.api.GetProduct(productID)
+}
+
+// Gift buys the product with the bot's credits and gifts it to the user
+func (i *IMVU) Gift(actor, productID, userID string) error {
+	return i.ExecAs(actor, CmdImvuGift, productID, userID)
+}
+
+// Purchase buys the product with the bot's credits
+func (i *IMVU) Purchase(actor, productID string) error {
+	return i.ExecAs(actor, CmdImvuPurchase, productID)
+}
@@ -0,0 +1,79 @@
+package imvu
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// defaultMaxMessageBytes is the default length at which chat messages are
+	// split, as IMVU truncates longer ones
+	defaultMaxMessageBytes = 200
+	// sentenceSeparator separates the sentences of Gemini responses
+	sentenceSeparator = ";"
+	// messagePace is the delay between the parts of a split message
+	messagePace = 750 * time.Millisecond
+)
+
+// splitMessage breaks a message into parts of at most limit bytes. Parts end
+// on sentence separators where possible, then on word boundaries; words
+// longer than the limit are cut between runes. A limit of 0 or less disables
+// splitting.
+func splitMessage(message string, limit int) []string {
+	if limit <= 0 || len(message) <= limit {
+		return []string{message}
+	}
+
+	var parts []string
+	var current strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			parts = append(parts, s)
+		}
+		current.Reset()
+	}
+	add := func(piece, sep string) {
+		if current.Len() > 0 && current.Len()+len(sep)+len(piece) > limit {
+			flush()
+		}
+		if current.Len() > 0 {
+			current.WriteString(sep)
+		}
+		current.WriteString(piece)
+	}
+
+	sentences := strings.SplitAfter(message, sentenceSeparator)
+	for _, sentence := range sentences {
+		sentence = strings.TrimSpace(sentence)
+		if sentence == "" {
+			continue
+		}
+		if len(sentence) <= limit {
+			add(sentence, " ")
+			continue
+		}
+
+		// A long sentence starts a part of its own
+		flush()
+		for _, word := range strings.Fields(sentence) {
+			for len(word) > limit {
+				flush()
+				cut := limit
+				for cut > 0 && !utf8.RuneStart(word[cut]) {
+					cut--
+				}
+				if cut == 0 {
+					// The limit is shorter than the first rune
+					_, cut = utf8.DecodeRuneInString(word)
+				}
+				parts = append(parts, word[:cut])
+				word = word[cut:]
+			}
+			add(word, " ")
+		}
+	}
+	flush()
+
+	return parts
+}
@@ -0,0 +1,52 @@
+package imvu
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		limit   int
+		want    []string
+	}{
+		{"short", "hello there", 20, []string{"hello there"}},
+		{"disabled", strings.Repeat("a", 50), 0, []string{strings.Repeat("a", 50)}},
+		{"sentences", "first one; second one; third", 15, []string{"first one;", "second one;", "third"}},
+		{"sentences joined", "a; b; c", 5, []string{"a; b;", "c"}},
+		{"words", "one two three four", 9, []string{"one two", "three", "four"}},
+		{"long word", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"runes", "ããããã", 4, []string{"ãã", "ãã", "ã"}},
+		{"limit below a rune", "日本", 2, []string{"日", "本"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitMessage(tt.message, tt.limit)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("splitMessage(%q, %d) = %q, want %q", tt.message, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitMessageParts(t *testing.T) {
+	message := strings.Repeat("palavra ação; ", 40) + strings.Repeat("x", 500)
+	for _, limit := range []int{utf8.UTFMax, 10, 37, 200} {
+		parts := splitMessage(message, limit)
+		for _, part := range parts {
+			if len(part) > limit {
+				t.Errorf("limit %d: part of %d bytes: %q", limit, len(part), part)
+			}
+			if !utf8.ValidString(part) {
+				t.Errorf("limit %d: part cut inside a rune: %q", limit, part)
+			}
+		}
+		if got, want := strings.Join(strings.Fields(strings.Join(parts, "")), ""), strings.Join(strings.Fields(message), ""); got != want {
+			t.Errorf("limit %d: parts don't add up to the message", limit)
+		}
+	}
+}
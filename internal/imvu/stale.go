@@ -0,0 +1,100 @@
+package imvu
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// defaultStaleChat is how long the room queues may stay silent before
+	// the chat queue is fetched again
+	defaultStaleChat = 15 * time.Minute
+	// staleCheckInterval is how often the room queues are checked for silence
+	staleCheckInterval = 1 * time.Minute
+)
+
+// SetStaleChatTimeout sets how long the room queues may go without any
+// message, while IMQ is connected, before the chat queue of the room is
+// fetched again and resubscribed. Zero disables the check.
+func (i *IMVU) SetStaleChatTimeout(timeout time.Duration) {
+	i.staleChat.Store(int64(timeout))
+}
+
+// touchRoom records traffic on one of the current room's queues
+func (i *IMVU) touchRoom() {
+	i.roomActivity.Store(time.Now().UnixNano())
+}
+
+// handleSceneMessage only records the traffic of the scene queue, which
+// carries the avatars' movements
+func (i *IMVU) handleSceneMessage(Message) {
+	i.touchRoom()
+}
+
+// watchChatActivity resubscribes to the chat of the room when its queues go
+// silent while IMQ looks healthy, since IMQ rotates the chat queue without
+// notice when a chat expires
+func (i *IMVU) watchChatActivity(ctx context.Context, roomID, roomChatID string) {
+	ticker := time.NewTicker(staleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		timeout := time.Duration(i.staleChat.Load())
+		state, since, _ := i.ConnectionState()
+		if timeout <= 0 || state != StateAuthenticated {
+			continue
+		}
+		// Silence only counts while connected
+		last := time.Unix(0, i.roomActivity.Load())
+		if since.After(last) {
+			last = since
+		}
+		if time.Since(last) < timeout {
+			continue
+		}
+
+		log.Printf("No traffic on room %s-%s for %s, resubscribing to its chat", roomID, roomChatID, time.Since(last).Round(time.Second))
+		if err := i.resubscribeChat(roomID, roomChatID); err != nil {
+			log.Printf("Failed to resubscribe to the chat of room %s-%s: %v", roomID, roomChatID, err)
+		}
+		i.touchRoom()
+	}
+}
+
+// resubscribeChat fetches the chat queue of the room again and subscribes to
+// it, following IMQ to the new queue when it changed
+func (i *IMVU) resubscribeChat(roomID, roomChatID string) error {
+	queue, err := i.api.GetRoomChatQueue(roomID, roomChatID)
+	if err != nil {
+		return fmt.Errorf("failed to get room chat queue: %w", err)
+	}
+	i.api.Subscribe(queue, i.handleChatMessage)
+	// The room is swapped for a copy so that senders holding it keep a
+	// consistent one
+	if room := i.currentRoom.Load(); room != nil && room.ChatQueue != queue {
+		moved := *room
+		moved.ChatQueue = queue
+		if i.currentRoom.CompareAndSwap(room, &moved) {
+			i.dropChatQueue(room.ChatQueue, queue)
+		}
+	}
+	return nil
+}
+
+// dropChatQueue stops listening to a chat queue that IMQ replaced, which
+// never receives anything again
+func (i *IMVU) dropChatQueue(old, replacement string) {
+	if old == "" {
+		return
+	}
+	log.Printf("Chat queue changed from %s to %s", old, replacement)
+	i.api.Unsubscribe(old)
+}
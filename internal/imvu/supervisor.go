@@ -0,0 +1,116 @@
+package imvu
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"giiny/internal/events"
+)
+
+const (
+	superviseInterval = 30 * time.Second
+	// maxDisconnected is how long IMQ may stay without being authenticated,
+	// which covers a client waiting forever to reconnect
+	maxDisconnected = 10 * time.Minute
+	// maxSilence is how long an authenticated IMQ connection may go without
+	// receiving anything; pongs alone arrive every ping interval
+	maxSilence = 3 * time.Minute
+	// maxRESTFailure is how long the REST API may go without a successful call
+	maxRESTFailure    = 10 * time.Minute
+	minRestartBackoff = 30 * time.Second
+	maxRestartBackoff = 10 * time.Minute
+)
+
+// Supervise watches the session and rebuilds it, logging in again and
+// rejoining the current room, when it is wedged. It must be called after a
+// successful Login and runs until the context is cancelled.
+func (i *IMVU) Supervise(ctx context.Context) {
+	ticker := time.NewTicker(superviseInterval)
+	defer ticker.Stop()
+
+	backoff := minRestartBackoff
+	var retryAt time.Time
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		now := time.Now()
+		var reason string
+		if !retryAt.IsZero() {
+			if now.Before(retryAt) {
+				continue
+			}
+			reason = "previous restart failed"
+		} else if reason = i.wedged(now); reason == "" {
+			continue
+		}
+
+		log.Printf("Session is wedged (%s), restarting it", reason)
+		if err := i.restart(); err != nil {
+			log.Printf("Failed to restart session, retrying in %s: %v", backoff, err)
+			retryAt = now.Add(backoff)
+			backoff = min(backoff*2, maxRestartBackoff)
+			continue
+		}
+
+		log.Printf("Session restarted")
+		retryAt, backoff = time.Time{}, minRestartBackoff
+		events.Publish(i.Events, events.SessionRestarted{Reason: reason, At: time.Now()})
+	}
+}
+
+// wedged returns why the session looks stuck, or an empty string if it looks
+// healthy
+func (i *IMVU) wedged(now time.Time) string {
+	if i.loggedOut.Load() {
+		return ""
+	}
+	if ws := i.api.ws.Load(); ws != nil {
+		state, since := ws.StateSince()
+		if state != StateAuthenticated && now.Sub(since) > maxDisconnected {
+			return fmt.Sprintf("IMQ %s for %s", state, now.Sub(since).Round(time.Second))
+		}
+		if last := ws.LastMessageTime(); state == StateAuthenticated && now.Sub(last) > maxSilence {
+			return fmt.Sprintf("IMQ silent for %s", now.Sub(last).Round(time.Second))
+		}
+	}
+
+	if last := i.api.client.LastSuccess(); now.Sub(last) > maxRESTFailure {
+		return fmt.Sprintf("no successful REST call for %s", now.Sub(last).Round(time.Second))
+	}
+	return ""
+}
+
+// restart tears down the session and builds it again with the credentials of
+// the last login. It holds the session lock throughout, so a room joined or
+// left meanwhile is what gets rejoined.
+func (i *IMVU) restart() error {
+	i.sessionMu.Lock()
+	defer i.sessionMu.Unlock()
+
+	i.close()
+	i.authenticated.Store(false)
+
+	if err := i.login(i.username, i.password); err != nil {
+		return err
+	}
+	room := i.currentRoom.Load()
+	if room == nil {
+		return nil
+	}
+
+	// A room that was only observed is observed again, without the avatar
+	// showing up in it
+	if room.Observing {
+		return i.observe(room.OwnerID, room.ChatroomID)
+	}
+	if _, err := i.joinRoom(room.OwnerID, room.ChatroomID); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package imvu
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PinnedTLSConfig returns a TLS configuration that, on top of the normal
+// certificate verification, requires one of the certificates in the verified
+// chain to have a public key whose SHA-256 hash (hex encoded) is in pins
+func PinnedTLSConfig(pins []string) *tls.Config {
+	allowed := make(map[string]bool, len(pins))
+	for _, pin := range pins {
+		allowed[strings.ToLower(strings.ReplaceAll(pin, ":", ""))] = true
+	}
+
+	return &tls.Config{
+		VerifyPeerCertificate: func(_ [][]byte, chains [][]*x509.Certificate) error {
+			for _, chain := range chains {
+				for _, cert := range chain {
+					sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+					if allowed[hex.EncodeToString(sum[:])] {
+						return nil
+					}
+				}
+			}
+			return errors.New("no certificate matches the pinned public keys")
+		},
+	}
+}
+
+// ClientTLSConfig returns a TLS configuration presenting the client
+// certificate in certFile and keyFile, when set, and trusting the certificate
+// authorities in caFile, when set, instead of the system ones
+func ClientTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	config := &tls.Config{}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read certificate authorities: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
@@ -0,0 +1,59 @@
+package imvu
+
+import (
+	"log"
+	"time"
+)
+
+// TryOn shows the product on the avatar without buying it. The look is undone
+// after undoAfter, when another product is tried on or by UndoTryOn. actor is
+// who asked for it, for the audit trail.
+func (i *IMVU) TryOn(actor, productID string, undoAfter time.Duration) error {
+	if err := i.UndoTryOn(actor); err != nil {
+		log.Printf("Failed to undo the previous try on: %v", err)
+	}
+	if err := i.ExecAs(actor, CmdImvuTry, productID); err != nil {
+		return err
+	}
+
+	i.tryMu.Lock()
+	defer i.tryMu.Unlock()
+	i.tryingOn = productID
+	i.tryTimer = time.AfterFunc(undoAfter, func() {
+		if err := i.undoTryOn("", productID); err != nil {
+			log.Printf("Failed to undo the try on of product %s: %v", productID, err)
+		}
+	})
+	return nil
+}
+
+// TryingOn returns the product being tried on, or an empty string
+func (i *IMVU) TryingOn() string {
+	i.tryMu.Lock()
+	defer i.tryMu.Unlock()
+	return i.tryingOn
+}
+
+// UndoTryOn takes off the product being tried on, if any
+func (i *IMVU) UndoTryOn(actor string) error {
+	return i.undoTryOn(actor, "")
+}
+
+// undoTryOn undoes the current try on. A non-empty productID only undoes the
+// try on of that product, so a late timer doesn't undo a newer one.
+func (i *IMVU) undoTryOn(actor, productID string) error {
+	i.tryMu.Lock()
+	current := i.tryingOn
+	if current == "" || (productID != "" && productID != current) {
+		i.tryMu.Unlock()
+		return nil
+	}
+	i.tryingOn = ""
+	if i.tryTimer != nil {
+		i.tryTimer.Stop()
+		i.tryTimer = nil
+	}
+	i.tryMu.Unlock()
+
+	return i.ExecAs(actor, CmdImvuTryForUndo, current)
+}
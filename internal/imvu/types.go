@@ -3,12 +3,13 @@ package imvu
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
+	"time"
 )
 
 // BaseResponse represents the common structure of all IMVU API responses
@@ -74,22 +75,39 @@ type User struct {
 	HasLegacyVIP          bool    `json:"has_legacy_vip"`
 }
 
-// UserResponse represents a response containing user data
-type UserResponse struct {
-	BaseResponse
-	User *User `json:"-"` // Not part of JSON, populated by ParseUser
+// CreatedAt returns when the account was registered, if the profile says
+func (u User) CreatedAt() (time.Time, bool) {
+	if u.Registered > 0 {
+		return time.Unix(u.Registered, 0), true
+	}
+	for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly} {
+		if t, err := time.Parse(layout, u.Created); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
 }
 
-// ParseResponse parses an HTTP response into the given response struct
+// ParseResponse parses an HTTP response into the given response struct. Non-2xx
+// responses, and 2xx responses with a failure status, return an *APIError.
 func ParseResponse(resp *http.Response, v any) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return readAPIError(resp)
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var payload errorPayload
+	if json.Unmarshal(body, &payload) == nil && payload.Status == "failure" {
+		return newAPIError(resp.StatusCode, body)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
 		return fmt.Errorf("failed to parse response: %w", err)
 	}
 
@@ -98,17 +116,7 @@ func ParseResponse(resp *http.Response, v any) error {
 
 // ExtractEntity extracts and parses an entity from the denormalized data
 func ExtractEntity[T any](response *BaseResponse, entityID string) (*T, error) {
-	// If entityID doesn't have the full URL, try to find it by suffix
-	if !strings.HasPrefix(entityID, "https://") {
-		for key := range response.Denormalized {
-			if strings.HasSuffix(key, entityID) {
-				entityID = key
-				break
-			}
-		}
-	}
-
-	entityData, ok := response.Denormalized[entityID]
+	entityData, ok := response.Entity(entityID)
 	if !ok {
 		return nil, fmt.Errorf("entity not found: %s", entityID)
 	}
@@ -121,20 +129,6 @@ func ExtractEntity[T any](response *BaseResponse, entityID string) (*T, error) {
 	return &entity, nil
 }
 
-// ParseUser parses the user data from a UserResponse
-func (r *UserResponse) ParseUser() error {
-	// Extract the user ID from the response ID
-	userID := r.ID
-
-	user, err := ExtractEntity[User](&r.BaseResponse, userID)
-	if err != nil {
-		return err
-	}
-
-	r.User = user
-	return nil
-}
-
 // MeData represents the data field inside the denormalized section for the "me" endpoint
 type MeData struct {
 	User struct {
@@ -145,23 +139,6 @@ type MeData struct {
 	Source    string `json:"source"`
 }
 
-// MeResponse represents the response from the "me" endpoint
-type MeResponse struct {
-	BaseResponse
-	Me *MeData `json:"-"` // Populated by ParseMe
-}
-
-// ParseMe extracts and parses the MeData from the denormalized map
-func (r *MeResponse) ParseMe() error {
-	entityID := r.ID
-	meData, err := ExtractEntity[MeData](&r.BaseResponse, entityID)
-	if err != nil {
-		return err
-	}
-	r.Me = meData
-	return nil
-}
-
 // ChatParticipantData represents the data field within a chat participant entity
 type ChatParticipantData struct {
 	SeatNumber          int    `json:"seat_number"`
@@ -179,6 +156,150 @@ type ChatParticipantData struct {
 	NFTProductIDs       []int  `json:"nft_product_ids"`
 }
 
+// Image returns the best picture of the participant's look: the full render,
+// else the look image, else its thumbnail
+func (d ChatParticipantData) Image() string {
+	for _, image := range []string{d.RenderedImage, d.LookImage, d.LookThumbnail} {
+		if image != "" {
+			return image
+		}
+	}
+	return ""
+}
+
+// Collection represents the data of a collection entity, a list of references
+// to other entities in the denormalized section
+type Collection struct {
+	Items      []string `json:"items"`
+	TotalCount int      `json:"total_count,omitempty"`
+}
+
+// RoomData represents the data field of a room entity
+type RoomData struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Privacy     string `json:"privacy"`
+	IsAP        bool   `json:"is_ap"`
+	IsVIP       bool   `json:"is_vip"`
+	Language    string `json:"language"`
+	Capacity    int    `json:"capacity"`
+	Occupancy   int    `json:"occupancy"`
+	Rating      int    `json:"rating"`
+	ImageURL    string `json:"image_url"`
+}
+
+// RoomInfo represents a room along with the IDs needed to join it
+type RoomInfo struct {
+	OwnerID    string
+	ChatroomID string
+	RoomData
+	// Moderators are the user IDs of the room's moderators. Only GetRoomInfo
+	// fills them.
+	Moderators []string
+}
+
+// Full reports whether the room has no free place
+func (r RoomInfo) Full() bool {
+	return r.Capacity > 0 && r.Occupancy >= r.Capacity
+}
+
+// Wallet represents the credit balances of a user
+type Wallet struct {
+	Credits      int64 `json:"credits"`
+	PromoCredits int64 `json:"promo_credits"`
+}
+
+// Roulette represents the state of the daily roulette of a user
+type Roulette struct {
+	IsSpinAvailable bool   `json:"is_spin_available"`
+	NextSpinTime    string `json:"next_spin_time"`
+}
+
+// RoulettePrize represents what was won in a roulette spin
+type RoulettePrize struct {
+	PrizeType   string `json:"prize_type"`
+	Amount      int64  `json:"amount"`
+	Description string `json:"description"`
+}
+
+// Product represents a catalog product
+type Product struct {
+	ProductName  string   `json:"product_name"`
+	CreatorName  string   `json:"creator_name"`
+	CreatorID    int64    `json:"creator_cid"`
+	Rating       string   `json:"rating"`
+	ProductPage  string   `json:"product_page"`
+	ProductImage string   `json:"product_image"`
+	Price        int64    `json:"price"`
+	Categories   []string `json:"categories"`
+	Triggers     []string `json:"triggers"`
+}
+
+// ProductURL returns the shop page of the product
+func ProductURL(productID string) string {
+	return "https://www.imvu.com/shop/product.php?products_id=" + productID
+}
+
+// ProductItem is a product listed for a user: on their wishlist, in their
+// inventory or worn by their avatar
+type ProductItem struct {
+	ProductID string
+	Product
+}
+
+// Friend is a user on someone's friend list
+type Friend struct {
+	UserID string
+	User
+}
+
+// URL returns the product page of the item
+func (p ProductItem) URL() string {
+	if p.ProductPage != "" {
+		return p.ProductPage
+	}
+	return ProductURL(p.ProductID)
+}
+
+// Look is the outfit of an avatar as described by its look URL
+type Look struct {
+	Products []StringOrInt `json:"products"`
+}
+
+// Kinds of Notification
+const (
+	NotificationFriendRequest = "friend_request"
+	NotificationGift          = "gift"
+	NotificationMessage       = "message"
+)
+
+// Notification is an entry of the user's notification center
+type Notification struct {
+	ID       string      `json:"-"`
+	Kind     string      `json:"type"`
+	Message  string      `json:"message"`
+	SenderID StringOrInt `json:"sender_id"`
+	Created  string      `json:"created"`
+	IsRead   bool        `json:"is_read"`
+}
+
+// Badge is a badge on a user's profile
+type Badge struct {
+	ID          string `json:"-"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ImageURL    string `json:"image_url"`
+	Created     string `json:"created"`
+}
+
+// FeedPost is a post on a user's feed
+type FeedPost struct {
+	ID       string `json:"-"`
+	Message  string `json:"message"`
+	ImageURL string `json:"image_url"`
+	Created  string `json:"created"`
+}
+
 // EnterChatResponse represents the response when entering a chat
 type EnterChatResponse struct {
 	BaseResponse
@@ -188,37 +309,43 @@ type EnterChatResponse struct {
 
 // ParseEnterChatResponse extracts and parses the relevant data from the denormalized map
 func (r *EnterChatResponse) ParseEnterChatResponse() error {
-	// Extract the participant ID from the response ID
-	participantID := r.ID
-
-	// Get the entity data for the participant
-	entityData, ok := r.Denormalized[participantID]
-	if !ok {
-		return fmt.Errorf("chat participant entity not found: %s", participantID)
+	participant, err := ExtractEntity[ChatParticipantData](&r.BaseResponse, r.ID)
+	if err != nil {
+		return fmt.Errorf("failed to parse chat participant: %w", err)
 	}
+	r.Participant = participant
 
-	// Unmarshal the data field into ChatParticipantData
-	var participantData ChatParticipantData
-	if err := json.Unmarshal(entityData.Data, &participantData); err != nil {
-		return fmt.Errorf("failed to parse chat participant data: %w", err)
-	}
-	r.Participant = &participantData
-
-	// Extract the user ID from the participant's relations
-	if entityData.Relations != nil {
-		if userRef, ok := entityData.Relations["ref"]; ok {
-			user, err := ExtractEntity[User](&r.BaseResponse, userRef)
-			if err != nil {
-				// Log the error but don't fail if user data isn't strictly necessary
-				log.Printf("Warning: Failed to parse user data from chat participant relations: %v", err)
-			}
-			r.User = user
-		}
+	// The participant refers to its user, which isn't strictly necessary
+	user, err := GetRelated[User](&r.BaseResponse, r.ID, "ref")
+	if err != nil && !errors.Is(err, ErrNoRelation) {
+		log.Printf("Warning: Failed to parse user data from chat participant relations: %v", err)
 	}
+	r.User = user
 
 	return nil
 }
 
+// JoinResult describes the bot's participation in a room right after joining
+type JoinResult struct {
+	// ParticipantID is the entity ID of the bot's participant entry
+	ParticipantID string
+	// ParticipantsRef is the entity ID of the room's participant list
+	ParticipantsRef string
+	SeatNumber      int
+	SeatFurniID     int
+	AssetURL        string
+	LookURL         string
+	// ChatQueue is the IMQ queue of the room's chat
+	ChatQueue string
+	User      *User
+}
+
+// Seated reports whether the room gave the avatar a seat. Joining a full room
+// or taking a seat that someone else got first leaves the avatar standing.
+func (r *JoinResult) Seated() bool {
+	return r.SeatNumber != 0
+}
+
 // StringOrInt is a type that can be unmarshalled from a JSON string or number.
 type StringOrInt string
 
@@ -269,8 +396,39 @@ func (s StringOrInt) Int64() (int64, error) {
 type ChatMessagePayload struct {
 	ChatID  StringOrInt `json:"chatId"`
 	Message string      `json:"message"`
-	To      StringOrInt `json:"to"`
-	UserID  StringOrInt `json:"userId"`
+	// To is the user a whisper is addressed to, "0" or empty for the room
+	To     StringOrInt `json:"to"`
+	UserID StringOrInt `json:"userId"`
+	// Timestamp is when the message was sent, in Unix seconds or
+	// milliseconds, if the sender's client set it
+	Timestamp StringOrInt `json:"timestamp,omitempty"`
+	// DisplayName is the display name of the sender, if the envelope has it
+	DisplayName string `json:"displayName,omitempty"`
+	// Flags are markers set by the sender's client, such as "system"
+	Flags []string `json:"flags,omitempty"`
+}
+
+// WhisperTarget returns the user the message is whispered to, or an empty
+// string for a message to the whole room
+func (p ChatMessagePayload) WhisperTarget() string {
+	if to := p.To.String(); to != "0" {
+		return to
+	}
+	return ""
+}
+
+// SentAt returns when the message was sent, or the zero time if the payload
+// has no timestamp
+func (p ChatMessagePayload) SentAt() time.Time {
+	ts, err := p.Timestamp.Int64()
+	if err != nil || ts <= 0 {
+		return time.Time{}
+	}
+	// Milliseconds since 1970 pass 1e12 in 2001, seconds never will
+	if ts >= 1e12 {
+		return time.UnixMilli(ts)
+	}
+	return time.Unix(ts, 0)
 }
 
 type chatMessageEncodedPayload ChatMessagePayload
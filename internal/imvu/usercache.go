@@ -0,0 +1,100 @@
+package imvu
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultUserTTL is how long a UserCache keeps users by default
+	DefaultUserTTL = 10 * time.Minute
+	// userCachePrune is the size at which expired users are dropped
+	userCachePrune = 1000
+)
+
+// UserCache keeps users looked up by ID for a TTL, so that each user is
+// fetched at most once per TTL however often they are looked up
+type UserCache struct {
+	fetch   func(userID string) (*User, error)
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cachedUser
+}
+
+type cachedUser struct {
+	user    *User
+	expires time.Time
+}
+
+// NewUserCache returns a UserCache that looks up missing users with fetch
+func NewUserCache(ttl time.Duration, fetch func(userID string) (*User, error)) *UserCache {
+	return &UserCache{
+		fetch:   fetch,
+		ttl:     ttl,
+		entries: map[string]cachedUser{},
+	}
+}
+
+// Get returns the user, fetching it if it isn't cached or expired. Failed
+// lookups aren't cached.
+func (c *UserCache) Get(userID string) (*User, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[userID]
+	ttl := c.ttl
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.user, nil
+	}
+
+	user, err := c.fetch(userID)
+	if err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		return user, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= userCachePrune {
+		for id, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, id)
+			}
+		}
+	}
+	c.entries[userID] = cachedUser{user: user, expires: now.Add(ttl)}
+	return user, nil
+}
+
+// Invalidate drops the user, so the next Get fetches it again
+func (c *UserCache) Invalidate(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, userID)
+}
+
+// SetTTL changes how long users are kept. Zero disables caching.
+func (c *UserCache) SetTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+	if ttl <= 0 {
+		clear(c.entries)
+	}
+}
+
+// userIDFromQueue returns the user a profile or user queue such as
+// "inv:/user/user-123" or "inv:/profile/123" is about
+func userIDFromQueue(queue string) string {
+	_, path, _ := strings.Cut(queue, ":/")
+	for _, prefix := range []string{"user/user-", "profile/user-", "profile/"} {
+		if id, ok := strings.CutPrefix(path, prefix); ok {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package imvu
+
+import (
+	"log"
+	"time"
+
+	"giiny/internal/events"
+)
+
+// UserName returns the display name of the user, falling back to the username
+// and then to the ID
+func (i *IMVU) UserName(userID string) string {
+	user, err := i.users.Get(userID)
+	if err != nil {
+		log.Printf("Failed to get name of user %s: %v", userID, err)
+		return userID
+	}
+
+	name := user.DisplayName
+	if name == "" {
+		name = user.Username
+	}
+	if name == "" {
+		name = userID
+	}
+	return name
+}
+
+// Friends returns up to limit friends of the user; 0 returns all of them
+func (i *IMVU) Friends(userID string, limit int) ([]Friend, error) {
+	return i.api.GetFriends(userID).Collect(limit)
+}
+
+// Profile returns the profile of the user. Profiles are cached for the user
+// cache TTL.
+func (i *IMVU) Profile(userID string) (*User, error) {
+	return i.users.Get(userID)
+}
+
+// SetUserCacheTTL sets how long users are cached. Zero disables the cache.
+func (i *IMVU) SetUserCacheTTL(ttl time.Duration) {
+	i.users.SetTTL(ttl)
+}
+
+// SetBots sets the user IDs of the other bots in the room, whose messages are
+// published with the bot sender
+func (i *IMVU) SetBots(userIDs []string) {
+	bots := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		bots[id] = true
+	}
+	i.bots.Store(&bots)
+}
+
+// Sender tells whether a user is the logged in user, one of the bots set with
+// SetBots or a person, as one of the events.Sender constants
+func (i *IMVU) Sender(userID string) string {
+	if userID == i.UserID() {
+		return events.SenderSelf
+	}
+	if bots := i.bots.Load(); bots != nil && (*bots)[userID] {
+		return events.SenderBot
+	}
+	return events.SenderHuman
+}
+
+// handleProfileMessage drops the user of a user or profile queue from the
+// cache, since messages on those queues announce profile changes
+func (i *IMVU) handleProfileMessage(msg Message) {
+	if userID := userIDFromQueue(msg.Queue); userID != "" {
+		i.users.Invalidate(userID)
+	}
+}
+
+// AcceptFriendRequest accepts the bot's friend request from the user
+func (i *IMVU) AcceptFriendRequest(userID string) error {
+	return i.api.AcceptFriendRequest(i.UserID(), userID)
+}
+
+// DeclineFriendRequest declines the bot's friend request from the user
+func (i *IMVU) DeclineFriendRequest(userID string) error {
+	return i.api.DeclineFriendRequest(i.UserID(), userID)
+}
+
+// Inventory returns up to limit products owned by the user; 0 returns all of
+// them
+func (i *IMVU) Inventory(userID string, limit int) ([]ProductItem, error) {
+	return i.api.GetInventory(userID).Collect(limit)
+}
@@ -0,0 +1,78 @@
+package imvu
+
+import (
+	"context"
+	"time"
+)
+
+// seenKept is how many keys a watcher remembers beyond those in the latest
+// listing
+const seenKept = 500
+
+// poll calls check right away and then every interval, or whenever wake
+// receives, until the context is cancelled. A nil wake is never received.
+func poll[T any](ctx context.Context, interval time.Duration, wake <-chan T, check func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		check()
+
+		select {
+		case <-ticker.C:
+		case <-wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// seenKeys remembers the keys a watcher has already published, so that only
+// new ones are. Keys that drop out of a listing are kept, up to limit of them,
+// so an item reappearing when a newer one is deleted isn't new again. A limit
+// of 0 only remembers the latest listing, for states such as being online
+// that can be published again once they end.
+type seenKeys struct {
+	limit  int
+	keys   map[string]bool
+	order  []string
+	primed bool
+}
+
+func newSeenKeys(limit int) *seenKeys {
+	return &seenKeys{limit: limit, keys: map[string]bool{}}
+}
+
+// update records a listing and returns its keys that weren't seen before.
+// The first listing is only recorded, as what exists when watching starts is
+// not new.
+func (s *seenKeys) update(listing []string) map[string]bool {
+	current := make(map[string]bool, len(listing))
+	fresh := map[string]bool{}
+	for _, key := range listing {
+		current[key] = true
+		if s.keys[key] {
+			continue
+		}
+		s.keys[key] = true
+		s.order = append(s.order, key)
+		if s.primed {
+			fresh[key] = true
+		}
+	}
+	s.primed = true
+
+	// The oldest keys go first, but never those still listed
+	kept := s.order[:0]
+	excess := len(s.order) - len(current) - s.limit
+	for _, key := range s.order {
+		if excess > 0 && !current[key] {
+			delete(s.keys, key)
+			excess--
+			continue
+		}
+		kept = append(kept, key)
+	}
+	s.order = kept
+	return fresh
+}
@@ -1,16 +1,36 @@
 package imvu
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"sync"
 	"time"
 
+	"giiny/internal/metrics"
+
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	errNotConnected  = errors.New("websocket is not connected")
+	errSendQueueFull = errors.New("websocket send queue is full")
+	// errMessageTooLarge is returned for a message over MaxMessageSize,
+	// which was skipped
+	errMessageTooLarge = errors.New("websocket message too large")
 )
 
+// sendQueueSize is how many outgoing messages are buffered for the writer
+const sendQueueSize = 64
+
 // State represents the state of the WebSocket connection
 type State int
 
@@ -39,6 +59,18 @@ func (s State) String() string {
 	}
 }
 
+// stateBuffer is how many state changes States buffers for a slow consumer
+const stateBuffer = 16
+
+// StateChange is a transition of the connection state. NextConnectTime is set
+// when entering StateWaiting.
+type StateChange struct {
+	Old             State
+	New             State
+	At              time.Time
+	NextConnectTime *time.Time
+}
+
 // Config holds the configuration for the WebSocketClient
 type Config struct {
 	URL                   string
@@ -49,18 +81,36 @@ type Config struct {
 	OpID                  *OperationID
 	PingInterval          time.Duration
 	ServerTimeoutInterval time.Duration
-	ReconnectIntervals    []time.Duration
-	OnStateChange         func(state State, nextConnectTime *time.Time)
-	OnMessage             func(message map[string]any)
-	OnPreReconnect        func(callback func(err error, newConfig *Config))
+	// WriteTimeout bounds each write to the connection. A write that times
+	// out or fails drops the connection and reconnects.
+	WriteTimeout time.Duration
+	// ReadTimeout is how long the connection may stay silent, pongs
+	// included, before it is considered dead. WebSocket pings are sent every
+	// third of it, so a half-open connection is noticed long before
+	// ServerTimeoutInterval.
+	ReadTimeout time.Duration
+	// MaxMessageSize is the largest message read, in bytes. Larger messages,
+	// like the scene updates of busy rooms, are skipped without closing the
+	// connection. Zero doesn't limit the size.
+	MaxMessageSize     int64
+	ReconnectIntervals []time.Duration
+	// Dialer is used to open the connection. When nil, a dialer with a 45
+	// second handshake timeout and compression enabled is used.
+	Dialer         *websocket.Dialer
+	OnStateChange  func(state State, nextConnectTime *time.Time)
+	OnMessage      func(message map[string]any)
+	OnPreReconnect func(callback func(err error, newConfig *Config))
 }
 
 // WebSocketClient represents a WebSocket client for IMVU
 type WebSocketClient struct {
 	config                    Config
 	conn                      *websocket.Conn
+	out                       chan []byte
 	mu                        sync.Mutex
 	state                     State
+	stateSince                time.Time
+	states                    chan StateChange
 	done                      chan struct{}
 	connectRetryTimer         *time.Timer
 	pingTimer                 *time.Timer
@@ -78,6 +128,12 @@ func NewWebSocketClient(config Config) *WebSocketClient {
 	if config.ServerTimeoutInterval == 0 {
 		config.ServerTimeoutInterval = 60 * time.Second
 	}
+	if config.WriteTimeout == 0 {
+		config.WriteTimeout = 10 * time.Second
+	}
+	if config.ReadTimeout == 0 {
+		config.ReadTimeout = 15 * time.Second
+	}
 	if len(config.ReconnectIntervals) == 0 {
 		config.ReconnectIntervals = []time.Duration{
 			5 * time.Second,
@@ -95,6 +151,7 @@ func NewWebSocketClient(config Config) *WebSocketClient {
 
 	client := &WebSocketClient{
 		config: config,
+		states: make(chan StateChange, stateBuffer),
 	}
 	client.setState(StateClosed, nil)
 	return client
@@ -131,8 +188,12 @@ func (c *WebSocketClient) run() {
 	c.setState(StateConnecting, nil)
 	log.Printf("Connecting to IMQ via '%s' as user '%s'", c.config.URL, c.config.UserID)
 
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 45 * time.Second,
+	dialer := c.config.Dialer
+	if dialer == nil {
+		dialer = &websocket.Dialer{
+			HandshakeTimeout:  45 * time.Second,
+			EnableCompression: true,
+		}
 	}
 
 	conn, _, err := dialer.Dial(c.config.URL, c.config.Headers)
@@ -143,8 +204,16 @@ func (c *WebSocketClient) run() {
 		return
 	}
 
+	readTimeout := c.config.ReadTimeout
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+
 	c.conn = conn
 	c.done = make(chan struct{})
+	c.out = make(chan []byte, sendQueueSize)
+	go c.writeLoop(conn, c.out, c.done)
 	c.lastMessageTime = time.Now()
 	c.scheduleServerTimeout()
 	c.mu.Unlock()
@@ -153,7 +222,13 @@ func (c *WebSocketClient) run() {
 
 	// Reader loop
 	for {
-		_, message, err := c.conn.ReadMessage()
+		message, err := c.readMessage(conn)
+		if errors.Is(err, errMessageTooLarge) {
+			metrics.IMQOversizedMessages.Add(1)
+			log.Printf("Skipping IMQ message: %v", err)
+			conn.SetReadDeadline(time.Now().Add(readTimeout))
+			continue
+		}
 		if err != nil {
 			// Check if the error is due to a closed connection
 			select {
@@ -167,16 +242,70 @@ func (c *WebSocketClient) run() {
 			}
 			return
 		}
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
 		c.onMessage(message)
 	}
 }
 
+// readMessage reads the next message from conn. A message over
+// MaxMessageSize is drained without keeping it in memory and
+// errMessageTooLarge is returned, so the connection stays usable.
+func (c *WebSocketClient) readMessage(conn *websocket.Conn) ([]byte, error) {
+	_, r, err := conn.NextReader()
+	if err != nil {
+		return nil, err
+	}
+
+	limit := c.config.MaxMessageSize
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+
+	message, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(message)) <= limit {
+		return message, nil
+	}
+
+	rest, err := io.Copy(io.Discard, r)
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: %d bytes, limit is %d", errMessageTooLarge, int64(len(message))+rest, limit)
+}
+
+// States returns the state changes of the client. Unlike OnStateChange it is
+// not called with the client locked, so consumers can select on it alongside
+// other channels. When the consumer falls behind the oldest changes are
+// dropped. The channel is never closed; StateClosed is the last change after
+// Close.
+func (c *WebSocketClient) States() <-chan StateChange {
+	return c.states
+}
+
 func (c *WebSocketClient) setState(state State, nextConnectTime *time.Time) {
 	if c.state == state {
 		return
 	}
+	change := StateChange{Old: c.state, New: state, At: time.Now(), NextConnectTime: nextConnectTime}
 	c.state = state
+	c.stateSince = change.At
 	log.Printf("IMQ State changed to: %s", state)
+
+	select {
+	case c.states <- change:
+	default:
+		// Drop the oldest change to make room. Only setState sends, with the
+		// lock held, so the send below can't block.
+		select {
+		case <-c.states:
+		default:
+		}
+		c.states <- change
+	}
+
 	if c.config.OnStateChange != nil {
 		c.config.OnStateChange(state, nextConnectTime)
 	}
@@ -224,6 +353,18 @@ func (c *WebSocketClient) onMessage(data []byte) {
 		return
 	}
 
+	_, span := tracer.Start(context.Background(), "imq.receive "+msgType,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("imq.record", msgType),
+			attribute.Int("imq.message.size", len(data)),
+		),
+	)
+	if queue, ok := msg["queue"].(string); ok {
+		span.SetAttributes(attribute.String("imq.queue", queue))
+	}
+	defer span.End()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -258,6 +399,20 @@ func (c *WebSocketClient) onDisconnected() {
 	c.mu.Unlock()
 }
 
+// onWriteError drops the connection after a failed write and reconnects,
+// unless conn was already replaced
+func (c *WebSocketClient) onWriteError(conn *websocket.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != conn {
+		return
+	}
+	c.disconnect()
+	log.Println("Connection to IMQ closed")
+	c.reconnect()
+}
+
 func (c *WebSocketClient) onAuthenticated() {
 	c.setState(StateAuthenticated, nil)
 	c.reset()
@@ -278,6 +433,7 @@ func (c *WebSocketClient) disconnect() {
 		}
 		c.conn.Close()
 		c.conn = nil
+		c.out = nil
 	}
 }
 
@@ -324,32 +480,86 @@ func (c *WebSocketClient) sendOpenFloodgates() {
 	c.send("msg_c2g_open_floodgates", map[string]any{})
 }
 
-// Send allows sending a message with a specific record type and payload.
-func (c *WebSocketClient) Send(record string, payload map[string]any) {
+// Send queues a message with a specific record type and payload. The error
+// only covers queueing; a failed write drops the connection instead.
+func (c *WebSocketClient) Send(record string, payload map[string]any) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.send(record, payload)
+	return c.send(record, payload)
 }
 
 // Internal send function, assumes lock is held.
-func (c *WebSocketClient) send(record string, payload map[string]any) {
+func (c *WebSocketClient) send(record string, payload map[string]any) error {
 	if c.state != StateAuthenticated {
 		log.Printf("Cannot send message '%s', not authenticated. State: %s", record, c.state)
-		return
+		return errNotConnected
 	}
 	c.schedulePing()
 	payload["record"] = record
-	c.sendRaw(payload)
+
+	_, span := tracer.Start(context.Background(), "imq.send "+record,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("imq.record", record)),
+	)
+	if queue, ok := payload["queue"].(string); ok {
+		span.SetAttributes(attribute.String("imq.queue", queue))
+	}
+	err := c.sendRaw(payload)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "send failed")
+	}
+	span.End()
+	return err
 }
 
-// sendRaw sends a raw message without adding the record or checking state.
-func (c *WebSocketClient) sendRaw(message any) {
+// sendRaw queues a raw message without adding the record or checking state.
+// It never blocks: when the writer falls behind the message is dropped.
+func (c *WebSocketClient) sendRaw(message any) error {
 	if c.conn == nil {
 		log.Println("Cannot send raw message, connection is nil.")
-		return
+		return errNotConnected
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Error encoding IMQ message: %v", err)
+		return err
 	}
-	if err := c.conn.WriteJSON(message); err != nil {
-		log.Printf("Error sending IMQ message: %v", err)
+
+	select {
+	case c.out <- data:
+		return nil
+	default:
+		log.Printf("Dropping IMQ message, %d messages are waiting to be sent", sendQueueSize)
+		return errSendQueueFull
+	}
+}
+
+// writeLoop is the only writer of conn, which also sends the WebSocket pings
+// that keep the read deadline moving. It stops when done is closed or a write
+// fails, in which case the connection is dropped.
+func (c *WebSocketClient) writeLoop(conn *websocket.Conn, out <-chan []byte, done <-chan struct{}) {
+	ping := time.NewTicker(c.config.ReadTimeout / 3)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.config.WriteTimeout)); err != nil {
+				log.Printf("IMQ WebSocket ping error: %v", err)
+				c.onWriteError(conn)
+				return
+			}
+		case data := <-out:
+			conn.SetWriteDeadline(time.Now().Add(c.config.WriteTimeout))
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				log.Printf("IMQ WebSocket write error: %v", err)
+				c.onWriteError(conn)
+				return
+			}
+		case <-done:
+			return
+		}
 	}
 }
 
@@ -390,6 +600,20 @@ func (c *WebSocketClient) sendPing() {
 	c.send("msg_c2g_ping", map[string]any{})
 }
 
+// StateSince returns the current state and when the client entered it
+func (c *WebSocketClient) StateSince() (State, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state, c.stateSince
+}
+
+// LastMessageTime returns when the last message, pongs included, was received
+func (c *WebSocketClient) LastMessageTime() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastMessageTime
+}
+
 // GetState returns the current state of the client.
 func (c *WebSocketClient) GetState() State {
 	c.mu.Lock()
@@ -418,6 +642,13 @@ type WebSocketSubscribeMessage struct {
 	QueuesWithResults []WebSocketSubscription `json:"queues_with_results"`
 }
 
+// WebSocketUnsubscribeMessage represents an unsubscribe message to be sent
+// over WebSocket
+type WebSocketUnsubscribeMessage struct {
+	Record string   `json:"record"`
+	Queues []string `json:"queues"`
+}
+
 // WebSocketSendMessageMessage represents a send message message to be sent over WebSocket
 type WebSocketSendMessageMessage struct {
 	Record  string `json:"record"`
@@ -0,0 +1,16 @@
+package imvu
+
+// Wishlist returns the products on the user's public wishlist
+func (i *IMVU) Wishlist(userID string) ([]ProductItem, error) {
+	return i.api.GetWishlist(userID)
+}
+
+// AddToWishlist adds the product to the bot's own wishlist
+func (i *IMVU) AddToWishlist(productID string) error {
+	return i.api.AddToWishlist(i.UserID(), productID)
+}
+
+// RemoveFromWishlist removes the product from the bot's own wishlist
+func (i *IMVU) RemoveFromWishlist(productID string) error {
+	return i.api.RemoveFromWishlist(i.UserID(), productID)
+}
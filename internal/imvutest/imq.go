@@ -0,0 +1,246 @@
+package imvutest
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+
+	"giiny/internal/imvu"
+
+	"github.com/gorilla/websocket"
+)
+
+// SentMessage represents a msg_c2g_send_message received from a client
+type SentMessage struct {
+	UserID  string
+	Queue   string
+	Mount   string
+	Payload imvu.ChatMessagePayload
+}
+
+type imqConn struct {
+	conn          *websocket.Conn
+	mu            sync.Mutex
+	userID        string
+	authenticated bool
+	queues        map[string]bool
+}
+
+func (c *imqConn) write(message map[string]any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteJSON(message)
+}
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// RejectConnections makes the IMQ endpoint refuse new WebSocket connections,
+// which is useful to exercise the client's reconnect backoff.
+func (s *Server) RejectConnections(reject bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejectWS = reject
+}
+
+// DropConnections closes every open IMQ connection without a close handshake,
+// simulating a network failure.
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	conns := make([]*imqConn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		c.conn.Close()
+	}
+}
+
+// Connections returns the number of authenticated IMQ connections
+func (s *Server) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for c := range s.conns {
+		if c.authenticated {
+			count++
+		}
+	}
+	return count
+}
+
+// Subscribed reports whether any connection of the user is subscribed to the queue
+func (s *Server) Subscribed(userID, queue string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.conns {
+		if c.userID == userID && c.queues[queue] {
+			return true
+		}
+	}
+	return false
+}
+
+// SentMessages returns a copy of every chat message sent by clients so far
+func (s *Server) SentMessages() []SentMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sent := make([]SentMessage, len(s.sent))
+	copy(sent, s.sent)
+	return sent
+}
+
+// Broadcast delivers a chat message to every connection subscribed to the queue,
+// as if it had been sent by another participant.
+func (s *Server) Broadcast(queue, mount string, payload imvu.ChatMessagePayload) {
+	s.mu.Lock()
+	var targets []*imqConn
+	for c := range s.conns {
+		if c.queues[queue] {
+			targets = append(targets, c)
+		}
+	}
+	s.mu.Unlock()
+
+	message := map[string]any{
+		"record":  "msg_g2c_send_message",
+		"queue":   queue,
+		"mount":   mount,
+		"message": payload,
+	}
+	for _, c := range targets {
+		if err := c.write(message); err != nil {
+			log.Printf("imvutest: failed to broadcast to %s: %v", c.userID, err)
+		}
+	}
+}
+
+// SendChat broadcasts a chat message from userID into the room's chat queue
+func (s *Server) SendChat(room *Room, userID, message string) {
+	s.Broadcast(room.Queue, "messages", imvu.ChatMessagePayload{
+		ChatID:  imvu.StringOrInt(room.ChatroomID),
+		Message: message,
+		To:      imvu.StringOrInt("0"),
+		UserID:  imvu.StringOrInt(userID),
+	})
+}
+
+func (s *Server) handleIMQ(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	reject := s.rejectWS
+	s.mu.Unlock()
+	if reject {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &imqConn{conn: conn, queues: map[string]bool{}}
+	s.mu.Lock()
+	s.conns[c] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg map[string]any
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		s.handleIMQMessage(c, msg, data)
+	}
+}
+
+func (s *Server) handleIMQMessage(c *imqConn, msg map[string]any, data []byte) {
+	record, _ := msg["record"].(string)
+	opID := msg["op_id"]
+
+	switch record {
+	case "msg_c2g_connect":
+		userID, _ := msg["user_id"].(string)
+
+		s.mu.Lock()
+		_, known := s.users[userID]
+		if known {
+			c.userID = userID
+			c.authenticated = true
+		}
+		s.mu.Unlock()
+
+		if !known {
+			c.write(map[string]any{"record": "msg_g2c_result", "op_id": opID, "status": 1, "error_message": "unknown user"})
+			return
+		}
+		c.write(map[string]any{"record": "msg_g2c_result", "op_id": opID, "status": 0})
+	case "msg_c2g_ping":
+		c.write(map[string]any{"record": "msg_g2c_pong"})
+	case "msg_c2g_subscribe":
+		var sub imvu.WebSocketSubscribeMessage
+		if err := json.Unmarshal(data, &sub); err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		for _, q := range sub.QueuesWithResults {
+			c.queues[q.Name] = true
+		}
+		s.mu.Unlock()
+
+		for _, q := range sub.QueuesWithResults {
+			c.write(map[string]any{"record": "msg_g2c_result", "op_id": q.OpID, "status": 0})
+		}
+	case "msg_c2g_unsubscribe":
+		var unsub imvu.WebSocketUnsubscribeMessage
+		if err := json.Unmarshal(data, &unsub); err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		for _, q := range unsub.Queues {
+			delete(c.queues, q)
+		}
+		s.mu.Unlock()
+	case "msg_c2g_send_message":
+		var send struct {
+			Queue   string                  `json:"queue"`
+			Mount   string                  `json:"mount"`
+			Message imvu.ChatMessagePayload `json:"message"`
+		}
+		if err := json.Unmarshal(data, &send); err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.sent = append(s.sent, SentMessage{
+			UserID:  c.userID,
+			Queue:   send.Queue,
+			Mount:   send.Mount,
+			Payload: send.Message,
+		})
+		s.mu.Unlock()
+
+		c.write(map[string]any{"record": "msg_g2c_result", "op_id": opID, "status": 0})
+		s.Broadcast(send.Queue, send.Mount, send.Message)
+	}
+}
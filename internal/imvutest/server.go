@@ -0,0 +1,460 @@
+package imvutest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"giiny/internal/imvu"
+)
+
+// User represents an account known by the fake server
+type User struct {
+	ID          string
+	Username    string
+	Password    string
+	DisplayName string
+	Sauce       string
+	SessionID   string
+	Credits     int64
+	Spun        bool
+}
+
+// Room represents a chat room known by the fake server
+type Room struct {
+	OwnerID      string
+	ChatroomID   string
+	Queue        string
+	Participants map[string]bool
+}
+
+// Server is a fake IMVU backend serving both the REST API and the IMQ
+// WebSocket endpoint from a single httptest server.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	users    map[string]*User
+	rooms    map[string]*Room
+	conns    map[*imqConn]bool
+	sent     []SentMessage
+	rejectWS bool
+}
+
+// NewServer starts a fake IMVU server. Callers must Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		users: map[string]*User{},
+		rooms: map[string]*Room{},
+		conns: map[*imqConn]bool{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", s.handleLogin)
+	mux.HandleFunc("GET /login/me", s.handleMe)
+	mux.HandleFunc("GET /user/{user}", s.handleGetUser)
+	mux.HandleFunc("POST /user/{user}", s.handleUpdateUser)
+	mux.HandleFunc("GET /user/{user}/rooms", s.handleGetUserRooms)
+	mux.HandleFunc("GET /wallet/{wallet}", s.handleGetWallet)
+	mux.HandleFunc("GET /roulette/{roulette}", s.handleGetRoulette)
+	mux.HandleFunc("POST /roulette/{roulette}/spin", s.handleSpinRoulette)
+	mux.HandleFunc("GET /chat/{chat}", s.handleGetChat)
+	mux.HandleFunc("GET /chat/{chat}/participants", s.handleGetParticipants)
+	mux.HandleFunc("POST /chat/{chat}/participants", s.handleJoinChat)
+	mux.HandleFunc("DELETE /chat/{chat}/participants/{user}", s.handleLeaveChat)
+	mux.HandleFunc("GET /streaming/imvu_pre", s.handleIMQ)
+
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// AddUser registers an account that can log in and be looked up
+func (s *Server) AddUser(id, username, password string) *User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := &User{
+		ID:          id,
+		Username:    username,
+		Password:    password,
+		DisplayName: username,
+		Sauce:       "sauce-" + id,
+		SessionID:   "session-" + id,
+	}
+	s.users[id] = user
+	return user
+}
+
+// AddRoom registers a chat room and returns it
+func (s *Server) AddRoom(ownerID, chatroomID string) *Room {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room := &Room{
+		OwnerID:      ownerID,
+		ChatroomID:   chatroomID,
+		Queue:        fmt.Sprintf("inv:/chat/chat-%s-%s", ownerID, chatroomID),
+		Participants: map[string]bool{},
+	}
+	s.rooms[ownerID+"-"+chatroomID] = room
+	return room
+}
+
+// ClientOptions returns the options needed to point an imvu client at this server
+func (s *Server) ClientOptions() []imvu.ClientOption {
+	return []imvu.ClientOption{imvu.WithEndpoints(imvu.Endpoints{
+		REST:         s.URL,
+		IMQ:          s.WSURL(),
+		Origin:       s.URL,
+		SecureOrigin: s.URL,
+	})}
+}
+
+// WSURL returns the URL of the fake IMQ endpoint
+func (s *Server) WSURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http") + "/streaming/imvu_pre"
+}
+
+// IsParticipant reports whether the user is currently in the given room
+func (s *Server) IsParticipant(ownerID, chatroomID, userID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.rooms[ownerID+"-"+chatroomID]
+	return ok && room.Participants[userID]
+}
+
+func (s *Server) userFromPath(r *http.Request) (*User, bool) {
+	id := strings.TrimPrefix(r.PathValue("user"), "user-")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[id]
+	return user, ok
+}
+
+func (s *Server) roomFromPath(r *http.Request) (*Room, bool) {
+	key := strings.TrimPrefix(r.PathValue("chat"), "chat-")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	room, ok := s.rooms[key]
+	return room, ok
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+
+	s.mu.Lock()
+	var user *User
+	for _, u := range s.users {
+		if u.Username == payload.Username && u.Password == payload.Password {
+			user = u
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: "osCsid", Value: user.SessionID, Path: "/"})
+	http.SetCookie(w, &http.Cookie{Name: "fake_user", Value: user.ID, Path: "/"})
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"status": "success",
+		"id":     s.URL + "/login/login-" + user.ID,
+	})
+}
+
+func (s *Server) currentUser(r *http.Request) *User {
+	cookie, err := r.Cookie("fake_user")
+	if err != nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.users[cookie.Value]
+}
+
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	user := s.currentUser(r)
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "not logged in")
+		return
+	}
+
+	id := s.URL + "/login/me"
+	data := map[string]any{
+		"user":       map[string]string{"id": s.URL + "/users/" + user.ID},
+		"sauce":      user.Sauce,
+		"session_id": user.SessionID,
+		"source":     "imvutest",
+	}
+	writeEntity(w, http.StatusOK, id, data, nil)
+}
+
+func (s *Server) handleGetUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.userFromPath(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	id := s.URL + "/user/user-" + user.ID
+	writeEntity(w, http.StatusOK, id, userEntity(user), nil)
+}
+
+func (s *Server) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.userFromPath(r); !ok {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"status": "success"})
+}
+
+func (s *Server) handleGetUserRooms(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.userFromPath(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	id := s.URL + "/user/user-" + user.ID + "/rooms"
+	res := imvu.BaseResponse{
+		Status:       "success",
+		ID:           id,
+		Denormalized: map[string]imvu.EntityData{},
+	}
+
+	s.mu.Lock()
+	var items []string
+	for _, room := range s.rooms {
+		if room.OwnerID != user.ID {
+			continue
+		}
+		itemID := fmt.Sprintf("%s/room/room-%s-%s", s.URL, room.OwnerID, room.ChatroomID)
+		items = append(items, itemID)
+		res.Denormalized[itemID] = imvu.EntityData{Data: mustMarshal(imvu.RoomData{
+			Name:      "Room " + room.ChatroomID,
+			Capacity:  10,
+			Occupancy: len(room.Participants),
+		})}
+	}
+	s.mu.Unlock()
+
+	res.Denormalized[id] = imvu.EntityData{Data: mustMarshal(imvu.Collection{Items: items, TotalCount: len(items)})}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) handleGetWallet(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.PathValue("wallet"), "wallet-")
+
+	s.mu.Lock()
+	user, ok := s.users[id]
+	var credits int64
+	if ok {
+		credits = user.Credits
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "wallet not found")
+		return
+	}
+
+	writeEntity(w, http.StatusOK, s.URL+"/wallet/wallet-"+id, imvu.Wallet{Credits: credits}, nil)
+}
+
+func (s *Server) rouletteUser(r *http.Request) (*User, bool) {
+	id := strings.TrimPrefix(r.PathValue("roulette"), "roulette-")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[id]
+	return user, ok
+}
+
+func (s *Server) handleGetRoulette(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.rouletteUser(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "roulette not found")
+		return
+	}
+
+	s.mu.Lock()
+	available := !user.Spun
+	s.mu.Unlock()
+
+	writeEntity(w, http.StatusOK, s.URL+"/roulette/roulette-"+user.ID, imvu.Roulette{IsSpinAvailable: available}, nil)
+}
+
+func (s *Server) handleSpinRoulette(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.rouletteUser(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "roulette not found")
+		return
+	}
+
+	s.mu.Lock()
+	if user.Spun {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, "already spun today")
+		return
+	}
+	user.Spun = true
+	user.Credits += 10
+	s.mu.Unlock()
+
+	prize := imvu.RoulettePrize{PrizeType: "credits", Amount: 10, Description: "10 credits"}
+	writeEntity(w, http.StatusCreated, s.URL+"/roulette/roulette-"+user.ID+"/spin", prize, nil)
+}
+
+func (s *Server) handleGetChat(w http.ResponseWriter, r *http.Request) {
+	room, ok := s.roomFromPath(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "chat not found")
+		return
+	}
+
+	id := fmt.Sprintf("%s/chat/chat-%s-%s", s.URL, room.OwnerID, room.ChatroomID)
+	writeEntity(w, http.StatusOK, id, map[string]any{"imq_queue": room.Queue}, nil)
+}
+
+func (s *Server) handleGetParticipants(w http.ResponseWriter, r *http.Request) {
+	room, ok := s.roomFromPath(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "chat not found")
+		return
+	}
+
+	id := fmt.Sprintf("%s/chat/chat-%s-%s/participants", s.URL, room.OwnerID, room.ChatroomID)
+	res := imvu.BaseResponse{
+		Status:       "success",
+		ID:           id,
+		Denormalized: map[string]imvu.EntityData{},
+	}
+
+	s.mu.Lock()
+	var items []string
+	seat := 0
+	for userID := range room.Participants {
+		seat++
+		itemID := id + "/user-" + userID
+		items = append(items, itemID)
+		res.Denormalized[itemID] = imvu.EntityData{
+			Data:      mustMarshal(imvu.ChatParticipantData{SeatNumber: seat, SeatFurniID: 1}),
+			Relations: map[string]string{"ref": s.URL + "/user/user-" + userID},
+		}
+	}
+	s.mu.Unlock()
+
+	res.Denormalized[id] = imvu.EntityData{Data: mustMarshal(imvu.Collection{Items: items, TotalCount: len(items)})}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) handleJoinChat(w http.ResponseWriter, r *http.Request) {
+	room, ok := s.roomFromPath(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "chat not found")
+		return
+	}
+
+	user := s.currentUser(r)
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "not logged in")
+		return
+	}
+
+	s.mu.Lock()
+	room.Participants[user.ID] = true
+	seat := len(room.Participants)
+	s.mu.Unlock()
+
+	userID := s.URL + "/user/user-" + user.ID
+	id := fmt.Sprintf("%s/chat/chat-%s-%s/participants/user-%s", s.URL, room.OwnerID, room.ChatroomID, user.ID)
+	participant := imvu.ChatParticipantData{
+		SeatNumber:   seat,
+		SeatFurniID:  1,
+		OutfitGender: "f",
+	}
+
+	writeJSON(w, http.StatusCreated, imvu.BaseResponse{
+		Status: "success",
+		ID:     id,
+		Denormalized: map[string]imvu.EntityData{
+			id:     {Data: mustMarshal(participant), Relations: map[string]string{"ref": userID}},
+			userID: {Data: mustMarshal(userEntity(user))},
+		},
+	})
+}
+
+func (s *Server) handleLeaveChat(w http.ResponseWriter, r *http.Request) {
+	room, ok := s.roomFromPath(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "chat not found")
+		return
+	}
+	user, ok := s.userFromPath(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	s.mu.Lock()
+	delete(room.Participants, user.ID)
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func userEntity(user *User) imvu.User {
+	return imvu.User{
+		DisplayName: user.DisplayName,
+		Username:    user.Username,
+		Online:      true,
+	}
+}
+
+func mustMarshal(v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func writeEntity(w http.ResponseWriter, status int, id string, data any, relations map[string]string) {
+	writeJSON(w, status, imvu.BaseResponse{
+		Status: "success",
+		ID:     id,
+		Denormalized: map[string]imvu.EntityData{
+			id: {Data: mustMarshal(data), Relations: relations},
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{
+		"status":  "failure",
+		"message": message,
+	})
+}
@@ -0,0 +1,114 @@
+// Package intent sorts short chat messages into intents by comparing their
+// embedding with those of example messages, so that trivial traffic can be
+// answered without the generative model.
+package intent
+
+import (
+	"fmt"
+	"sync"
+
+	"giiny/internal/memory"
+)
+
+// Intent is what a message is trying to do
+type Intent string
+
+const (
+	// Other is a message that matches no intent closely enough; it goes to
+	// the generative model
+	Other       Intent = "other"
+	Greeting    Intent = "greeting"
+	Question    Intent = "question"
+	CommandLike Intent = "command"
+	Smalltalk   Intent = "smalltalk"
+	Abuse       Intent = "abuse"
+)
+
+// examples are typical messages of each intent, in the languages the bot
+// speaks
+var examples = map[Intent][]string{
+	Greeting: {
+		"hi", "hello", "hey there", "good morning", "good night", "hi everyone",
+		"oi", "olá", "bom dia", "boa noite", "hola", "buenas noches",
+	},
+	Question: {
+		"what is your favorite movie?", "how old are you?", "where are you from?",
+		"why is the sky blue?", "can you explain how this works?",
+		"qual é o seu filme favorito?", "de onde você é?", "¿cuántos años tienes?",
+	},
+	CommandLike: {
+		"dance", "sit down", "wave at me", "come here", "give me a hug", "do a flip",
+		"dança", "senta", "baila", "siéntate",
+	},
+	Smalltalk: {
+		"lol", "haha", "ok", "nice", "cool", "same", "yeah", "kkkk", "rsrs", "jajaja",
+		"xd", "omg", "wow",
+	},
+	Abuse: {
+		"you are stupid", "shut up", "you're useless", "i hate you", "dumb bot",
+		"cala a boca", "você é burra", "cállate", "eres tonta",
+	},
+}
+
+// Router classifies messages by their closest examples
+type Router struct {
+	embed memory.Embedder
+
+	mu         sync.Mutex
+	prototypes map[Intent][][]float32
+}
+
+// NewRouter returns a router embedding with embed. The examples are embedded
+// on the first Classify.
+func NewRouter(embed memory.Embedder) *Router {
+	return &Router{embed: embed}
+}
+
+// Classify returns the intent whose examples are the most similar to the
+// text and the similarity, or Other when none reaches threshold
+func (r *Router) Classify(text string, threshold float64) (Intent, float64, error) {
+	prototypes, err := r.load()
+	if err != nil {
+		return Other, 0, err
+	}
+	embedding, err := r.embed(text)
+	if err != nil {
+		return Other, 0, fmt.Errorf("failed to embed message: %w", err)
+	}
+
+	best, bestScore := Other, 0.0
+	for intent, vectors := range prototypes {
+		for _, v := range vectors {
+			if score := memory.Cosine(embedding, v); score > bestScore {
+				best, bestScore = intent, score
+			}
+		}
+	}
+	if bestScore < threshold {
+		return Other, bestScore, nil
+	}
+	return best, bestScore, nil
+}
+
+// load embeds the examples once. A failure is returned and retried on the
+// next call.
+func (r *Router) load() (map[Intent][][]float32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.prototypes != nil {
+		return r.prototypes, nil
+	}
+
+	prototypes := make(map[Intent][][]float32, len(examples))
+	for intent, texts := range examples {
+		for _, text := range texts {
+			v, err := r.embed(text)
+			if err != nil {
+				return nil, fmt.Errorf("failed to embed example %q: %w", text, err)
+			}
+			prototypes[intent] = append(prototypes[intent], v)
+		}
+	}
+	r.prototypes = prototypes
+	return prototypes, nil
+}
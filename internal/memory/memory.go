@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"giiny/internal/store"
+)
+
+// Embedder turns text into an embedding vector
+type Embedder func(text string) ([]float32, error)
+
+// Extractor returns a durable fact about the speaker contained in a message,
+// or an empty string when there is nothing worth remembering
+type Extractor func(text string) (string, error)
+
+const (
+	// minRelevance is the minimum cosine similarity for a memory to be recalled
+	minRelevance = 0.5
+	// duplicateThreshold is the similarity above which a new fact is considered
+	// already known
+	duplicateThreshold = 0.92
+)
+
+// Memory stores facts about users as embeddings and recalls the ones most
+// relevant to a message
+type Memory struct {
+	store   *store.Store
+	embed   Embedder
+	extract Extractor
+}
+
+func New(st *store.Store, embed Embedder, extract Extractor) *Memory {
+	return &Memory{
+		store:   st,
+		embed:   embed,
+		extract: extract,
+	}
+}
+
+// Recall returns up to limit facts about the user that are relevant to text,
+// most relevant first
+func (m *Memory) Recall(userID, text string, limit int) ([]string, error) {
+	memories, err := m.store.Memories(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(memories) == 0 {
+		return nil, nil
+	}
+
+	query, err := m.embed(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed message: %w", err)
+	}
+
+	type scored struct {
+		fact  string
+		score float64
+	}
+	var candidates []scored
+	for _, mem := range memories {
+		score := Cosine(query, mem.Embedding)
+		if score >= minRelevance {
+			candidates = append(candidates, scored{mem.Fact, score})
+		}
+	}
+
+	sort.Slice(candidates, func(a, b int) bool {
+		return candidates[a].score > candidates[b].score
+	})
+
+	var facts []string
+	for i := 0; i < len(candidates) && i < limit; i++ {
+		facts = append(facts, candidates[i].fact)
+	}
+	return facts, nil
+}
+
+// Learn extracts a fact from the message and stores it, unless it is already
+// known
+func (m *Memory) Learn(userID, text string) error {
+	fact, err := m.extract(text)
+	if err != nil {
+		return fmt.Errorf("failed to extract fact: %w", err)
+	}
+	if fact == "" {
+		return nil
+	}
+
+	embedding, err := m.embed(fact)
+	if err != nil {
+		return fmt.Errorf("failed to embed fact: %w", err)
+	}
+
+	memories, err := m.store.Memories(userID)
+	if err != nil {
+		return err
+	}
+	for _, mem := range memories {
+		if Cosine(embedding, mem.Embedding) >= duplicateThreshold {
+			return nil
+		}
+	}
+
+	log.Printf("Remembering about user %s: %s", userID, fact)
+	return m.store.AddMemory(userID, fact, embedding)
+}
+
+// Cosine returns the cosine similarity of two embeddings, 0 when their sizes
+// differ
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,70 @@
+// Package metrics holds the bot's counters. They are published through expvar,
+// so they show up under /debug/vars wherever the expvar handler is served.
+package metrics
+
+import (
+	"expvar"
+	"runtime"
+)
+
+var (
+	GeminiRequests       = expvar.NewInt("gemini_requests")
+	GeminiPromptTokens   = expvar.NewInt("gemini_prompt_tokens")
+	GeminiResponseTokens = expvar.NewInt("gemini_response_tokens")
+	// GeminiBudgetRejections counts messages not answered because the daily
+	// token budget was spent
+	GeminiBudgetRejections = expvar.NewInt("gemini_budget_rejections")
+	// GeminiRefusals counts answers blocked by the safety filters or empty
+	GeminiRefusals = expvar.NewInt("gemini_refusals")
+	// GeminiCacheHits counts chat messages answered from the answer cache
+	GeminiCacheHits = expvar.NewInt("gemini_cache_hits")
+	// IntentsRouted counts chat messages answered without Gemini, keyed by
+	// intent
+	IntentsRouted = expvar.NewMap("intents_routed")
+
+	// RoomsJoined counts the rooms joined, not counting rejoins of the same
+	// room
+	RoomsJoined = expvar.NewInt("rooms_joined")
+	// ChatMessagesHandled counts the room messages of people the bot handled
+	ChatMessagesHandled = expvar.NewInt("chat_messages_handled")
+	// AnswerFailures counts the users' messages and commands that failed
+	// with a Gemini or IMVU error
+	AnswerFailures = expvar.NewInt("answer_failures")
+
+	// Stages of handling a message (send, gemini, command): how many ran,
+	// their total duration and how many ran past their deadline
+	StageCalls    = expvar.NewMap("stage_calls")
+	StageSeconds  = expvar.NewMap("stage_seconds")
+	StageOverruns = expvar.NewMap("stage_overruns")
+
+	// HTTPCacheHits counts IMVU GET requests served from the cache without a
+	// request, HTTPCacheRevalidations those answered with 304 Not Modified
+	HTTPCacheHits          = expvar.NewInt("imvu_http_cache_hits")
+	HTTPCacheRevalidations = expvar.NewInt("imvu_http_cache_revalidations")
+
+	// ChatResends counts chat messages sent again because their echo didn't
+	// come back, ChatDeliveryFailures those that weren't echoed either time
+	ChatResends          = expvar.NewInt("chat_resends")
+	ChatDeliveryFailures = expvar.NewInt("chat_delivery_failures")
+
+	// IMQOversizedMessages counts IMQ messages skipped for being over the
+	// maximum message size
+	IMQOversizedMessages = expvar.NewInt("imq_oversized_messages")
+
+	// IMVU REST rate limiting, keyed by limit category
+	RateLimitWaits       = expvar.NewMap("imvu_rate_limit_waits")
+	RateLimitWaitSeconds = expvar.NewMap("imvu_rate_limit_wait_seconds")
+	RateLimitTokens      = expvar.NewMap("imvu_rate_limit_tokens")
+)
+
+func init() {
+	// A number that keeps growing points at leaked goroutines
+	expvar.Publish("goroutines", expvar.Func(func() any { return runtime.NumGoroutine() }))
+}
+
+// SetRateLimitTokens records the tokens left in a rate limit bucket
+func SetRateLimitTokens(category string, tokens float64) {
+	v := new(expvar.Float)
+	v.Set(tokens)
+	RateLimitTokens.Set(category, v)
+}
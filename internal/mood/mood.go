@@ -0,0 +1,238 @@
+// Package mood keeps Giiny's mood from the sentiment of the room's chat. A
+// small lexicon scores each message; the scores and the chat activity decay
+// over time, so the mood drifts back to cheerful when the room calms down and
+// to sleepy when it goes quiet.
+package mood
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Mood is a state of the persona
+type Mood string
+
+// Moods of the persona
+const (
+	Cheerful Mood = "cheerful"
+	Excited  Mood = "excited"
+	Sleepy   Mood = "sleepy"
+	Pouty    Mood = "pouty"
+)
+
+// Moods lists every mood
+var Moods = []Mood{Cheerful, Excited, Sleepy, Pouty}
+
+const (
+	// halfLife is how long it takes for the sentiment and activity of the
+	// chat to lose half of their weight
+	halfLife = 10 * time.Minute
+	// sentimentWeight is how much a single message moves the valence
+	sentimentWeight = 0.3
+	// activityWeight is how much a single message adds to the energy
+	activityWeight = 0.15
+
+	excitedValence = 0.3
+	poutyValence   = -0.3
+	sleepyEnergy   = 0.05
+)
+
+// prompts describe the moods to the model, in the language of the system
+// instructions. The cheerful mood is the persona's default and needs none.
+var prompts = map[Mood]string{
+	Excited: "Agora você está muito animada e empolgada, fale com muita energia e entusiasmo.",
+	Sleepy:  "Agora você está com muito sono, bocejando e falando devagar, querendo tirar uma soneca.",
+	Pouty:   "Agora você está emburrada e fazendo beicinho, um pouco chateada, mas continua carinhosa com o senpai.",
+}
+
+// Prompt returns the instruction that sets the mood in the system prompt, or
+// an empty string for the default mood
+func Prompt(m Mood) string {
+	return prompts[m]
+}
+
+// Valid reports whether m is a known mood
+func Valid(m Mood) bool {
+	for _, known := range Moods {
+		if m == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Tracker follows the sentiment and activity of the chat. The zero value is
+// not usable; use New.
+type Tracker struct {
+	mu      sync.Mutex
+	valence float64
+	energy  float64
+	updated time.Time
+	mood    Mood
+}
+
+// New returns a Tracker in the default mood, as if the room had just been
+// active
+func New(now time.Time) *Tracker {
+	return &Tracker{
+		energy:  activityWeight,
+		updated: now,
+		mood:    Cheerful,
+	}
+}
+
+// Observe scores a chat message and returns the resulting mood and whether it
+// changed
+func (t *Tracker) Observe(text string, at time.Time) (Mood, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.decay(at)
+
+	score := Sentiment(text)
+	t.valence = clamp(t.valence+score*sentimentWeight, -1, 1)
+
+	energy := activityWeight
+	energy += 0.05 * float64(min(strings.Count(text, "!"), 3))
+	t.energy = clamp(t.energy+energy, 0, 1)
+
+	return t.update()
+}
+
+// Current returns the mood at the given time, which may have changed since
+// the last message when the chat went quiet
+func (t *Tracker) Current(at time.Time) (Mood, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.decay(at)
+	return t.update()
+}
+
+// decay fades the valence and energy for the time since the last update
+func (t *Tracker) decay(at time.Time) {
+	elapsed := at.Sub(t.updated)
+	if elapsed <= 0 {
+		return
+	}
+
+	factor := math.Pow(0.5, float64(elapsed)/float64(halfLife))
+	t.valence *= factor
+	t.energy *= factor
+	t.updated = at
+}
+
+func (t *Tracker) update() (Mood, bool) {
+	var m Mood
+	switch {
+	case t.valence <= poutyValence:
+		m = Pouty
+	case t.valence >= excitedValence:
+		m = Excited
+	case t.energy < sleepyEnergy:
+		m = Sleepy
+	default:
+		m = Cheerful
+	}
+
+	changed := m != t.mood
+	t.mood = m
+	return m, changed
+}
+
+// Sentiment scores text between -1 (negative) and 1 (positive). Text without
+// any known word or emoticon scores 0.
+func Sentiment(text string) float64 {
+	lower := strings.ToLower(text)
+
+	var positive, negative int
+	for emoticon, score := range emoticons {
+		n := strings.Count(lower, emoticon)
+		if score > 0 {
+			positive += n
+		} else {
+			negative += n
+		}
+	}
+
+	words := strings.FieldsFunc(lower, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for i, word := range words {
+		score, ok := lexicon[word]
+		if !ok {
+			if isLaugh(word) {
+				positive++
+			}
+			continue
+		}
+		if i > 0 && negations[words[i-1]] {
+			score = -score
+		}
+		if score > 0 {
+			positive++
+		} else {
+			negative++
+		}
+	}
+
+	if positive+negative == 0 {
+		return 0
+	}
+	return float64(positive-negative) / float64(positive+negative)
+}
+
+// isLaugh reports whether the word is a written laugh like "kkkk", "hahaha" or
+// "jajaja"
+func isLaugh(word string) bool {
+	if len(word) >= 3 && strings.Trim(word, "k") == "" {
+		return true
+	}
+	for _, laugh := range []string{"ha", "ja", "he", "rs"} {
+		if len(word) >= 4 && strings.ReplaceAll(word, laugh, "") == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
+
+var emoticons = map[string]int{
+	":)": 1, ":d": 1, "^_^": 1, "^^": 1, "uwu": 1, "owo": 1, ">w<": 1, "<3": 1, ";)": 1, "xd": 1,
+	":(": -1, ":'(": -1, "t_t": -1, ";-;": -1, "-_-": -1, ">:(": -1, "qq": -1,
+}
+
+// negations flip the score of the word that follows them
+var negations = map[string]bool{
+	"não": true, "nao": true, "nunca": true, "nem": true,
+	"not": true, "never": true, "don": true,
+	"jamás": true, "tampoco": true,
+}
+
+// lexicon scores common words in Portuguese, English and Spanish
+var lexicon = map[string]int{
+	// Portuguese
+	"amo": 1, "adoro": 1, "feliz": 1, "legal": 1, "lindo": 1, "linda": 1, "fofo": 1, "fofa": 1,
+	"incrível": 1, "maravilhoso": 1, "maravilhosa": 1, "perfeito": 1, "perfeita": 1, "ótimo": 1,
+	"ótima": 1, "top": 1, "obrigado": 1, "obrigada": 1, "parabéns": 1, "bom": 1, "boa": 1,
+	"amei": 1, "demais": 1, "animado": 1, "animada": 1, "yay": 1, "eba": 1, "oba": 1,
+	"odeio": -1, "triste": -1, "chato": -1, "chata": -1, "ruim": -1, "horrível": -1, "péssimo": -1,
+	"raiva": -1, "feio": -1, "feia": -1, "idiota": -1, "burra": -1, "burro": -1, "cansado": -1,
+	"cansada": -1, "tédio": -1, "droga": -1, "saco": -1, "irritado": -1, "irritada": -1, "cala": -1,
+	// English
+	"love": 1, "happy": 1, "great": 1, "awesome": 1, "cute": 1, "nice": 1, "cool": 1, "thanks": 1,
+	"amazing": 1, "beautiful": 1, "perfect": 1, "good": 1, "fun": 1, "excited": 1, "lol": 1,
+	"hate": -1, "sad": -1, "boring": -1, "bad": -1, "awful": -1, "terrible": -1, "angry": -1,
+	"ugly": -1, "stupid": -1, "dumb": -1, "tired": -1, "annoying": -1, "shut": -1, "worst": -1,
+	// Spanish
+	"genial": 1, "bonito": 1, "bonita": 1, "gracias": 1, "encanta": 1,
+	"increíble": 1, "bueno": 1, "buena": 1, "divertido": 1, "emocionado": 1, "emocionada": 1,
+	"odio": -1, "aburrido": -1, "aburrida": -1, "malo": -1, "mala": -1, "horrible": -1,
+	"enojado": -1, "enojada": -1, "feo": -1, "fea": -1, "tonto": -1, "tonta": -1,
+}
@@ -0,0 +1,332 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"giiny/internal/events"
+	"giiny/internal/imvu"
+)
+
+const (
+	// externalQueue is how many events are buffered for a slow external
+	// plugin before new ones are dropped
+	externalQueue = 64
+	// minRestartBackoff and maxRestartBackoff bound the wait before a crashed
+	// external plugin is started again, doubled after every crash
+	minRestartBackoff = time.Second
+	maxRestartBackoff = 5 * time.Minute
+	// stableRun is how long a plugin has to run for its backoff to reset
+	stableRun = time.Minute
+)
+
+// Event is what an external plugin receives, one JSON object per line on its
+// stdin. Type is "message", "user_joined", "user_left", "command" or "tick".
+type Event struct {
+	Type    string    `json:"type"`
+	UserID  string    `json:"user_id,omitempty"`
+	To      string    `json:"to,omitempty"`
+	Message string    `json:"message,omitempty"`
+	Command string    `json:"command,omitempty"`
+	Args    []string  `json:"args,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// Action is what an external plugin sends, one JSON object per line on its
+// stdout:
+//
+//	{"type": "register", "commands": ["weather"]}  handle these chat commands
+//	{"type": "say", "text": "..."}                 send a chat message
+//	{"type": "whisper", "user_id": "...", "text": "..."}
+//	{"type": "trigger", "text": "dance"}           play an avatar trigger
+//
+// Anything the plugin writes to stderr is logged.
+type Action struct {
+	Type     string   `json:"type"`
+	Text     string   `json:"text,omitempty"`
+	UserID   string   `json:"user_id,omitempty"`
+	Commands []string `json:"commands,omitempty"`
+}
+
+// External is a plugin running as a separate process, so it can be written
+// in any language. It is told about the room's events and can answer with
+// actions at any time. Actions need the IMVU client, which the plugin learns
+// from the first event it gets. A process that exits is started again with
+// backoff, and gets no events until then.
+type External struct {
+	name    string
+	command []string
+	events  chan Event
+	done    chan struct{}
+	stop    sync.Once
+	client  atomic.Pointer[imvu.IMVU]
+	alive   atomic.Bool
+
+	mu       sync.Mutex
+	cmd      *exec.Cmd
+	commands []string
+}
+
+// StartExternal starts an external plugin running the command
+func StartExternal(name string, command []string) (*External, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("plugin %s: command is empty", name)
+	}
+
+	e := &External{
+		name:    name,
+		command: command,
+		events:  make(chan Event, externalQueue),
+		done:    make(chan struct{}),
+	}
+	exited, err := e.start()
+	if err != nil {
+		return nil, err
+	}
+	go e.supervise(exited)
+
+	return e, nil
+}
+
+// start starts the plugin process and returns a channel closed when it exits
+func (e *External) start() (<-chan struct{}, error) {
+	cmd := exec.Command(e.command[0], e.command[1:]...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", e.name, err)
+	}
+
+	e.mu.Lock()
+	e.cmd = cmd
+	e.mu.Unlock()
+	e.alive.Store(true)
+
+	exited := make(chan struct{})
+	go e.write(stdin, exited)
+
+	// Wait closes the pipes, so it waits for the last actions and log lines
+	// of the plugin to be read
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		e.read(stdout)
+	}()
+	go func() {
+		defer readers.Done()
+		e.logStderr(stderr)
+	}()
+	go func() {
+		readers.Wait()
+		err := cmd.Wait()
+		e.alive.Store(false)
+		log.Printf("Plugin %s exited: %v", e.name, err)
+		close(exited)
+	}()
+	return exited, nil
+}
+
+// supervise starts the plugin again whenever it exits, until it is closed
+func (e *External) supervise(exited <-chan struct{}) {
+	backoff := minRestartBackoff
+	started := time.Now()
+	for {
+		select {
+		case <-exited:
+		case <-e.done:
+			return
+		}
+
+		// Events queued for the dead process and its commands are stale; the
+		// new process registers its commands again
+		e.drain()
+		e.mu.Lock()
+		e.commands = nil
+		e.mu.Unlock()
+
+		if time.Since(started) >= stableRun {
+			backoff = minRestartBackoff
+		}
+		for {
+			log.Printf("Restarting plugin %s in %s", e.name, backoff)
+			select {
+			case <-time.After(backoff):
+			case <-e.done:
+				return
+			}
+			if e.closed() {
+				return
+			}
+			backoff = min(backoff*2, maxRestartBackoff)
+
+			var err error
+			if exited, err = e.start(); err == nil {
+				break
+			}
+			log.Printf("Failed to restart plugin %s: %v", e.name, err)
+		}
+		started = time.Now()
+	}
+}
+
+// drain drops the queued events
+func (e *External) drain() {
+	for {
+		select {
+		case <-e.events:
+		default:
+			return
+		}
+	}
+}
+
+// closed reports whether Close was called
+func (e *External) closed() bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops the plugin process
+func (e *External) Close() error {
+	e.stop.Do(func() { close(e.done) })
+	e.mu.Lock()
+	cmd := e.cmd
+	e.mu.Unlock()
+	return cmd.Process.Kill()
+}
+
+func (e *External) OnMessage(client *imvu.IMVU, msg events.ChatMessage) {
+	e.send(client, Event{Type: "message", UserID: msg.UserID, To: msg.To, Message: msg.Message, Time: msg.ReceivedAt})
+}
+
+func (e *External) OnUserJoined(client *imvu.IMVU, userID string) {
+	e.send(client, Event{Type: "user_joined", UserID: userID, Time: time.Now()})
+}
+
+func (e *External) OnUserLeft(client *imvu.IMVU, userID string) {
+	e.send(client, Event{Type: "user_left", UserID: userID, Time: time.Now()})
+}
+
+// OnCommand handles the commands the plugin registered
+func (e *External) OnCommand(client *imvu.IMVU, userID, name string, args []string) bool {
+	e.mu.Lock()
+	registered := slices.Contains(e.commands, name)
+	e.mu.Unlock()
+	if !registered {
+		return false
+	}
+
+	e.send(client, Event{Type: "command", UserID: userID, Command: name, Args: args, Time: time.Now()})
+	return true
+}
+
+func (e *External) OnTick(client *imvu.IMVU, now time.Time) {
+	e.send(client, Event{Type: "tick", Time: now})
+}
+
+// send queues an event for the plugin without blocking the caller. Events
+// are dropped while the process is down.
+func (e *External) send(client *imvu.IMVU, event Event) {
+	e.client.Store(client)
+	if !e.alive.Load() {
+		return
+	}
+	select {
+	case e.events <- event:
+	default:
+		log.Printf("Plugin %s is not keeping up, dropping %s event", e.name, event.Type)
+	}
+}
+
+// write sends the queued events to the process until it exits
+func (e *External) write(stdin io.WriteCloser, exited <-chan struct{}) {
+	defer stdin.Close()
+
+	encoder := json.NewEncoder(stdin)
+	for {
+		select {
+		case event := <-e.events:
+			if err := encoder.Encode(event); err != nil {
+				log.Printf("Failed to send %s event to plugin %s: %v", event.Type, e.name, err)
+				return
+			}
+		case <-exited:
+			return
+		case <-e.done:
+			return
+		}
+	}
+}
+
+func (e *External) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		var action Action
+		if err := json.Unmarshal(scanner.Bytes(), &action); err != nil {
+			log.Printf("Plugin %s sent an invalid action: %v", e.name, err)
+			continue
+		}
+		if err := e.perform(action); err != nil {
+			log.Printf("Plugin %s: %s failed: %v", e.name, action.Type, err)
+		}
+	}
+}
+
+// perform carries out an action of the plugin
+func (e *External) perform(action Action) error {
+	if action.Type == "register" {
+		e.mu.Lock()
+		e.commands = action.Commands
+		e.mu.Unlock()
+		log.Printf("Plugin %s handles commands: %v", e.name, action.Commands)
+		return nil
+	}
+
+	client := e.client.Load()
+	if client == nil {
+		return fmt.Errorf("not connected to IMVU yet")
+	}
+
+	switch action.Type {
+	case "say":
+		// Avatar commands are refused with imvu.ErrCommandText; running them
+		// is up to the bot
+		return client.SendChatMessage(action.Text)
+	case "whisper":
+		return client.SendWhisper(action.UserID, action.Text)
+	case "trigger":
+		return client.TriggerAction("", action.Text)
+	default:
+		return fmt.Errorf("unknown action")
+	}
+}
+
+func (e *External) logStderr(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		log.Printf("[plugin %s] %s", e.name, scanner.Text())
+	}
+}
@@ -0,0 +1,78 @@
+// Package plugin defines the hooks through which features react to what
+// happens in the room, and the registry they are compiled in through. Each
+// deployment picks the plugins it runs by name in the config.
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"giiny/internal/events"
+	"giiny/internal/imvu"
+)
+
+// ErrUnknown is returned by New for names that weren't registered
+var ErrUnknown = errors.New("unknown plugin")
+
+// Plugin reacts to the events of the room. Hooks may be called from different
+// goroutines and must not block; slow work belongs in a goroutine. Embed Base
+// to implement only some of them.
+type Plugin interface {
+	// OnMessage is called with every chat message of other users, before
+	// the bot handles it
+	OnMessage(client *imvu.IMVU, msg events.ChatMessage)
+	// OnUserJoined is called when a user enters the room
+	OnUserJoined(client *imvu.IMVU, userID string)
+	// OnUserLeft is called when a user leaves the room
+	OnUserLeft(client *imvu.IMVU, userID string)
+	// OnCommand is offered the chat commands the bot doesn't know and
+	// reports whether it handled the command
+	OnCommand(client *imvu.IMVU, userID, name string, args []string) bool
+	// OnTick is called every minute
+	OnTick(client *imvu.IMVU, now time.Time)
+}
+
+// Base implements every hook of Plugin as a no-op
+type Base struct{}
+
+func (Base) OnMessage(*imvu.IMVU, events.ChatMessage)            {}
+func (Base) OnUserJoined(*imvu.IMVU, string)                     {}
+func (Base) OnUserLeft(*imvu.IMVU, string)                       {}
+func (Base) OnCommand(*imvu.IMVU, string, string, []string) bool { return false }
+func (Base) OnTick(*imvu.IMVU, time.Time)                        {}
+
+// Factory creates a plugin. It is called once per process for each enabled
+// plugin.
+type Factory func() Plugin
+
+var registry = map[string]Factory{}
+
+// Register makes a plugin available under name, usually from an init
+// function. Registering a name twice is a programming error and panics.
+func Register(name string, factory Factory) {
+	if _, ok := registry[name]; ok {
+		panic("plugin: registered twice: " + name)
+	}
+	registry[name] = factory
+}
+
+// New creates the plugin registered under name
+func New(name string) (Plugin, error) {
+	factory, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknown, name)
+	}
+	return factory(), nil
+}
+
+// Names returns the names of the registered plugins, sorted
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
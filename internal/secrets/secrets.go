@@ -0,0 +1,166 @@
+// Package secrets keeps credentials in a file encrypted with AES-256-GCM, under
+// a key derived from a passphrase with PBKDF2, so they don't have to be kept in
+// plaintext in the .env file.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Names of the secrets that can be stored
+const (
+	Password     = "password"
+	GeminiAPIKey = "gemini_api_key"
+)
+
+// Names lists every secret that can be stored
+var Names = []string{Password, GeminiAPIKey}
+
+// PassphraseEnv is the environment variable holding the passphrase
+const PassphraseEnv = "GIINY_SECRETS_PASSPHRASE"
+
+// ErrWrongPassphrase is returned when the file can't be decrypted
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted secrets file")
+
+const (
+	saltSize   = 16
+	keySize    = 32
+	iterations = 600_000
+)
+
+// file is the encrypted secrets file. Data is the JSON object of the secrets,
+// sealed with the key derived from the passphrase and Salt.
+type file struct {
+	Salt  []byte `json:"salt"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// Load decrypts the secrets file at path
+func Load(path, passphrase string) (map[string]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets file: %w", err)
+	}
+
+	aead, err := newAEAD(passphrase, f.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Nonce) != aead.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+	plaintext, err := aead.Open(nil, f.Nonce, f.Data, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	values := map[string]string{}
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets: %w", err)
+	}
+	return values, nil
+}
+
+// Save encrypts the secrets into the file at path, readable only by its owner
+func Save(path, passphrase string, values map[string]string) error {
+	if passphrase == "" {
+		return errors.New("passphrase is empty")
+	}
+
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	f := file{Salt: make([]byte, saltSize)}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return err
+	}
+	aead, err := newAEAD(passphrase, f.Salt)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Data = aead.Seal(nil, f.Nonce, plaintext, nil)
+
+	raw, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a failed write doesn't lose the
+	// existing secrets
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".secrets-*")
+	if err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	return nil
+}
+
+// Set stores a single secret, creating the file if it doesn't exist. An empty
+// value removes the secret.
+func Set(path, passphrase, name, value string) error {
+	if !slices.Contains(Names, name) {
+		return fmt.Errorf("unknown secret %q", name)
+	}
+
+	values, err := Load(path, passphrase)
+	if errors.Is(err, os.ErrNotExist) {
+		values = map[string]string{}
+	} else if err != nil {
+		return err
+	}
+
+	if value == "" {
+		delete(values, name)
+	} else {
+		values[name] = value
+	}
+	return Save(path, passphrase, values)
+}
+
+func newAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	if len(salt) != saltSize {
+		return nil, ErrWrongPassphrase
+	}
+
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,111 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	values := map[string]string{Password: "hunter2", GeminiAPIKey: "AIza-key"}
+
+	if err := Save(path, "passphrase", values); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range values {
+		if bytes.Contains(raw, []byte(v)) {
+			t.Errorf("secrets file holds %q in plaintext", v)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("secrets file mode = %o, want 600", perm)
+	}
+
+	got, err := Load(path, "passphrase")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got) != len(values) || got[Password] != values[Password] || got[GeminiAPIKey] != values[GeminiAPIKey] {
+		t.Errorf("Load = %v, want %v", got, values)
+	}
+
+	if _, err := Load(path, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Load with a wrong passphrase: err = %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestLoadTampered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	if err := Save(path, "passphrase", map[string]string{Password: "hunter2"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f file
+	if err := json.Unmarshal(raw, &f); err != nil {
+		t.Fatal(err)
+	}
+	f.Data[0] ^= 1
+	raw, _ = json.Marshal(f)
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path, "passphrase"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Load of a tampered file: err = %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestSaveEmptyPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	if err := Save(path, "", map[string]string{Password: "hunter2"}); err == nil {
+		t.Error("Save with an empty passphrase succeeded")
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("secrets file written without a passphrase: %v", err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+
+	if err := Set(path, "passphrase", Password, "hunter2"); err != nil {
+		t.Fatalf("Set on a missing file: %v", err)
+	}
+	if err := Set(path, "passphrase", GeminiAPIKey, "AIza-key"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := Set(path, "passphrase", Password, ""); err != nil {
+		t.Fatalf("Set to remove: %v", err)
+	}
+	if err := Set(path, "passphrase", "token", "x"); err == nil {
+		t.Error("Set of an unknown secret succeeded")
+	}
+	if err := Set(path, "wrong", Password, "x"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Set with a wrong passphrase: err = %v, want ErrWrongPassphrase", err)
+	}
+
+	got, err := Load(path, "passphrase")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got) != 1 || got[GeminiAPIKey] != "AIza-key" {
+		t.Errorf("Load = %v, want only the Gemini API key", got)
+	}
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// AuditEntry is an IMVU command executed by the bot
+type AuditEntry struct {
+	ID        int64
+	Actor     string
+	Command   string
+	Args      string
+	Result    string
+	CreatedAt time.Time
+}
+
+// AddAudit records an executed command. Result is "ok" or the error.
+func (s *Store) AddAudit(actor, command, args, result string, at time.Time) error {
+	_, err := s.db.Exec(
+		`INSERT INTO audit_log (actor, command, args, result, created_at) VALUES (?, ?, ?, ?, ?)`,
+		actor, command, args, result, at.UTC().Format(time.DateTime),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// RecentAudit returns up to limit of the latest executed commands, newest
+// first
+func (s *Store) RecentAudit(limit int) ([]AuditEntry, error) {
+	rows, err := s.db.Query(
+		`SELECT id, actor, command, args, result, created_at FROM audit_log
+		ORDER BY id DESC LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		var createdAt string
+		if err := rows.Scan(&e.ID, &e.Actor, &e.Command, &e.Args, &e.Result, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		e.CreatedAt = parseTime(createdAt)
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
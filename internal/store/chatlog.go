@@ -0,0 +1,85 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// ChatLine is a message of the room transcript
+type ChatLine struct {
+	ID     int64
+	Queue  string
+	UserID string
+	// Sender is one of the events.Sender constants
+	Sender    string
+	Message   string
+	CreatedAt time.Time
+}
+
+// AddChatLine appends a message to the transcript of the chat queue
+func (s *Store) AddChatLine(queue, userID, sender, message string, at time.Time) error {
+	_, err := s.db.Exec(
+		`INSERT INTO chat_log (queue, user_id, sender, message, created_at) VALUES (?, ?, ?, ?, ?)`,
+		queue, userID, sender, message, at.UTC().Format(time.DateTime),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert chat line: %w", err)
+	}
+	return nil
+}
+
+// RecentChatLines returns up to limit of the latest messages of the chat
+// queue, oldest first
+func (s *Store) RecentChatLines(queue string, limit int) ([]ChatLine, error) {
+	rows, err := s.db.Query(
+		`SELECT id, queue, user_id, sender, message, created_at FROM (
+			SELECT * FROM chat_log WHERE queue = ? ORDER BY id DESC LIMIT ?
+		) ORDER BY id`,
+		queue, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chat log: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []ChatLine
+	for rows.Next() {
+		var l ChatLine
+		var createdAt string
+		if err := rows.Scan(&l.ID, &l.Queue, &l.UserID, &l.Sender, &l.Message, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan chat line: %w", err)
+		}
+		l.CreatedAt = parseTime(createdAt)
+		lines = append(lines, l)
+	}
+
+	return lines, rows.Err()
+}
+
+// ChatLinesSince returns up to limit of the latest messages of the chat queue
+// sent after since, oldest first
+func (s *Store) ChatLinesSince(queue string, since time.Time, limit int) ([]ChatLine, error) {
+	rows, err := s.db.Query(
+		`SELECT id, queue, user_id, sender, message, created_at FROM (
+			SELECT * FROM chat_log WHERE queue = ? AND created_at >= ? ORDER BY id DESC LIMIT ?
+		) ORDER BY id`,
+		queue, since.UTC().Format(time.DateTime), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chat log: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []ChatLine
+	for rows.Next() {
+		var l ChatLine
+		var createdAt string
+		if err := rows.Scan(&l.ID, &l.Queue, &l.UserID, &l.Sender, &l.Message, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan chat line: %w", err)
+		}
+		l.CreatedAt = parseTime(createdAt)
+		lines = append(lines, l)
+	}
+
+	return lines, rows.Err()
+}
@@ -0,0 +1,47 @@
+package store
+
+import "fmt"
+
+// Score is the points of a user in a game
+type Score struct {
+	UserID string
+	Points int64
+}
+
+// AddScore adds points to the user's score in the game
+func (s *Store) AddScore(game, userID string, points int64) error {
+	_, err := s.db.Exec(
+		`INSERT INTO game_scores (game, user_id, points) VALUES (?, ?, ?)
+		ON CONFLICT (game, user_id) DO UPDATE SET
+			points = points + excluded.points,
+			updated_at = datetime('now')`,
+		game, userID, points,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add score: %w", err)
+	}
+	return nil
+}
+
+// TopScores returns the best scores of the game, highest first
+func (s *Store) TopScores(game string, limit int) ([]Score, error) {
+	rows, err := s.db.Query(
+		`SELECT user_id, points FROM game_scores WHERE game = ?
+		ORDER BY points DESC, updated_at ASC LIMIT ?`,
+		game, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scores: %w", err)
+	}
+	defer rows.Close()
+
+	var scores []Score
+	for rows.Next() {
+		var score Score
+		if err := rows.Scan(&score.UserID, &score.Points); err != nil {
+			return nil, fmt.Errorf("failed to scan score: %w", err)
+		}
+		scores = append(scores, score)
+	}
+	return scores, rows.Err()
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// GreeterScore is the greeter score of the bot on a day (UTC)
+type GreeterScore struct {
+	Day   string
+	Score int
+}
+
+// SetGreeterScore records the greeter score of the day of at, replacing an
+// earlier reading of the same day
+func (s *Store) SetGreeterScore(at time.Time, score int) error {
+	_, err := s.db.Exec(
+		`INSERT INTO greeter_scores (day, score) VALUES (?, ?)
+		ON CONFLICT (day) DO UPDATE SET score = excluded.score`,
+		usageDay(at), score,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record greeter score: %w", err)
+	}
+	return nil
+}
+
+// GreeterScoresSince returns the greeter scores recorded from the day of
+// since on, oldest first
+func (s *Store) GreeterScoresSince(since time.Time) ([]GreeterScore, error) {
+	rows, err := s.db.Query(
+		`SELECT day, score FROM greeter_scores WHERE day >= ? ORDER BY day`,
+		usageDay(since),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query greeter scores: %w", err)
+	}
+	defer rows.Close()
+
+	var scores []GreeterScore
+	for rows.Next() {
+		var g GreeterScore
+		if err := rows.Scan(&g.Day, &g.Score); err != nil {
+			return nil, fmt.Errorf("failed to scan greeter score: %w", err)
+		}
+		scores = append(scores, g)
+	}
+	return scores, rows.Err()
+}
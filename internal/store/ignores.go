@@ -0,0 +1,59 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Ignore makes the bot ignore a user. A zero expiresAt ignores the user until
+// Unignore is called.
+func (s *Store) Ignore(userID, reason string, expiresAt time.Time) error {
+	var expires sql.NullString
+	if !expiresAt.IsZero() {
+		expires = sql.NullString{String: expiresAt.UTC().Format(time.DateTime), Valid: true}
+	}
+
+	_, err := s.db.Exec(
+		`INSERT INTO ignores (user_id, reason, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET reason = excluded.reason, expires_at = excluded.expires_at`,
+		userID, reason, expires,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to ignore user: %w", err)
+	}
+	return nil
+}
+
+// Unignore removes a user from the ignore list and reports whether the user
+// was on it
+func (s *Store) Unignore(userID string) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM ignores WHERE user_id = ?`, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unignore user: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to unignore user: %w", err)
+	}
+	return n > 0, nil
+}
+
+// IsIgnored reports whether the user is ignored at the given time
+func (s *Store) IsIgnored(userID string, at time.Time) (bool, error) {
+	var expires sql.NullString
+	err := s.db.QueryRow(`SELECT expires_at FROM ignores WHERE user_id = ?`, userID).Scan(&expires)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to query ignores: %w", err)
+	}
+
+	if !expires.Valid {
+		return true, nil
+	}
+	return at.Before(parseTime(expires.String)), nil
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Memory represents a fact the bot remembers about a user
+type Memory struct {
+	ID        int64
+	UserID    string
+	Fact      string
+	Embedding []float32
+	CreatedAt time.Time
+}
+
+// AddMemory stores a new fact about a user along with its embedding
+func (s *Store) AddMemory(userID, fact string, embedding []float32) error {
+	_, err := s.db.Exec(
+		`INSERT INTO memories (user_id, fact, embedding) VALUES (?, ?, ?)`,
+		userID, fact, encodeEmbedding(embedding),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert memory: %w", err)
+	}
+	return nil
+}
+
+// Memories returns every fact stored about a user, oldest first
+func (s *Store) Memories(userID string) ([]Memory, error) {
+	rows, err := s.db.Query(
+		`SELECT id, user_id, fact, embedding, created_at FROM memories WHERE user_id = ? ORDER BY id`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query memories: %w", err)
+	}
+	defer rows.Close()
+
+	var memories []Memory
+	for rows.Next() {
+		var m Memory
+		var embedding []byte
+		var createdAt string
+		if err := rows.Scan(&m.ID, &m.UserID, &m.Fact, &embedding, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan memory: %w", err)
+		}
+		m.Embedding = decodeEmbedding(embedding)
+		m.CreatedAt = parseTime(createdAt)
+		memories = append(memories, m)
+	}
+
+	return memories, rows.Err()
+}
+
+// encodeEmbedding packs the vector as little-endian float32 values
+func encodeEmbedding(v []float32) []byte {
+	buf := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(f))
+	}
+	return buf
+}
+
+func decodeEmbedding(buf []byte) []float32 {
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[i*4:]))
+	}
+	return v
+}
+
+// parseTime parses the timestamps written by SQLite's datetime('now')
+func parseTime(s string) time.Time {
+	t, err := time.Parse(time.DateTime, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
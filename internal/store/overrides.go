@@ -0,0 +1,50 @@
+package store
+
+import "fmt"
+
+// ConfigOverrides returns the settings changed with "!config set", by key
+func (s *Store) ConfigOverrides() (map[string]string, error) {
+	rows, err := s.db.Query(`SELECT key, value FROM config_overrides`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query config overrides: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := map[string]string{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan config override: %w", err)
+		}
+		overrides[key] = value
+	}
+	return overrides, rows.Err()
+}
+
+// SetConfigOverride stores the value of a setting changed by the user
+func (s *Store) SetConfigOverride(key, value, userID string) error {
+	_, err := s.db.Exec(
+		`INSERT INTO config_overrides (key, value, updated_by, updated_at) VALUES (?, ?, ?, datetime('now'))
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, updated_by = excluded.updated_by,
+			updated_at = excluded.updated_at`,
+		key, value, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save config override: %w", err)
+	}
+	return nil
+}
+
+// DeleteConfigOverride goes back to the config file's value of a setting and
+// reports whether it was overridden
+func (s *Store) DeleteConfigOverride(key string) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM config_overrides WHERE key = ?`, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete config override: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete config override: %w", err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,50 @@
+package store
+
+import "fmt"
+
+// forgettable are the tables with data about a user that ForgetUser deletes.
+// Ignores, the audit log and spending are kept as records of the bot's own
+// actions.
+var forgettable = []string{"memories", "chat_log", "usage", "xp", "game_scores", "room_chatters", "user_profiles"}
+
+// ForgetUser deletes everything stored about the user
+func (s *Store) ForgetUser(userID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range forgettable {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE user_id = ?`, userID); err != nil {
+			return fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// UserChatLines returns every message of the user in the transcripts, oldest
+// first
+func (s *Store) UserChatLines(userID string) ([]ChatLine, error) {
+	rows, err := s.db.Query(
+		`SELECT id, queue, user_id, sender, message, created_at FROM chat_log WHERE user_id = ? ORDER BY id`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chat log: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []ChatLine
+	for rows.Next() {
+		var l ChatLine
+		var createdAt string
+		if err := rows.Scan(&l.ID, &l.Queue, &l.UserID, &l.Sender, &l.Message, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan chat line: %w", err)
+		}
+		l.CreatedAt = parseTime(createdAt)
+		lines = append(lines, l)
+	}
+
+	return lines, rows.Err()
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Profile is what a user told the bot about themselves with "!me"
+type Profile struct {
+	UserID string
+	// Nickname is what the bot calls the user instead of their display name
+	Nickname string
+	Pronouns string
+	// Language is the language whispers and answers to the user are in,
+	// instead of the room's
+	Language string
+	// NoPing keeps the bot from greeting or otherwise calling out the user
+	NoPing bool
+	// NoAI keeps the user's messages away from Gemini
+	NoAI      bool
+	UpdatedAt time.Time
+}
+
+// Profile returns the profile of the user, empty if they never set one
+func (s *Store) Profile(userID string) (Profile, error) {
+	p := Profile{UserID: userID}
+	var updatedAt string
+	err := s.db.QueryRow(
+		`SELECT nickname, pronouns, language, no_ping, no_ai, updated_at FROM user_profiles WHERE user_id = ?`,
+		userID,
+	).Scan(&p.Nickname, &p.Pronouns, &p.Language, &p.NoPing, &p.NoAI, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, nil
+	}
+	if err != nil {
+		return p, fmt.Errorf("failed to query profile: %w", err)
+	}
+	p.UpdatedAt = parseTime(updatedAt)
+	return p, nil
+}
+
+// SaveProfile creates or replaces the profile of p.UserID
+func (s *Store) SaveProfile(p Profile) error {
+	_, err := s.db.Exec(
+		`INSERT INTO user_profiles (user_id, nickname, pronouns, language, no_ping, no_ai, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, datetime('now'))
+		ON CONFLICT (user_id) DO UPDATE SET nickname = excluded.nickname, pronouns = excluded.pronouns,
+			language = excluded.language, no_ping = excluded.no_ping, no_ai = excluded.no_ai,
+			updated_at = excluded.updated_at`,
+		p.UserID, p.Nickname, p.Pronouns, p.Language, p.NoPing, p.NoAI,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// AddSpending records credits spent on a product, gifted to recipient or
+// bought for the bot itself
+func (s *Store) AddSpending(kind, recipient, productID string, amount int64, at time.Time) error {
+	_, err := s.db.Exec(
+		`INSERT INTO spending (kind, recipient, product_id, amount, day, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		kind, recipient, productID, amount, usageDay(at), at.UTC().Format(time.DateTime),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record spending: %w", err)
+	}
+	return nil
+}
+
+// DailySpending returns the credits spent on the day of at
+func (s *Store) DailySpending(at time.Time) (int64, error) {
+	var total int64
+	err := s.db.QueryRow(
+		`SELECT COALESCE(SUM(amount), 0) FROM spending WHERE day = ?`,
+		usageDay(at),
+	).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query spending: %w", err)
+	}
+	return total, nil
+}
+
+// RecipientSpending returns the credits spent on recipient on the day of at
+func (s *Store) RecipientSpending(recipient string, at time.Time) (int64, error) {
+	var total int64
+	err := s.db.QueryRow(
+		`SELECT COALESCE(SUM(amount), 0) FROM spending WHERE day = ? AND recipient = ?`,
+		usageDay(at), recipient,
+	).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query spending: %w", err)
+	}
+	return total, nil
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// HourActivity is the activity of a room during an hour (UTC)
+type HourActivity struct {
+	Hour          time.Time
+	Messages      int
+	Chatters      int
+	PeakOccupancy int
+}
+
+// RoomActivity is the activity of a room over a period
+type RoomActivity struct {
+	Room          string
+	Since         time.Time
+	Messages      int
+	Chatters      int
+	PeakOccupancy int
+	// Hours are the hours with any activity, oldest first
+	Hours []HourActivity
+}
+
+func activityHour(t time.Time) string {
+	return t.UTC().Truncate(time.Hour).Format(time.DateTime)
+}
+
+// AddRoomMessage counts a message of the user in the room at the hour of at
+func (s *Store) AddRoomMessage(room, userID string, at time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hour := activityHour(at)
+	if _, err := tx.Exec(
+		`INSERT INTO room_activity (room, hour, messages) VALUES (?, ?, 1)
+		ON CONFLICT (room, hour) DO UPDATE SET messages = messages + 1`,
+		room, hour,
+	); err != nil {
+		return fmt.Errorf("failed to count room message: %w", err)
+	}
+	if _, err := tx.Exec(
+		`INSERT OR IGNORE INTO room_chatters (room, hour, user_id) VALUES (?, ?, ?)`,
+		room, hour, userID,
+	); err != nil {
+		return fmt.Errorf("failed to record room chatter: %w", err)
+	}
+	return tx.Commit()
+}
+
+// RecordOccupancy raises the peak occupancy of the room at the hour of at to
+// count, if it is higher
+func (s *Store) RecordOccupancy(room string, at time.Time, count int) error {
+	_, err := s.db.Exec(
+		`INSERT INTO room_activity (room, hour, peak_occupancy) VALUES (?, ?, ?)
+		ON CONFLICT (room, hour) DO UPDATE SET peak_occupancy = MAX(peak_occupancy, excluded.peak_occupancy)`,
+		room, activityHour(at), count,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record room occupancy: %w", err)
+	}
+	return nil
+}
+
+// RoomActivitySince returns the activity of the room from the hour of since
+// on
+func (s *Store) RoomActivitySince(room string, since time.Time) (RoomActivity, error) {
+	activity := RoomActivity{Room: room, Since: since}
+	from := activityHour(since)
+
+	rows, err := s.db.Query(
+		`SELECT a.hour, a.messages, a.peak_occupancy, COUNT(c.user_id)
+		FROM room_activity a
+		LEFT JOIN room_chatters c ON c.room = a.room AND c.hour = a.hour
+		WHERE a.room = ? AND a.hour >= ?
+		GROUP BY a.hour ORDER BY a.hour`,
+		room, from,
+	)
+	if err != nil {
+		return activity, fmt.Errorf("failed to query room activity: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var h HourActivity
+		var hour string
+		if err := rows.Scan(&hour, &h.Messages, &h.PeakOccupancy, &h.Chatters); err != nil {
+			return activity, fmt.Errorf("failed to scan room activity: %w", err)
+		}
+		h.Hour = parseTime(hour)
+		activity.Messages += h.Messages
+		activity.PeakOccupancy = max(activity.PeakOccupancy, h.PeakOccupancy)
+		activity.Hours = append(activity.Hours, h)
+	}
+	if err := rows.Err(); err != nil {
+		return activity, err
+	}
+
+	// Users who chatted in several hours are counted once over the period
+	err = s.db.QueryRow(
+		`SELECT COUNT(DISTINCT user_id) FROM room_chatters WHERE room = ? AND hour >= ?`,
+		room, from,
+	).Scan(&activity.Chatters)
+	if err != nil {
+		return activity, fmt.Errorf("failed to count room chatters: %w", err)
+	}
+	return activity, nil
+}
+
+// BusiestHours returns up to n hours of the day (UTC) with the most messages
+// over the period, busiest first
+func (a RoomActivity) BusiestHours(n int) []int {
+	var messages [24]int
+	for _, h := range a.Hours {
+		messages[h.Hour.UTC().Hour()] += h.Messages
+	}
+
+	var hours []int
+	for hour, count := range messages {
+		if count > 0 {
+			hours = append(hours, hour)
+		}
+	}
+	sort.SliceStable(hours, func(i, j int) bool {
+		return messages[hours[i]] > messages[hours[j]]
+	})
+	if len(hours) > n {
+		hours = hours[:n]
+	}
+	return hours
+}
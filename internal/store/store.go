@@ -0,0 +1,34 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Store wraps the SQLite database used to persist bot state. The schema is
+// managed by the migrations in the migrations directory (see the Makefile).
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens the SQLite database at path
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000&_foreign_keys=on")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the underlying database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
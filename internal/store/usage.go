@@ -0,0 +1,68 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// Usage is the number of LLM requests and tokens spent in a day
+type Usage struct {
+	Requests       int64
+	PromptTokens   int64
+	ResponseTokens int64
+}
+
+// Total returns the number of tokens counted against the daily budget
+func (u Usage) Total() int64 {
+	return u.PromptTokens + u.ResponseTokens
+}
+
+// usageDay is the key of the usage table, a UTC date
+func usageDay(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// AddUsage adds one request and its token counts to the user's usage of the
+// day of at
+func (s *Store) AddUsage(userID string, at time.Time, promptTokens, responseTokens int64) error {
+	_, err := s.db.Exec(
+		`INSERT INTO usage (user_id, day, requests, prompt_tokens, response_tokens) VALUES (?, ?, 1, ?, ?)
+		ON CONFLICT (user_id, day) DO UPDATE SET
+			requests = requests + 1,
+			prompt_tokens = prompt_tokens + excluded.prompt_tokens,
+			response_tokens = response_tokens + excluded.response_tokens`,
+		userID, usageDay(at), promptTokens, responseTokens,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
+
+// UserUsage returns the usage of a user on the day of at
+func (s *Store) UserUsage(userID string, at time.Time) (Usage, error) {
+	var u Usage
+	err := s.db.QueryRow(
+		`SELECT COALESCE(SUM(requests), 0), COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(response_tokens), 0)
+		FROM usage WHERE user_id = ? AND day = ?`,
+		userID, usageDay(at),
+	).Scan(&u.Requests, &u.PromptTokens, &u.ResponseTokens)
+	if err != nil {
+		return Usage{}, fmt.Errorf("failed to query usage: %w", err)
+	}
+	return u, nil
+}
+
+// DailyUsage returns the usage of every user on the day of at
+func (s *Store) DailyUsage(at time.Time) (Usage, error) {
+	var u Usage
+	err := s.db.QueryRow(
+		`SELECT COALESCE(SUM(requests), 0), COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(response_tokens), 0)
+		FROM usage WHERE day = ?`,
+		usageDay(at),
+	).Scan(&u.Requests, &u.PromptTokens, &u.ResponseTokens)
+	if err != nil {
+		return Usage{}, fmt.Errorf("failed to query usage: %w", err)
+	}
+	return u, nil
+}
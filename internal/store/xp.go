@@ -0,0 +1,106 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Activity is what a user did in the room and the XP it earned
+type Activity struct {
+	UserID   string
+	Messages int64
+	Seconds  int64
+	XP       int64
+}
+
+// AddActivity adds messages and seconds spent in the room to the user's
+// activity, along with points of XP limited by the daily cap (UTC days; 0 means
+// no cap). It returns the XP actually awarded.
+func (s *Store) AddActivity(userID string, at time.Time, messages, seconds, points, dailyCap int64) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var day string
+	var dayXP int64
+	err = tx.QueryRow(`SELECT day, day_xp FROM xp WHERE user_id = ?`, userID).Scan(&day, &dayXP)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to query xp: %w", err)
+	}
+
+	today := usageDay(at)
+	if day != today {
+		dayXP = 0
+	}
+	if dailyCap > 0 {
+		points = max(min(points, dailyCap-dayXP), 0)
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO xp (user_id, messages, seconds, xp, day, day_xp) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			messages = messages + excluded.messages,
+			seconds = seconds + excluded.seconds,
+			xp = xp + excluded.xp,
+			day = excluded.day,
+			day_xp = ?,
+			updated_at = datetime('now')`,
+		userID, messages, seconds, points, today, points, dayXP+points,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add activity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit activity: %w", err)
+	}
+	return points, nil
+}
+
+// UserActivity returns the activity of the user and their position in the
+// leaderboard, starting at 1. A user without activity has rank 0.
+func (s *Store) UserActivity(userID string) (Activity, int, error) {
+	a := Activity{UserID: userID}
+	err := s.db.QueryRow(
+		`SELECT messages, seconds, xp FROM xp WHERE user_id = ?`,
+		userID,
+	).Scan(&a.Messages, &a.Seconds, &a.XP)
+	if errors.Is(err, sql.ErrNoRows) {
+		return a, 0, nil
+	}
+	if err != nil {
+		return Activity{}, 0, fmt.Errorf("failed to query xp: %w", err)
+	}
+
+	var ahead int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM xp WHERE xp > ?`, a.XP).Scan(&ahead); err != nil {
+		return Activity{}, 0, fmt.Errorf("failed to query rank: %w", err)
+	}
+	return a, ahead + 1, nil
+}
+
+// Leaderboard returns the users with the most XP, highest first
+func (s *Store) Leaderboard(limit int) ([]Activity, error) {
+	rows, err := s.db.Query(
+		`SELECT user_id, messages, seconds, xp FROM xp ORDER BY xp DESC, updated_at ASC LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	var board []Activity
+	for rows.Next() {
+		var a Activity
+		if err := rows.Scan(&a.UserID, &a.Messages, &a.Seconds, &a.XP); err != nil {
+			return nil, fmt.Errorf("failed to scan xp: %w", err)
+		}
+		board = append(board, a)
+	}
+	return board, rows.Err()
+}
@@ -0,0 +1,151 @@
+// Package telegram is a minimal Telegram Bot API client used as an admin
+// channel: the owner sends commands from a chat and receives alerts in it.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const apiURL = "https://api.telegram.org"
+
+// pollTimeout is the long polling timeout of getUpdates
+const pollTimeout = 50 * time.Second
+
+// Config configures the admin channel. Only messages from ChatID are
+// accepted, and alerts are sent to it.
+type Config struct {
+	Enabled bool   `json:"enabled"`
+	Token   string `json:"token"`
+	ChatID  int64  `json:"chat_id"`
+	// AlertKeywords raise an alert when said in the room by someone else
+	AlertKeywords []string `json:"alert_keywords"`
+}
+
+type Client struct {
+	token      string
+	chatID     int64
+	baseURL    string
+	httpClient *http.Client
+}
+
+func New(cfg Config) *Client {
+	return &Client{
+		token:   cfg.Token,
+		chatID:  cfg.ChatID,
+		baseURL: apiURL,
+		httpClient: &http.Client{
+			Timeout: pollTimeout + 10*time.Second,
+		},
+	}
+}
+
+type response struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+type update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *message `json:"message"`
+}
+
+type message struct {
+	Chat struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+	Text string `json:"text"`
+}
+
+// call invokes a Bot API method and decodes its result into v
+func (c *Client) call(ctx context.Context, method string, params any, v any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s request: %w", method, err)
+	}
+
+	endpoint := fmt.Sprintf("%s/bot%s/%s", c.baseURL, url.PathEscape(c.token), method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// The error contains the URL, and therefore the token
+		return fmt.Errorf("%s request failed", method)
+	}
+	defer resp.Body.Close()
+
+	var res response
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("failed to parse %s response: %w", method, err)
+	}
+	if !res.OK {
+		return fmt.Errorf("%s failed: %s", method, res.Description)
+	}
+
+	if v != nil {
+		if err := json.Unmarshal(res.Result, v); err != nil {
+			return fmt.Errorf("failed to parse %s result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// Send sends a message to the admin chat
+func (c *Client) Send(text string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return c.call(ctx, "sendMessage", map[string]any{
+		"chat_id": c.chatID,
+		"text":    text,
+	}, nil)
+}
+
+// Poll long-polls for messages of the admin chat and calls handler with their
+// text until the context is cancelled. Messages from other chats are dropped.
+func (c *Client) Poll(ctx context.Context, handler func(text string)) {
+	var offset int64
+	for {
+		var updates []update
+		err := c.call(ctx, "getUpdates", map[string]any{
+			"offset":          offset,
+			"timeout":         int(pollTimeout.Seconds()),
+			"allowed_updates": []string{"message"},
+		}, &updates)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("Telegram: %v", err)
+			select {
+			case <-time.After(5 * time.Second):
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if u.Message == nil || u.Message.Text == "" {
+				continue
+			}
+			if u.Message.Chat.ID != c.chatID {
+				log.Printf("Telegram: ignoring message from chat %d", u.Message.Chat.ID)
+				continue
+			}
+			handler(u.Message.Text)
+		}
+	}
+}
@@ -0,0 +1 @@
+DROP TABLE memories;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE usage;
\ No newline at end of file
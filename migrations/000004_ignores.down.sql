@@ -0,0 +1 @@
+DROP TABLE ignores;
\ No newline at end of file
@@ -0,0 +1,6 @@
+CREATE TABLE ignores (
+    user_id TEXT PRIMARY KEY,
+    reason TEXT NOT NULL DEFAULT '',
+    expires_at TEXT,
+    created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
@@ -0,0 +1 @@
+DROP TABLE chat_log;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE game_scores;
\ No newline at end of file
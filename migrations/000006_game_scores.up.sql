@@ -0,0 +1,7 @@
+CREATE TABLE game_scores (
+    game TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    points INTEGER NOT NULL DEFAULT 0,
+    updated_at TEXT NOT NULL DEFAULT (datetime('now')),
+    PRIMARY KEY (game, user_id)
+);
@@ -0,0 +1 @@
+DROP TABLE xp;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE xp (
+    user_id TEXT PRIMARY KEY,
+    messages INTEGER NOT NULL DEFAULT 0,
+    seconds INTEGER NOT NULL DEFAULT 0,
+    xp INTEGER NOT NULL DEFAULT 0,
+    day TEXT NOT NULL,
+    day_xp INTEGER NOT NULL DEFAULT 0,
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX xp_xp ON xp (xp);
@@ -0,0 +1 @@
+DROP TABLE spending;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE chat_log DROP COLUMN sender;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE chat_log ADD COLUMN sender TEXT NOT NULL DEFAULT 'human';
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE greeter_scores;
\ No newline at end of file
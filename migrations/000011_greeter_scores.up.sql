@@ -0,0 +1,4 @@
+CREATE TABLE greeter_scores (
+    day TEXT PRIMARY KEY,
+    score INTEGER NOT NULL
+);
\ No newline at end of file
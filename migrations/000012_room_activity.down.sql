@@ -0,0 +1,2 @@
+DROP TABLE room_chatters;
+DROP TABLE room_activity;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE room_activity (
+    room TEXT NOT NULL,
+    hour TEXT NOT NULL,
+    messages INTEGER NOT NULL DEFAULT 0,
+    peak_occupancy INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (room, hour)
+);
+
+CREATE TABLE room_chatters (
+    room TEXT NOT NULL,
+    hour TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    PRIMARY KEY (room, hour, user_id)
+);
\ No newline at end of file
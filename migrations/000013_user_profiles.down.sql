@@ -0,0 +1 @@
+DROP TABLE user_profiles;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE user_profiles (
+    user_id TEXT PRIMARY KEY,
+    nickname TEXT NOT NULL DEFAULT '',
+    pronouns TEXT NOT NULL DEFAULT '',
+    language TEXT NOT NULL DEFAULT '',
+    no_ping INTEGER NOT NULL DEFAULT 0,
+    no_ai INTEGER NOT NULL DEFAULT 0,
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE config_overrides;
\ No newline at end of file
@@ -0,0 +1,6 @@
+CREATE TABLE config_overrides (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_by TEXT NOT NULL,
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
\ No newline at end of file